@@ -5,11 +5,10 @@ go 1.24.0
 require (
 	github.com/go-playground/assert/v2 v2.2.0
 	github.com/google/go-querystring v1.1.0
+	github.com/gorilla/websocket v1.5.3
 	github.com/joho/godotenv v1.5.1
 	github.com/shopspring/decimal v1.4.0
+	go.etcd.io/bbolt v1.4.3
 )
 
-require (
-	github.com/coder/websocket v1.8.13 // indirect
-	github.com/gorilla/websocket v1.5.3 // indirect
-)
+require golang.org/x/sys v0.29.0 // indirect
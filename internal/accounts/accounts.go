@@ -0,0 +1,445 @@
+package accounts
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/jamesonhm/gochain/internal/calendar"
+	"github.com/jamesonhm/gochain/internal/clock"
+	"github.com/jamesonhm/gochain/internal/dt"
+	"github.com/jamesonhm/gochain/internal/dxlink"
+	"github.com/jamesonhm/gochain/internal/executor"
+	"github.com/jamesonhm/gochain/internal/journal"
+	"github.com/jamesonhm/gochain/internal/options"
+	"github.com/jamesonhm/gochain/internal/strategy"
+	"github.com/jamesonhm/gochain/internal/tasty"
+)
+
+// Config maps account aliases used in strategy files to account numbers
+type Config struct {
+	// alias or account number used by strategies that don't name an account
+	Default string            `json:"default"`
+	Aliases map[string]string `json:"aliases"`
+}
+
+func LoadConfig(fpath string) (Config, error) {
+	var cfg Config
+	data, err := os.ReadFile(fpath)
+	if err != nil {
+		return cfg, err
+	}
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return cfg, fmt.Errorf("invalid accounts config %s: %w", fpath, err)
+	}
+	return cfg, nil
+}
+
+// Includes reports whether the account should be traded, all accounts are
+// traded when no aliases are configured
+func (c Config) Includes(acctNum string) bool {
+	if len(c.Aliases) == 0 {
+		return true
+	}
+	for _, num := range c.Aliases {
+		if num == acctNum {
+			return true
+		}
+	}
+	return c.Default == acctNum
+}
+
+// DefaultNumber resolves the default account to an account number, empty if not configured
+func (c Config) DefaultNumber() string {
+	if num, ok := c.Aliases[c.Default]; ok {
+		return num
+	}
+	return c.Default
+}
+
+func (c Config) aliasFor(acctNum string) string {
+	for alias, num := range c.Aliases {
+		if num == acctNum {
+			return alias
+		}
+	}
+	return ""
+}
+
+type Options struct {
+	StateFile string
+	// legacy JSON state file imported into the state store once, if it exists
+	ImportFile  string
+	JournalFile string
+	// how often open trades are marked and closed trades journaled
+	MarkInterval    time.Duration
+	Workers         int
+	JobTimeout      time.Duration
+	LiveOrder       bool
+	Stream          bool
+	BalanceInterval time.Duration
+}
+
+// Account holds everything bound to a single broker account. Errors are kept
+// on the account so a failing account only disables its own strategies
+type Account struct {
+	Number   string
+	Alias    string
+	Status   *strategy.Status
+	Streamer *tasty.AccountStreamer
+	Executor *executor.Engine
+	Balances *Balances
+	Journal  *journal.Journal
+	api      *tasty.TastyAPI
+	marks    journal.MarkProvider
+	clock    clock.Clock
+	opts     Options
+	mu       sync.RWMutex
+	err      error
+	// the streamer is connected or reconnecting
+	streaming bool
+}
+
+func New(
+	ctx context.Context,
+	number string,
+	alias string,
+	api *tasty.TastyAPI,
+	options *dxlink.DxLinkClient,
+	cal *calendar.Calendar,
+	clk clock.Clock,
+	opts Options,
+) (*Account, error) {
+	status, err := strategy.NewStatus(opts.StateFile)
+	if err != nil {
+		return nil, fmt.Errorf("account %s: %w", number, err)
+	}
+	if opts.ImportFile != "" {
+		count, err := status.ImportJSON(opts.ImportFile)
+		if err != nil {
+			status.Close()
+			return nil, fmt.Errorf("account %s: import %s: %w", number, opts.ImportFile, err)
+		}
+		if count > 0 {
+			slog.Info("imported legacy state file", "account", number, "file", opts.ImportFile, "orders", count)
+		}
+	}
+	jrnl, err := journal.Open(opts.JournalFile)
+	if err != nil {
+		status.Close()
+		return nil, fmt.Errorf("account %s: journal: %w", number, err)
+	}
+	streamer := tasty.NewAccountStreamer(
+		ctx,
+		number,
+		api.GetToken(),
+		status,
+		clk,
+		api.Env == tasty.TastyProd,
+	)
+	// renewed sessions authorize the streamer's reconnects
+	api.OnSessionToken(streamer.SetToken)
+	return &Account{
+		Number:   number,
+		Alias:    alias,
+		Status:   status,
+		Streamer: streamer,
+		Executor: executor.NewEngine(api, number, options, cal, clk, status, opts.Workers, opts.JobTimeout, ctx, opts.LiveOrder),
+		Balances: NewBalances(api, number, clk),
+		Journal:  jrnl,
+		api:      api,
+		marks:    options,
+		clock:    clk,
+		opts:     opts,
+	}, nil
+}
+
+func (a *Account) String() string {
+	if a.Alias != "" {
+		return fmt.Sprintf("%s (%s)", a.Alias, a.Number)
+	}
+	return a.Number
+}
+
+// Start reconciles the account state with the broker, then connects the account
+// streamer and starts the balance refresh. The account is marked unhealthy on failure
+// until a later Prepare succeeds
+func (a *Account) Start(ctx context.Context) {
+	go a.Balances.Run(ctx, a.opts.BalanceInterval)
+	go a.trackTrades(ctx)
+
+	if err := a.Reconcile(ctx); err != nil {
+		a.setErr(err)
+		return
+	}
+	if a.opts.Stream {
+		go func() {
+			if err := a.connectStreamer(); err != nil {
+				a.setErr(err)
+			}
+		}()
+	}
+}
+
+// Prepare readies the account for a session, reconciling with the broker and
+// reconnecting a streamer that gave up. An account disabled by an earlier failure
+// can trade again once both succeed
+func (a *Account) Prepare(ctx context.Context) error {
+	if err := a.Reconcile(ctx); err != nil {
+		a.setErr(err)
+		return err
+	}
+	if a.opts.Stream {
+		if err := a.connectStreamer(); err != nil {
+			a.setErr(err)
+			return err
+		}
+	}
+	a.clearErr()
+	return nil
+}
+
+// connectStreamer connects the account streamer unless it is already connected,
+// the account is marked unhealthy when the streamer gives up reconnecting
+func (a *Account) connectStreamer() error {
+	a.mu.Lock()
+	if a.streaming {
+		a.mu.Unlock()
+		return nil
+	}
+	a.streaming = true
+	a.mu.Unlock()
+
+	if err := a.Streamer.Connect(); err != nil {
+		a.setStreaming(false)
+		return fmt.Errorf("account streamer: %w", err)
+	}
+	done := a.Streamer.Done()
+	go func() {
+		<-done
+		a.setStreaming(false)
+		if err := a.Streamer.Err(); err != nil {
+			a.setErr(fmt.Errorf("account streamer: %w", err))
+		}
+	}()
+	return nil
+}
+
+func (a *Account) setStreaming(streaming bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.streaming = streaming
+}
+
+// Reconcile brings the strategy order states in line with the broker's orders and positions
+func (a *Account) Reconcile(ctx context.Context) error {
+	report, err := a.Status.Reconcile(ctx, a.api, a.Number, a.clock.Now())
+	if err != nil {
+		return fmt.Errorf("reconcile: %w", err)
+	}
+	for _, change := range report.Changed {
+		slog.Info("Reconciled order state", "account", a.String(), "change", change)
+	}
+	for _, pos := range report.Orphans {
+		slog.Warn("Orphan position not owned by any strategy", "account", a.String(), "symbol", pos.Symbol, "quantity", pos.Quantity, "direction", pos.QuantityDirection)
+	}
+	return nil
+}
+
+// DailyReport journals the trades closed so far and returns the stats of the trades
+// closed on the New York date of day
+func (a *Account) DailyReport(day time.Time) (journal.Stats, error) {
+	a.syncJournal(a.Status.AllOrders())
+	from := dt.Midnight(day.In(dt.TZNY()))
+	trades, err := a.Journal.Trades(journal.Filter{From: from, To: from.AddDate(0, 0, 1)})
+	if err != nil {
+		return journal.Stats{}, fmt.Errorf("daily report: %w", err)
+	}
+	return journal.ComputeStats(trades), nil
+}
+
+func (a *Account) Close() {
+	if a.opts.Stream {
+		if err := a.Streamer.Close(); err != nil {
+			slog.Error("error closing account streamer", "account", a.String(), "error", err)
+		}
+	}
+	if err := a.Status.Close(); err != nil {
+		slog.Error("error closing state store", "account", a.String(), "error", err)
+	}
+	if err := a.Journal.Close(); err != nil {
+		slog.Error("error closing journal", "account", a.String(), "error", err)
+	}
+}
+
+// OpenLegSymbols lists the DxLink symbols of the legs of active orders
+func (a *Account) OpenLegSymbols() []string {
+	var syms []string
+	for _, wos := range a.Status.AllOrders() {
+		for _, wo := range wos {
+			if !wo.State.Active() {
+				continue
+			}
+			for _, leg := range wo.Order.Legs {
+				opt, err := options.ParseOCCOption(leg.Symbol)
+				if err != nil {
+					continue
+				}
+				syms = append(syms, opt.DxLinkString())
+			}
+		}
+	}
+	return syms
+}
+
+// ImportHistory rebuilds journal trades from the account transactions between from and to
+func (a *Account) ImportHistory(ctx context.Context, from, to time.Time, strategies []string) {
+	count, err := a.Journal.ImportHistory(ctx, a.api, a.Number, from, to, strategies)
+	if err != nil {
+		slog.Error("unable to import trade history", "account", a.String(), "error", err)
+		return
+	}
+	slog.Info("imported trade history", "account", a.String(), "from", from, "to", to, "trades", count)
+}
+
+// trackTrades marks the open trades for excursion tracking and journals closed trades
+func (a *Account) trackTrades(ctx context.Context) {
+	interval := a.opts.MarkInterval
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		orders := a.Status.AllOrders()
+		now := a.clock.Now()
+		for name, wos := range orders {
+			for _, wo := range wos {
+				if wo.State != strategy.StateOpen && wo.State != strategy.StateClosing {
+					continue
+				}
+				pnl, err := journal.MarkToMarket(ctx, wo, a.marks)
+				if err != nil {
+					slog.Debug("unable to mark trade", "account", a.String(), "strategy", name, "pfid", wo.PreflightID, "error", err)
+					continue
+				}
+				if err := a.Journal.Mark(name, wo.PreflightID, pnl, now); err != nil {
+					slog.Error("unable to record mark", "account", a.String(), "strategy", name, "pfid", wo.PreflightID, "error", err)
+				}
+			}
+		}
+
+		a.syncJournal(orders)
+	}
+}
+
+// syncJournal journals the trades closed since the last sync
+func (a *Account) syncJournal(orders map[string][]strategy.WrappedOrder) {
+	added, err := a.Journal.Sync(orders)
+	if err != nil {
+		slog.Error("unable to journal trades", "account", a.String(), "error", err)
+		return
+	}
+	for _, t := range added {
+		slog.Info("trade closed", "account", a.String(), "strategy", t.Strategy, "pfid", t.PFID, "pnl", t.RealizedPnL, "mae", t.MAE, "hold", t.HoldTime)
+	}
+}
+
+// Healthy returns the error that disabled the account, nil when it can trade
+func (a *Account) Healthy() error {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.err
+}
+
+func (a *Account) setErr(err error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	slog.Error("account disabled", "account", a.String(), "error", err)
+	a.err = err
+}
+
+func (a *Account) clearErr() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.err != nil {
+		slog.Info("account enabled", "account", a.String(), "previous error", a.err)
+	}
+	a.err = nil
+}
+
+var ErrUnknownAccount = errors.New("unknown account")
+
+// Registry resolves the account named by a strategy, by alias or account number
+type Registry struct {
+	mu       sync.RWMutex
+	cfg      Config
+	accounts map[string]*Account
+}
+
+func NewRegistry(cfg Config) *Registry {
+	return &Registry{
+		cfg:      cfg,
+		accounts: make(map[string]*Account),
+	}
+}
+
+func (r *Registry) Alias(acctNum string) string {
+	return r.cfg.aliasFor(acctNum)
+}
+
+func (r *Registry) Add(acct *Account) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.accounts[acct.Number] = acct
+	if r.cfg.Default == "" {
+		r.cfg.Default = acct.Number
+	}
+}
+
+func (r *Registry) Resolve(name string) (*Account, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if name == "" {
+		name = r.cfg.Default
+	}
+	if num, ok := r.cfg.Aliases[name]; ok {
+		name = num
+	}
+	if acct, ok := r.accounts[name]; ok {
+		return acct, nil
+	}
+	return nil, fmt.Errorf("%w: %s", ErrUnknownAccount, name)
+}
+
+// All returns the accounts sorted by account number
+func (r *Registry) All() []*Account {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var accts []*Account
+	for _, acct := range r.accounts {
+		accts = append(accts, acct)
+	}
+	slices.SortFunc(accts, func(a, b *Account) int {
+		if a.Number < b.Number {
+			return -1
+		} else if a.Number > b.Number {
+			return 1
+		}
+		return 0
+	})
+	return accts
+}
@@ -0,0 +1,44 @@
+package accounts
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/go-playground/assert/v2"
+)
+
+func TestRegistryResolve(t *testing.T) {
+	cfg := Config{
+		Default: "main",
+		Aliases: map[string]string{"main": "ACCT1", "ira": "ACCT2"},
+	}
+	assert.Equal(t, cfg.Includes("ACCT2"), true)
+	assert.Equal(t, cfg.Includes("ACCT3"), false)
+
+	r := NewRegistry(cfg)
+	r.Add(&Account{Number: "ACCT1", Alias: r.Alias("ACCT1")})
+	r.Add(&Account{Number: "ACCT2", Alias: r.Alias("ACCT2")})
+
+	acct, err := r.Resolve("")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, acct.Number, "ACCT1")
+
+	acct, err = r.Resolve("ira")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, acct.String(), "ira (ACCT2)")
+
+	acct, err = r.Resolve("ACCT2")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, acct.Alias, "ira")
+
+	_, err = r.Resolve("ACCT3")
+	assert.Equal(t, errors.Is(err, ErrUnknownAccount), true)
+	assert.Equal(t, len(r.All()), 2)
+}
+
+func TestAccountHealthy(t *testing.T) {
+	acct := &Account{Number: "ACCT1"}
+	assert.Equal(t, acct.Healthy(), nil)
+	acct.setErr(errors.New("streamer down"))
+	assert.NotEqual(t, acct.Healthy(), nil)
+}
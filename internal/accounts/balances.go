@@ -0,0 +1,90 @@
+package accounts
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/jamesonhm/gochain/internal/clock"
+	"github.com/jamesonhm/gochain/internal/tasty"
+	"github.com/shopspring/decimal"
+)
+
+type BalanceProvider interface {
+	GetAccountBalances(context.Context, string) (*tasty.AccountBalances, error)
+}
+
+// Balances is a periodically refreshed view of the account balances
+type Balances struct {
+	mu      sync.RWMutex
+	acctNum string
+	api     BalanceProvider
+	latest  tasty.AccountBalances
+	updated time.Time
+	err     error
+	clock   clock.Clock
+}
+
+func NewBalances(api BalanceProvider, acctNum string, clk clock.Clock) *Balances {
+	return &Balances{
+		acctNum: acctNum,
+		api:     api,
+		clock:   clk,
+	}
+}
+
+func (b *Balances) Run(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	b.Refresh(ctx)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			b.Refresh(ctx)
+		}
+	}
+}
+
+func (b *Balances) Refresh(ctx context.Context) {
+	balances, err := b.api.GetAccountBalances(ctx, b.acctNum)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.err = err
+	if err != nil {
+		slog.Error("unable to refresh balances", "account", b.acctNum, "error", err)
+		return
+	}
+	b.latest = *balances
+	b.updated = b.clock.Now()
+}
+
+// Get returns the last balances fetched and the time they were fetched
+func (b *Balances) Get() (tasty.AccountBalances, time.Time, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if b.updated.IsZero() {
+		if b.err != nil {
+			return b.latest, b.updated, b.err
+		}
+		return b.latest, b.updated, fmt.Errorf("no balances fetched for %s", b.acctNum)
+	}
+	return b.latest, b.updated, nil
+}
+
+func (b *Balances) NetLiquidatingValue() (decimal.Decimal, error) {
+	bal, _, err := b.Get()
+	return bal.NetLiquidatingValue, err
+}
+
+func (b *Balances) DerivativeBuyingPower() (decimal.Decimal, error) {
+	bal, _, err := b.Get()
+	return bal.DerivativeBuyingPower, err
+}
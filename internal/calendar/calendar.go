@@ -0,0 +1,211 @@
+package calendar
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/jamesonhm/gochain/internal/dt"
+)
+
+// regular and early close session times, as offsets from midnight in New York
+const (
+	RegularOpen  = 9*time.Hour + 30*time.Minute
+	RegularClose = 16 * time.Hour
+	EarlyClose   = 13 * time.Hour
+)
+
+// offline NYSE holidays and half days, used until the broker's list is loaded
+//
+//go:embed holidays.json
+var embedded []byte
+
+type table struct {
+	Holidays []string `json:"holidays"`
+	HalfDays []string `json:"half-days"`
+}
+
+// Session is the regular trading hours of one day
+type Session struct {
+	Open  time.Time
+	Close time.Time
+	// the market closes at 1:00PM
+	Early bool
+}
+
+// Contains reports whether t falls between the open and the close
+func (s Session) Contains(t time.Time) bool {
+	return !t.Before(s.Open) && t.Before(s.Close)
+}
+
+// Source supplies the broker's holiday and half day lists
+type Source interface {
+	GetMarketCalendar(ctx context.Context) ([]time.Time, []time.Time, error)
+}
+
+// Calendar knows which days the equity market trades and when it closes. Holidays
+// close the market for the day, half days close it early
+type Calendar struct {
+	mu       sync.RWMutex
+	holidays map[string]bool
+	halfDays map[string]bool
+}
+
+// New loads the embedded holiday table
+func New() *Calendar {
+	c := &Calendar{holidays: make(map[string]bool), halfDays: make(map[string]bool)}
+	var t table
+	if err := json.Unmarshal(embedded, &t); err != nil {
+		panic(fmt.Sprintf("calendar: embedded holidays: %v", err))
+	}
+	for _, d := range t.Holidays {
+		c.holidays[d] = true
+	}
+	for _, d := range t.HalfDays {
+		c.halfDays[d] = true
+	}
+	return c
+}
+
+func key(d time.Time) string {
+	return d.In(dt.TZNY()).Format(time.DateOnly)
+}
+
+// Update merges the broker's holidays and half days into the calendar, a day listed
+// as a half day is no longer treated as a holiday
+func (c *Calendar) Update(holidays, halfDays []time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, d := range holidays {
+		c.holidays[key(d)] = true
+		delete(c.halfDays, key(d))
+	}
+	for _, d := range halfDays {
+		c.halfDays[key(d)] = true
+		delete(c.holidays, key(d))
+	}
+}
+
+// Refresh updates the calendar from src, the embedded table is kept on error
+func (c *Calendar) Refresh(ctx context.Context, src Source) error {
+	holidays, halfDays, err := src.GetMarketCalendar(ctx)
+	if err != nil {
+		return fmt.Errorf("calendar refresh: %w", err)
+	}
+	c.Update(holidays, halfDays)
+	return nil
+}
+
+func (c *Calendar) IsHoliday(d time.Time) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.holidays[key(d)]
+}
+
+func (c *Calendar) IsHalfDay(d time.Time) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.halfDays[key(d)]
+}
+
+// IsTradingDay is a weekday that isn't a holiday, half days trade
+func (c *Calendar) IsTradingDay(d time.Time) bool {
+	wd := d.In(dt.TZNY()).Weekday()
+	return wd != time.Saturday && wd != time.Sunday && !c.IsHoliday(d)
+}
+
+// Session is the regular session of the day of d, false when the market is closed that day
+func (c *Calendar) Session(d time.Time) (Session, bool) {
+	if !c.IsTradingDay(d) {
+		return Session{}, false
+	}
+	day := dt.Midnight(d.In(dt.TZNY()))
+	s := Session{Open: day.Add(RegularOpen), Close: day.Add(RegularClose)}
+	if c.IsHalfDay(d) {
+		s.Close, s.Early = day.Add(EarlyClose), true
+	}
+	return s, true
+}
+
+// IsOpen reports whether t is within the day's regular session
+func (c *Calendar) IsOpen(t time.Time) bool {
+	s, ok := c.Session(t)
+	return ok && s.Contains(t)
+}
+
+// MinutesToClose is the time left in the session at t, 0 when the market is closed
+func (c *Calendar) MinutesToClose(t time.Time) float64 {
+	s, ok := c.Session(t)
+	if !ok || !s.Contains(t) {
+		return 0
+	}
+	return s.Close.Sub(t).Minutes()
+}
+
+// NextTradingDay is midnight of the first trading day after d
+func (c *Calendar) NextTradingDay(d time.Time) time.Time {
+	day := dt.Midnight(d.In(dt.TZNY())).AddDate(0, 0, 1)
+	for !c.IsTradingDay(day) {
+		day = day.AddDate(0, 0, 1)
+	}
+	return day
+}
+
+// PrevTradingDay is midnight of the last trading day before d
+func (c *Calendar) PrevTradingDay(d time.Time) time.Time {
+	day := dt.Midnight(d.In(dt.TZNY())).AddDate(0, 0, -1)
+	for !c.IsTradingDay(day) {
+		day = day.AddDate(0, 0, -1)
+	}
+	return day
+}
+
+// AddTradingDays moves n trading days from d, negative n moves back
+func (c *Calendar) AddTradingDays(d time.Time, n int) time.Time {
+	day := dt.Midnight(d.In(dt.TZNY()))
+	for ; n > 0; n-- {
+		day = c.NextTradingDay(day)
+	}
+	for ; n < 0; n++ {
+		day = c.PrevTradingDay(day)
+	}
+	return day
+}
+
+// TradingDaysBetween counts the trading days after start up to and including end,
+// the trading day DTE of an option expiring on end
+func (c *Calendar) TradingDaysBetween(start, end time.Time) int {
+	day := dt.Midnight(start.In(dt.TZNY()))
+	last := dt.Midnight(end.In(dt.TZNY()))
+	var n int
+	for day.Before(last) {
+		day = day.AddDate(0, 0, 1)
+		if c.IsTradingDay(day) {
+			n++
+		}
+	}
+	return n
+}
+
+// DTEToDate is the expiration dte calendar days from start, rolled forward to the
+// next trading day
+func (c *Calendar) DTEToDate(start time.Time, dte int) time.Time {
+	return dt.DTEToDateHolidays(start, dte, c.Holidays())
+}
+
+// Holidays lists the full day closures in date order
+func (c *Calendar) Holidays() []time.Time {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	days := make([]time.Time, 0, len(c.holidays))
+	for d := range c.holidays {
+		t, _ := time.ParseInLocation(time.DateOnly, d, dt.TZNY())
+		days = append(days, t)
+	}
+	sort.Slice(days, func(i, j int) bool { return days[i].Before(days[j]) })
+	return days
+}
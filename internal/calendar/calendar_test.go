@@ -0,0 +1,74 @@
+package calendar
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-playground/assert/v2"
+	"github.com/jamesonhm/gochain/internal/dt"
+)
+
+func ny(y int, m time.Month, d, h, min int) time.Time {
+	return time.Date(y, m, d, h, min, 0, 0, dt.TZNY())
+}
+
+func TestSessions(t *testing.T) {
+	c := New()
+
+	s, ok := c.Session(ny(2025, 7, 2, 12, 0))
+	assert.Equal(t, ok, true)
+	assert.Equal(t, s.Early, false)
+	assert.Equal(t, s.Close, ny(2025, 7, 2, 16, 0))
+
+	// half days trade until 1:00PM
+	s, ok = c.Session(ny(2025, 7, 3, 12, 0))
+	assert.Equal(t, ok, true)
+	assert.Equal(t, s.Early, true)
+	assert.Equal(t, c.IsOpen(ny(2025, 7, 3, 12, 59)), true)
+	assert.Equal(t, c.IsOpen(ny(2025, 7, 3, 13, 0)), false)
+	assert.Equal(t, c.MinutesToClose(ny(2025, 7, 3, 12, 30)), 30.0)
+
+	_, ok = c.Session(ny(2025, 7, 4, 12, 0))
+	assert.Equal(t, ok, false)
+	_, ok = c.Session(ny(2025, 7, 5, 12, 0))
+	assert.Equal(t, ok, false)
+	assert.Equal(t, c.IsOpen(ny(2025, 7, 2, 9, 29)), false)
+	assert.Equal(t, c.MinutesToClose(ny(2025, 7, 2, 17, 0)), 0.0)
+}
+
+func TestTradingDayMath(t *testing.T) {
+	c := New()
+	// thanksgiving thursday, then a half day friday
+	wed := ny(2025, 11, 26, 10, 0)
+	assert.Equal(t, c.NextTradingDay(wed), ny(2025, 11, 28, 0, 0))
+	assert.Equal(t, c.PrevTradingDay(ny(2025, 12, 1, 10, 0)), ny(2025, 11, 28, 0, 0))
+	assert.Equal(t, c.AddTradingDays(wed, 2), ny(2025, 12, 1, 0, 0))
+	assert.Equal(t, c.AddTradingDays(wed, -3), ny(2025, 11, 21, 0, 0))
+	// 7 calendar days, 4 trading days
+	assert.Equal(t, c.TradingDaysBetween(wed, ny(2025, 12, 3, 0, 0)), 4)
+	assert.Equal(t, c.TradingDaysBetween(wed, wed), 0)
+	assert.Equal(t, c.DTEToDate(ny(2025, 11, 26, 10, 0), 1), ny(2025, 11, 28, 0, 0))
+}
+
+type fakeSource struct {
+	holidays []time.Time
+	halfDays []time.Time
+}
+
+func (f fakeSource) GetMarketCalendar(ctx context.Context) ([]time.Time, []time.Time, error) {
+	return f.holidays, f.halfDays, nil
+}
+
+func TestRefresh(t *testing.T) {
+	c := New()
+	extra := ny(2028, 1, 17, 0, 0)
+	half := ny(2025, 12, 24, 0, 0)
+	err := c.Refresh(context.Background(), fakeSource{holidays: []time.Time{extra}, halfDays: []time.Time{half}})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, c.IsTradingDay(extra), false)
+	assert.Equal(t, c.IsTradingDay(half), true)
+	assert.Equal(t, c.IsHalfDay(half), true)
+	holidays := c.Holidays()
+	assert.Equal(t, holidays[len(holidays)-1], extra)
+}
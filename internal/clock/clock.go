@@ -0,0 +1,89 @@
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock is the source of the current time for the engine, so strategies can be
+// run at a chosen instant or replayed over historical data. Only Now follows the
+// clock, tickers and timeouts still run in wall time
+type Clock interface {
+	Now() time.Time
+}
+
+// Real is the wall clock
+type Real struct{}
+
+func (Real) Now() time.Time {
+	return time.Now()
+}
+
+// Fixed stands still at one instant until it is set or advanced
+type Fixed struct {
+	mu sync.RWMutex
+	t  time.Time
+}
+
+func NewFixed(t time.Time) *Fixed {
+	return &Fixed{t: t}
+}
+
+func (f *Fixed) Now() time.Time {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.t
+}
+
+func (f *Fixed) Set(t time.Time) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.t = t
+}
+
+func (f *Fixed) Advance(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.t = f.t.Add(d)
+}
+
+// Simulated runs from a start instant at speed times the wall clock, a speed of 60
+// plays an hour of the session every minute
+type Simulated struct {
+	mu    sync.RWMutex
+	start time.Time
+	// wall clock time at start
+	base  time.Time
+	speed float64
+	wall  func() time.Time
+}
+
+func NewSimulated(start time.Time, speed float64) *Simulated {
+	return &Simulated{start: start, base: time.Now(), speed: speed, wall: time.Now}
+}
+
+func (s *Simulated) Now() time.Time {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.now()
+}
+
+func (s *Simulated) now() time.Time {
+	elapsed := s.wall().Sub(s.base)
+	return s.start.Add(time.Duration(float64(elapsed) * s.speed))
+}
+
+// Jump moves the simulated time to t, it keeps running at the same speed from there
+func (s *Simulated) Jump(t time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.start, s.base = t, s.wall()
+}
+
+// SetSpeed changes the speed from the current simulated time, 0 pauses the clock
+func (s *Simulated) SetSpeed(speed float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.start, s.base = s.now(), s.wall()
+	s.speed = speed
+}
@@ -0,0 +1,36 @@
+package clock
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-playground/assert/v2"
+)
+
+func TestFixed(t *testing.T) {
+	start := time.Date(2025, 7, 3, 9, 30, 0, 0, time.UTC)
+	f := NewFixed(start)
+	assert.Equal(t, f.Now(), start)
+	f.Advance(time.Minute)
+	assert.Equal(t, f.Now(), start.Add(time.Minute))
+	f.Set(start)
+	assert.Equal(t, f.Now(), start)
+}
+
+func TestSimulated(t *testing.T) {
+	wall := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	start := time.Date(2025, 7, 3, 9, 30, 0, 0, time.UTC)
+	s := &Simulated{start: start, base: wall, speed: 60, wall: func() time.Time { return wall }}
+
+	wall = wall.Add(time.Minute)
+	assert.Equal(t, s.Now(), start.Add(time.Hour))
+
+	s.SetSpeed(0)
+	wall = wall.Add(time.Minute)
+	assert.Equal(t, s.Now(), start.Add(time.Hour))
+
+	s.SetSpeed(2)
+	s.Jump(start)
+	wall = wall.Add(time.Minute)
+	assert.Equal(t, s.Now(), start.Add(2*time.Minute))
+}
@@ -50,8 +50,8 @@ func NextWeekday(d time.Time) time.Time {
 	return d.AddDate(0, 0, 1)
 }
 
-func DTEToDate(dte int) time.Time {
-	exp := time.Now().In(TZNY()).AddDate(0, 0, dte)
+func DTEToDate(now time.Time, dte int) time.Time {
+	exp := now.In(TZNY()).AddDate(0, 0, dte)
 	if exp.Weekday() < 1 || exp.Weekday() > 5 {
 		exp = NextWeekday(exp)
 	}
@@ -82,9 +82,9 @@ func YMDEqual(d1 time.Time, d2 time.Time) bool {
 	return d1.Year() == d2.Year() && d1.Month() == d2.Month() && d1.Day() == d2.Day()
 }
 
-// parses a string representing an hour and minute in "kitchen" format ("3:04PM") to a time.Time with todays date
-func ParseTimeAsToday(timestr string) time.Time {
-	day := time.Now().In(TZNY())
+// parses a string representing an hour and minute in "kitchen" format ("3:04PM") to a time.Time with the date of now
+func ParseTimeAsToday(now time.Time, timestr string) time.Time {
+	day := now.In(TZNY())
 	daystr := day.Format(time.DateOnly)
 	dtstr := fmt.Sprintf("%s %s", daystr, timestr)
 	t, err := time.ParseInLocation("2006-01-02 3:04PM", dtstr, TZNY())
@@ -50,3 +50,10 @@ func TestDTEToDateHolidays(t *testing.T) {
 	}
 
 }
+
+func TestParseTimeAsToday(t *testing.T) {
+	now := time.Date(2025, 7, 2, 15, 0, 0, 0, time.UTC)
+	assert.Equal(t, ParseTimeAsToday(now, "9:40AM"), time.Date(2025, 7, 2, 9, 40, 0, 0, TZNY()))
+	// a saturday rolls to monday
+	assert.Equal(t, DTEToDate(now, 3), time.Date(2025, 7, 7, 0, 0, 0, 0, TZNY()))
+}
@@ -1,6 +1,7 @@
 package dxlink
 
 import (
+	"context"
 	"fmt"
 	"log/slog"
 	"math"
@@ -11,6 +12,7 @@ import (
 )
 
 func (c *DxLinkClient) OptionDataByOffset(
+	ctx context.Context,
 	underlying string,
 	dte int,
 	optType options.OptionType,
@@ -18,10 +20,7 @@ func (c *DxLinkClient) OptionDataByOffset(
 	offsetBy int,
 	holidays []time.Time,
 ) (*OptionData, error) {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
-
-	exp := dt.DTEToDateHolidays(time.Now(), dte, holidays)
+	exp := dt.DTEToDateHolidays(c.now(), dte, holidays)
 	s := float64(int(offsetFrom) + offsetBy)
 	opt := options.OptionSymbol{
 		Underlying: underlying,
@@ -29,15 +28,17 @@ func (c *DxLinkClient) OptionDataByOffset(
 		Strike:     s,
 		OptionType: optType,
 	}
-	data, err := c.GetOptData(opt.DxLinkString())
+	data, err := c.GetOptData(ctx, opt.DxLinkString())
 	if err != nil {
 		return nil, fmt.Errorf("OptionDataByOffset: unable to find INITIAL option in subscription data: %s, %w", opt.DxLinkString(), err)
 	}
 	return data, nil
 }
 
-// searches the map of optionSubs for the date, and strike nearest the delta based on the rounding value
+// finds the option of the expiration with the delta nearest the target, only strikes
+// that are a multiple of round are considered when round is above 1
 func (c *DxLinkClient) OptionDataByDelta(
+	ctx context.Context,
 	underlying string,
 	dte int,
 	optType options.OptionType,
@@ -45,85 +46,108 @@ func (c *DxLinkClient) OptionDataByDelta(
 	targetDelta float64,
 	holidays []time.Time,
 ) (*OptionData, error) {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
+	exp := dt.DTEToDateHolidays(c.now(), dte, holidays)
+	data, err := c.NearestDelta(ctx, underlying, exp, optType, round, targetDelta)
+	if err != nil {
+		return nil, fmt.Errorf("OptionDataByDelta: %w", err)
+	}
+	slog.Info("returning option data", "option", data.Greek.Symbol, "delta", *data.Greek.Delta, "target", targetDelta)
+	return data, nil
+}
 
-	var delta float64 = 0.0
-	var dist float64 = 99.9
-	var tempDist float64 = 0.0
-	var tempOpt *options.OptionSymbol
-	var err error
-	var attempt int = 0
+// NearestDelta searches every strike of the expiration for the delta nearest the target,
+// options without streamed greeks use the model greeks of their mid
+func (c *DxLinkClient) NearestDelta(
+	ctx context.Context,
+	underlying string,
+	exp time.Time,
+	optType options.OptionType,
+	round int,
+	targetDelta float64,
+) (*OptionData, error) {
+	now := c.now()
+	return c.nearest(ctx, underlying, exp, optType, round, func(d *OptionData) (*OptionData, float64, bool) {
+		d, ok := c.withModelGreeks(d, now)
+		if !ok {
+			return nil, 0, false
+		}
+		return d, math.Abs(*d.Greek.Delta - targetDelta), true
+	})
+}
 
-	// find exp date
-	exp := dt.DTEToDateHolidays(time.Now(), dte, holidays)
-	atm, err := c.getUnderlyingPrice(underlying)
+// NearestPrice searches every strike of the expiration for the mid price nearest the target
+func (c *DxLinkClient) NearestPrice(
+	ctx context.Context,
+	underlying string,
+	exp time.Time,
+	optType options.OptionType,
+	round int,
+	targetPrice float64,
+) (*OptionData, error) {
+	return c.nearest(ctx, underlying, exp, optType, round, func(d *OptionData) (*OptionData, float64, bool) {
+		if d.Quote.BidPrice == nil || d.Quote.AskPrice == nil || *d.Quote.AskPrice == 0 {
+			return nil, 0, false
+		}
+		return d, math.Abs((*d.Quote.BidPrice+*d.Quote.AskPrice)/2 - targetPrice), true
+	})
+}
+
+// ATMOption is the option with the strike nearest the last underlying trade
+func (c *DxLinkClient) ATMOption(ctx context.Context, underlying string, exp time.Time, optType options.OptionType) (*OptionData, error) {
+	price, err := c.getUnderlyingPrice(underlying)
 	if err != nil {
-		return nil, fmt.Errorf("OptionDataByDelta: unable to get underlying price for '%s'", underlying)
+		return nil, fmt.Errorf("ATMOption: unable to get underlying price for '%s': %w", underlying, err)
 	}
-	s := roundNearest(atm, round)
-	opt := &options.OptionSymbol{
-		Underlying: underlying,
-		Date:       exp,
-		Strike:     s,
-		OptionType: optType,
+	_, sym, err := c.chain.NearestStrike(underlying, exp, optType, price)
+	if err != nil {
+		return nil, fmt.Errorf("ATMOption: %w", err)
 	}
+	return c.GetOptData(ctx, sym)
+}
 
-	for attempt < 5 {
-		// condition initial starting point not found yet
-		if delta == 0.0 && tempDist == 0.0 {
-			delta, err = c.getOptDelta(opt.DxLinkString())
-			if err != nil {
-				attempt += 1
-				tempOpt = opt.NewRelative(float64(round))
-				slog.Info("unable to find initial delta", "option", opt.DxLinkString(), "attempt", attempt, "error", err)
+// nearest returns the option data from dist with the smallest distance, options without
+// the data for dist are skipped. The search is retried while no option of the expiration has data,
+// until ctx is done.
+func (c *DxLinkClient) nearest(
+	ctx context.Context,
+	underlying string,
+	exp time.Time,
+	optType options.OptionType,
+	round int,
+	dist func(*OptionData) (*OptionData, float64, bool),
+) (*OptionData, error) {
+	entries := c.chain.entries(underlying, exp, optType)
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("no %s strikes subscribed for %s %s", optType, underlying, expKey(exp))
+	}
+	delay := c.delay
+	for i := 0; i < c.retries; i++ {
+		var best *OptionData
+		bestDist := math.Inf(1)
+		for _, e := range entries {
+			if round > 1 && math.Mod(e.Strike, float64(round)) != 0 {
 				continue
-			} else {
-				attempt = 0
-				tempDist = math.Abs(delta - targetDelta)
-				slog.Info("found initial delta", "option", opt.DxLinkString(), "delta", delta, "dist", tempDist)
 			}
-		}
-		// have a delta and a dist and a targetDelta
-		if tempDist > dist {
-			// return prev, non-temp opt
-			optDataPtr, err := c.GetOptData(opt.DxLinkString())
-			if err != nil {
-				slog.Error("unable to get option data", "option", opt.DxLinkString(), "error", err)
-				return nil, err
-			}
-			slog.Info("returning option data", "option", opt.DxLinkString())
-			return optDataPtr, nil
-		}
-		if tempOpt != nil {
-			opt = tempOpt
-		}
-		dist = tempDist
-		if delta > targetDelta {
-			// decrement new temp opt
-			tempOpt = opt.NewRelative(-1 * float64(round))
-			delta, err = c.getOptDelta(tempOpt.DxLinkString())
-			if err != nil {
-				attempt += 1
-				slog.Info("unable to find delta", "option", tempOpt.DxLinkString(), "attempt", attempt, "error", err)
+			data, ok := c.optionSubs.Load(e.Symbol)
+			if !ok {
 				continue
 			}
-			tempDist = math.Abs(delta - targetDelta)
-			slog.Info("decremented option", "option", tempOpt.DxLinkString(), "delta", delta, "dist", tempDist)
-		} else if delta < targetDelta {
-			// increment new temp opt
-			tempOpt = opt.NewRelative(float64(round))
-			delta, err = c.getOptDelta(tempOpt.DxLinkString())
-			if err != nil {
-				attempt += 1
-				slog.Info("unable to find delta", "option", tempOpt.DxLinkString(), "attempt", attempt, "error", err)
-				continue
+			if data, d, ok := dist(data); ok && d < bestDist {
+				best, bestDist = data, d
 			}
-			tempDist = math.Abs(delta - targetDelta)
-			slog.Info("incremented option", "option", tempOpt.DxLinkString(), "delta", delta, "dist", tempDist)
+		}
+		if best != nil {
+			return best, nil
+		}
+		slog.Debug("retrying nearest option search", "underlying", underlying, "exp", expKey(exp), "attempt", i+1, "delay", delay)
+		if err := wait(ctx, delay); err != nil {
+			return nil, fmt.Errorf("nearest %s option for %s %s: %w", optType, underlying, expKey(exp), err)
+		}
+		if c.expBackoff {
+			delay *= 2
 		}
 	}
-	return nil, fmt.Errorf("OptionDataByDelta: Max attempts reached\n")
+	return nil, fmt.Errorf("no %s options with data for %s %s", optType, underlying, expKey(exp))
 }
 
 // searches the map of optionSubs for the date, and strike nearest the delta based on the rounding value
@@ -221,14 +245,10 @@ func (c *DxLinkClient) OptionDataByDelta(
 //}
 
 func (c *DxLinkClient) getUnderlyingData(sym string) (*UnderlyingData, error) {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
 	delay := c.delay
 	for i := 0; i < c.retries; i++ {
-		if underlyingPtr, ok := c.underlyingSubs[sym]; ok {
-			if underlyingPtr != nil {
-				return underlyingPtr, nil
-			}
+		if underlyingPtr, ok := c.underlyingSubs.Load(sym); ok {
+			return underlyingPtr, nil
 		}
 		fmt.Printf("Retrying getUnderlyingData, attempt %d, delay: %s\n", i+1, delay.String())
 		time.Sleep(delay)
@@ -251,38 +271,32 @@ func (c *DxLinkClient) getUnderlyingPrice(sym string) (float64, error) {
 
 }
 
-func (c *DxLinkClient) GetOptData(opt string) (*OptionData, error) {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
+// GetOptData returns a snapshot of the option data, it is not updated by later events.
+// Retried while the option has no quote or greeks, until ctx is done
+func (c *DxLinkClient) GetOptData(ctx context.Context, opt string) (*OptionData, error) {
 	delay := c.delay
-	retry := func(i int, delay time.Duration) time.Duration {
-		fmt.Printf("Retrying getOptData, attempt %d, delay: %s\n", i+1, delay.String())
-		time.Sleep(delay)
-		if c.expBackoff {
-			delay *= 2
-		}
-		return delay
-	}
 	for i := 0; i < c.retries; i++ {
-		if optionDataPtr, ok := c.optionSubs[opt]; !ok {
-			delay = retry(i, delay)
-		} else if optionDataPtr == nil {
-			delay = retry(i, delay)
-		} else if optionDataPtr.Greek.Delta == nil ||
-			optionDataPtr.Quote.AskPrice == nil ||
-			*optionDataPtr.Quote.AskPrice == 0.0 ||
-			optionDataPtr.Quote.BidPrice == nil ||
-			*optionDataPtr.Quote.BidPrice == 0.0 {
-			delay = retry(i, delay)
-		} else {
+		optionDataPtr, ok := c.optionSubs.Load(opt)
+		if ok && optionDataPtr.Greek.Delta != nil &&
+			optionDataPtr.Quote.AskPrice != nil &&
+			*optionDataPtr.Quote.AskPrice != 0.0 &&
+			optionDataPtr.Quote.BidPrice != nil &&
+			*optionDataPtr.Quote.BidPrice != 0.0 {
 			return optionDataPtr, nil
 		}
+		slog.Debug("retrying option data", "option", opt, "attempt", i+1, "delay", delay)
+		if err := wait(ctx, delay); err != nil {
+			return nil, fmt.Errorf("option data for %s: %w", opt, err)
+		}
+		if c.expBackoff {
+			delay *= 2
+		}
 	}
 	return nil, fmt.Errorf("unable to find option in subscription data or is nil: %s", opt)
 }
 
-func (c *DxLinkClient) getOptDelta(opt string) (float64, error) {
-	optionDataPtr, err := c.GetOptData(opt)
+func (c *DxLinkClient) getOptDelta(ctx context.Context, opt string) (float64, error) {
+	optionDataPtr, err := c.GetOptData(ctx, opt)
 	if err != nil {
 		return 0, err
 	}
@@ -293,10 +307,14 @@ func (c *DxLinkClient) getOptDelta(opt string) (float64, error) {
 	return delta, nil
 }
 
-func roundNearest(price float64, round int) float64 {
-	if round == 0 || round == 1 {
-		return math.Floor(price)
+// wait sleeps for the retry delay, cut short when ctx is done
+func wait(ctx context.Context, delay time.Duration) error {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
 	}
-	diff := int(math.Floor(price)) % round
-	return math.Floor(price) - float64(diff)
 }
@@ -0,0 +1,276 @@
+package dxlink
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jamesonhm/gochain/internal/dt"
+)
+
+type CandlePeriod string
+
+const (
+	Candle1m CandlePeriod = "1m"
+	Candle5m CandlePeriod = "5m"
+	Candle1d CandlePeriod = "1d"
+)
+
+// intraday periods from finest to coarsest, moves use the finest one received
+var intradayPeriods = []CandlePeriod{Candle1m, Candle5m}
+
+// CandleSymbol is the dxfeed candle symbol for a period, ex. SPY{=5m}
+func CandleSymbol(symbol string, period CandlePeriod) string {
+	return fmt.Sprintf("%s{=%s}", feedSymbol(symbol), period)
+}
+
+// parseCandleSymbol splits a candle symbol into the symbol and period, other
+// attributes after the period are ignored
+func parseCandleSymbol(candleSym string) (string, CandlePeriod, bool) {
+	open := strings.Index(candleSym, "{=")
+	if open <= 0 || !strings.HasSuffix(candleSym, "}") {
+		return "", "", false
+	}
+	attrs := candleSym[open+2 : len(candleSym)-1]
+	if comma := strings.Index(attrs, ","); comma >= 0 {
+		attrs = attrs[:comma]
+	}
+	return candleSym[:open], CandlePeriod(attrs), attrs != ""
+}
+
+// feedSymbol maps the yahoo style index symbols used in conditions, ex. ^VIX, to the feed symbol
+func feedSymbol(symbol string) string {
+	return strings.TrimPrefix(symbol, "^")
+}
+
+type Bar struct {
+	Time   time.Time
+	Open   float64
+	High   float64
+	Low    float64
+	Close  float64
+	Volume float64
+}
+
+// BarRing keeps the latest bars of a series in time order, the oldest bar is
+// dropped once it is full. Updates to a bar already held replace it.
+type BarRing struct {
+	bars  []Bar
+	start int
+	n     int
+}
+
+func NewBarRing(size int) *BarRing {
+	return &BarRing{bars: make([]Bar, size)}
+}
+
+func (r *BarRing) Len() int {
+	return r.n
+}
+
+func (r *BarRing) at(i int) *Bar {
+	return &r.bars[(r.start+i)%len(r.bars)]
+}
+
+func (r *BarRing) Add(b Bar) {
+	if len(r.bars) == 0 {
+		return
+	}
+	if r.n == 0 || b.Time.After(r.at(r.n-1).Time) {
+		if r.n < len(r.bars) {
+			*r.at(r.n) = b
+			r.n++
+			return
+		}
+		r.bars[r.start] = b
+		r.start = (r.start + 1) % len(r.bars)
+		return
+	}
+
+	// snapshots are sent newest first, older bars are inserted in order
+	i := sort.Search(r.n, func(i int) bool { return !r.at(i).Time.Before(b.Time) })
+	if r.at(i).Time.Equal(b.Time) {
+		*r.at(i) = b
+		return
+	}
+	if r.n == len(r.bars) {
+		if i == 0 {
+			return
+		}
+		r.start = (r.start + 1) % len(r.bars)
+		r.n--
+		i--
+	}
+	for j := r.n; j > i; j-- {
+		*r.at(j) = *r.at(j - 1)
+	}
+	*r.at(i) = b
+	r.n++
+}
+
+// Bars are returned oldest first
+func (r *BarRing) Bars() []Bar {
+	bars := make([]Bar, r.n)
+	for i := range r.n {
+		bars[i] = *r.at(i)
+	}
+	return bars
+}
+
+func (r *BarRing) Last() (Bar, bool) {
+	if r.n == 0 {
+		return Bar{}, false
+	}
+	return *r.at(r.n - 1), true
+}
+
+// CandleStore holds the streamed bars for each candle symbol and answers the
+// strategy candle conditions from them
+type CandleStore struct {
+	mu     sync.RWMutex
+	series map[string]*BarRing
+	size   int
+	now    func() time.Time
+}
+
+func NewCandleStore(size int) *CandleStore {
+	return &CandleStore{
+		series: make(map[string]*BarRing),
+		size:   size,
+		now:    time.Now,
+	}
+}
+
+// Add stores a candle event, events without a time or prices, ex. removals, are skipped
+func (s *CandleStore) Add(evt CandleEvent) bool {
+	if evt.Time == nil || evt.Open == nil || evt.High == nil || evt.Low == nil || evt.Close == nil {
+		return false
+	}
+	if math.IsNaN(*evt.Close) || *evt.Time <= 0 {
+		return false
+	}
+	bar := Bar{
+		Time:  time.UnixMilli(int64(*evt.Time)),
+		Open:  *evt.Open,
+		High:  *evt.High,
+		Low:   *evt.Low,
+		Close: *evt.Close,
+	}
+	if evt.Volume != nil && !math.IsNaN(*evt.Volume) {
+		bar.Volume = *evt.Volume
+	}
+
+	symbol, period, ok := parseCandleSymbol(evt.Symbol)
+	if !ok {
+		return false
+	}
+	key := CandleSymbol(symbol, period)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ring, ok := s.series[key]
+	if !ok {
+		ring = NewBarRing(s.size)
+		s.series[key] = ring
+	}
+	ring.Add(bar)
+	return true
+}
+
+func (s *CandleStore) Bars(symbol string, period CandlePeriod) []Bar {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	ring, ok := s.series[CandleSymbol(symbol, period)]
+	if !ok {
+		return nil
+	}
+	return ring.Bars()
+}
+
+// lastTime of the newest bar for a candle symbol
+func (s *CandleStore) lastTime(candleSym string) (time.Time, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	ring, ok := s.series[candleSym]
+	if !ok {
+		return time.Time{}, false
+	}
+	last, ok := ring.Last()
+	return last.Time, ok
+}
+
+// today's regular session bars from the finest intraday series
+func (s *CandleStore) sessionBars(symbol string, now time.Time) []Bar {
+	nyNow := now.In(dt.TZNY())
+	open := time.Date(nyNow.Year(), nyNow.Month(), nyNow.Day(), 9, 30, 0, 0, dt.TZNY())
+	for _, period := range intradayPeriods {
+		var session []Bar
+		for _, bar := range s.Bars(symbol, period) {
+			if !bar.Time.Before(open) && dt.YMDEqual(bar.Time.In(dt.TZNY()), nyNow) {
+				session = append(session, bar)
+			}
+		}
+		if len(session) > 0 {
+			return session
+		}
+	}
+	return nil
+}
+
+// daily bars are stamped at midnight UTC or exchange time, the UTC date is the session date for both
+func dailyDate(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func (s *CandleStore) prevClose(symbol string, now time.Time) (float64, error) {
+	nyNow := now.In(dt.TZNY())
+	today := time.Date(nyNow.Year(), nyNow.Month(), nyNow.Day(), 0, 0, 0, 0, time.UTC)
+	daily := s.Bars(symbol, Candle1d)
+	for i := len(daily) - 1; i >= 0; i-- {
+		if dailyDate(daily[i].Time).Before(today) {
+			return daily[i].Close, nil
+		}
+	}
+	return 0, fmt.Errorf("no previous daily close for %s", symbol)
+}
+
+func (s *CandleStore) ONMove(symbol string) (float64, error) {
+	now := s.now()
+	session := s.sessionBars(symbol, now)
+	if len(session) == 0 {
+		return 0, fmt.Errorf("no session bars for %s", symbol)
+	}
+	prevClose, err := s.prevClose(symbol, now)
+	if err != nil {
+		return 0, err
+	}
+	return session[0].Open - prevClose, nil
+}
+
+func (s *CandleStore) ONMovePct(symbol string) (float64, error) {
+	now := s.now()
+	session := s.sessionBars(symbol, now)
+	if len(session) == 0 {
+		return 0, fmt.Errorf("no session bars for %s", symbol)
+	}
+	prevClose, err := s.prevClose(symbol, now)
+	if err != nil {
+		return 0, err
+	}
+	if prevClose == 0 {
+		return 0, fmt.Errorf("previous close for %s is 0", symbol)
+	}
+	return ((session[0].Open - prevClose) / prevClose) * 100, nil
+}
+
+func (s *CandleStore) IntradayMove(symbol string) (float64, error) {
+	session := s.sessionBars(symbol, s.now())
+	if len(session) == 0 {
+		return 0, fmt.Errorf("no session bars for %s", symbol)
+	}
+	return session[len(session)-1].Close - session[0].Open, nil
+}
@@ -0,0 +1,106 @@
+package dxlink
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-playground/assert/v2"
+	"github.com/jamesonhm/gochain/internal/dt"
+)
+
+func candle(sym string, at time.Time, open, close float64) CandleEvent {
+	ms := float64(at.UnixMilli())
+	high, low := max(open, close), min(open, close)
+	return CandleEvent{EventType: "Candle", Symbol: sym, Time: &ms, Open: &open, High: &high, Low: &low, Close: &close}
+}
+
+func TestBarRing(t *testing.T) {
+	base := time.Date(2025, 8, 8, 14, 0, 0, 0, time.UTC)
+	at := func(i int) time.Time { return base.Add(time.Duration(i) * time.Minute) }
+	times := func(r *BarRing) []time.Time {
+		var ts []time.Time
+		for _, b := range r.Bars() {
+			ts = append(ts, b.Time)
+		}
+		return ts
+	}
+
+	r := NewBarRing(3)
+	// snapshot arrives newest first
+	r.Add(Bar{Time: at(2)})
+	r.Add(Bar{Time: at(1)})
+	r.Add(Bar{Time: at(0)})
+	assert.Equal(t, times(r), []time.Time{at(0), at(1), at(2)})
+
+	// the current bar is updated in place
+	r.Add(Bar{Time: at(2), Close: 5})
+	last, _ := r.Last()
+	assert.Equal(t, r.Len(), 3)
+	assert.Equal(t, last.Close, 5.0)
+
+	// new bars drop the oldest
+	r.Add(Bar{Time: at(3)})
+	r.Add(Bar{Time: at(4)})
+	assert.Equal(t, times(r), []time.Time{at(2), at(3), at(4)})
+
+	// older than everything held once full
+	r.Add(Bar{Time: at(1)})
+	assert.Equal(t, times(r), []time.Time{at(2), at(3), at(4)})
+
+	r = NewBarRing(3)
+	r.Add(Bar{Time: at(0)})
+	r.Add(Bar{Time: at(2)})
+	r.Add(Bar{Time: at(3)})
+	r.Add(Bar{Time: at(1)})
+	assert.Equal(t, times(r), []time.Time{at(1), at(2), at(3)})
+}
+
+func TestParseCandleSymbol(t *testing.T) {
+	sym, period, ok := parseCandleSymbol("SPY{=5m,tho=true}")
+	assert.Equal(t, ok, true)
+	assert.Equal(t, sym, "SPY")
+	assert.Equal(t, period, Candle5m)
+
+	_, _, ok = parseCandleSymbol("SPY")
+	assert.Equal(t, ok, false)
+	assert.Equal(t, CandleSymbol("^VIX", Candle1d), "VIX{=1d}")
+}
+
+func TestCandleStoreMoves(t *testing.T) {
+	ny := dt.TZNY()
+	s := NewCandleStore(100)
+	s.now = func() time.Time { return time.Date(2025, 8, 8, 10, 0, 0, 0, ny) }
+
+	_, err := s.ONMove("^VIX")
+	assert.NotEqual(t, err, nil)
+
+	daily := CandleSymbol("VIX", Candle1d)
+	s.Add(candle(daily, time.Date(2025, 8, 6, 0, 0, 0, 0, ny), 17, 16.5))
+	s.Add(candle(daily, time.Date(2025, 8, 7, 0, 0, 0, 0, ny), 16.5, 16))
+	s.Add(candle(daily, time.Date(2025, 8, 8, 0, 0, 0, 0, ny), 16.4, 17.2))
+
+	intra := CandleSymbol("VIX", Candle5m)
+	// premarket bars are not part of the session
+	s.Add(candle(intra, time.Date(2025, 8, 8, 9, 25, 0, 0, ny), 15, 15.5))
+	s.Add(candle(intra, time.Date(2025, 8, 8, 9, 30, 0, 0, ny), 16.5, 17))
+	s.Add(candle(intra, time.Date(2025, 8, 8, 9, 35, 0, 0, ny), 17, 17.25))
+
+	move, err := s.ONMove("^VIX")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, move, 0.5)
+
+	pct, err := s.ONMovePct("^VIX")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, pct, 3.125)
+
+	intraday, err := s.IntradayMove("^VIX")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, intraday, 0.75)
+
+	// daily bars stamped at midnight UTC
+	s.Add(candle(CandleSymbol("SPX", Candle1d), time.Date(2025, 8, 7, 0, 0, 0, 0, time.UTC), 6300, 6340))
+	s.Add(candle(CandleSymbol("SPX", Candle1m), time.Date(2025, 8, 8, 9, 30, 0, 0, ny), 6350, 6352))
+	move, err = s.ONMove("SPX")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, move, 10.0)
+}
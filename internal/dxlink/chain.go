@@ -0,0 +1,243 @@
+package dxlink
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/jamesonhm/gochain/internal/options"
+	"github.com/jamesonhm/gochain/internal/symbology"
+)
+
+type strikeEntry struct {
+	Strike float64
+	Root   string
+	Symbol string
+}
+
+// ChainIndex orders the subscribed options by underlying, expiration and type,
+// with the strikes of each sorted ascending. Every root of an underlying, such as
+// SPX and SPXW, is indexed under the underlying
+type ChainIndex struct {
+	mu     sync.RWMutex
+	chains map[string]map[string]map[options.OptionType][]strikeEntry
+}
+
+func NewChainIndex() *ChainIndex {
+	return &ChainIndex{
+		chains: make(map[string]map[string]map[options.OptionType][]strikeEntry),
+	}
+}
+
+// expirations are keyed by calendar date, independent of the time zone of the date passed
+func expKey(exp time.Time) string {
+	return exp.Format("2006-01-02")
+}
+
+// rootRank orders the roots listed at the same strike and expiration, PM settled
+// weeklies such as SPXW first, then the standard root, adjusted deliverables last
+func rootRank(root string) int {
+	switch {
+	case root != "" && unicode.IsDigit(rune(root[len(root)-1])):
+		return 2
+	case root == symbology.Underlying(root):
+		return 1
+	default:
+		return 0
+	}
+}
+
+func entryLess(a, b strikeEntry) bool {
+	if a.Strike != b.Strike {
+		return a.Strike < b.Strike
+	}
+	if rootRank(a.Root) != rootRank(b.Root) {
+		return rootRank(a.Root) < rootRank(b.Root)
+	}
+	return a.Root < b.Root
+}
+
+func (x *ChainIndex) Add(symbol string) error {
+	opt, err := options.ParseDxLinkOption(symbol)
+	if err != nil {
+		return err
+	}
+	underlying := symbology.Underlying(opt.Underlying)
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	exps, ok := x.chains[underlying]
+	if !ok {
+		exps = make(map[string]map[options.OptionType][]strikeEntry)
+		x.chains[underlying] = exps
+	}
+	types, ok := exps[expKey(opt.Date)]
+	if !ok {
+		types = make(map[options.OptionType][]strikeEntry)
+		exps[expKey(opt.Date)] = types
+	}
+	entry := strikeEntry{Strike: opt.Strike, Root: opt.Underlying, Symbol: symbol}
+	strikes := types[opt.OptionType]
+	i := sort.Search(len(strikes), func(i int) bool { return !entryLess(strikes[i], entry) })
+	if i < len(strikes) && strikes[i].Symbol == symbol {
+		return nil
+	}
+	strikes = append(strikes, strikeEntry{})
+	copy(strikes[i+1:], strikes[i:])
+	strikes[i] = entry
+	types[opt.OptionType] = strikes
+	return nil
+}
+
+func (x *ChainIndex) Remove(symbol string) {
+	opt, err := options.ParseDxLinkOption(symbol)
+	if err != nil {
+		return
+	}
+	underlying := symbology.Underlying(opt.Underlying)
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	types := x.chains[underlying][expKey(opt.Date)]
+	if types == nil {
+		return
+	}
+	strikes := types[opt.OptionType]
+	for i, e := range strikes {
+		if e.Symbol == symbol {
+			types[opt.OptionType] = append(strikes[:i], strikes[i+1:]...)
+			break
+		}
+	}
+	if len(types[opt.OptionType]) == 0 {
+		delete(types, opt.OptionType)
+	}
+	if len(types) == 0 {
+		delete(x.chains[underlying], expKey(opt.Date))
+	}
+	if len(x.chains[underlying]) == 0 {
+		delete(x.chains, underlying)
+	}
+}
+
+func (x *ChainIndex) Clear() {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	clear(x.chains)
+}
+
+// selectEntries picks the strikes of name, a root selects only its own options and an
+// underlying one option per strike, in the order of rootRank
+func selectEntries(name string, all []strikeEntry) []strikeEntry {
+	var selected []strikeEntry
+	byRoot := name != symbology.Underlying(name)
+	for _, e := range all {
+		if byRoot && e.Root != name {
+			continue
+		}
+		if !byRoot && len(selected) > 0 && selected[len(selected)-1].Strike == e.Strike {
+			continue
+		}
+		selected = append(selected, e)
+	}
+	return selected
+}
+
+// entries is a copy of the sorted strikes for an expiration and type of a root or underlying
+func (x *ChainIndex) entries(name string, exp time.Time, optType options.OptionType) []strikeEntry {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	return selectEntries(name, x.chains[symbology.Underlying(name)][expKey(exp)][optType])
+}
+
+// Symbol is the streamer symbol of the strike of a root or underlying
+func (x *ChainIndex) Symbol(name string, exp time.Time, optType options.OptionType, strike float64) (string, bool) {
+	for _, e := range x.entries(name, exp, optType) {
+		if e.Strike == strike {
+			return e.Symbol, true
+		}
+	}
+	return "", false
+}
+
+// Expirations of the root or underlying in the index, in date order
+func (x *ChainIndex) Expirations(name string) []time.Time {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	var exps []time.Time
+	for key, types := range x.chains[symbology.Underlying(name)] {
+		found := false
+		for _, all := range types {
+			if len(selectEntries(name, all)) > 0 {
+				found = true
+				break
+			}
+		}
+		exp, err := time.Parse("2006-01-02", key)
+		if err != nil || !found {
+			continue
+		}
+		exps = append(exps, exp)
+	}
+	sort.Slice(exps, func(i, j int) bool { return exps[i].Before(exps[j]) })
+	return exps
+}
+
+func (x *ChainIndex) Strikes(underlying string, exp time.Time, optType options.OptionType) []float64 {
+	entries := x.entries(underlying, exp, optType)
+	strikes := make([]float64, len(entries))
+	for i, e := range entries {
+		strikes[i] = e.Strike
+	}
+	return strikes
+}
+
+// StrikesBetween are the strikes within lower and upper inclusive
+func (x *ChainIndex) StrikesBetween(underlying string, exp time.Time, optType options.OptionType, lower, upper float64) []float64 {
+	strikes := x.Strikes(underlying, exp, optType)
+	lo := sort.SearchFloat64s(strikes, lower)
+	hi := sort.Search(len(strikes), func(i int) bool { return strikes[i] > upper })
+	if lo >= hi {
+		return nil
+	}
+	return strikes[lo:hi]
+}
+
+// StrikeIncrement is the most common spacing between strikes of an expiration,
+// the narrower spacing wins a tie
+func (x *ChainIndex) StrikeIncrement(underlying string, exp time.Time) (float64, error) {
+	counts := make(map[float64]int)
+	for _, optType := range []options.OptionType{options.CallOption, options.PutOption} {
+		strikes := x.Strikes(underlying, exp, optType)
+		for i := 1; i < len(strikes); i++ {
+			// rounded to the cent so float noise doesn't split the counts
+			diff := math.Round((strikes[i]-strikes[i-1])*100) / 100
+			counts[diff]++
+		}
+	}
+	var inc float64
+	best := 0
+	for diff, n := range counts {
+		if n > best || (n == best && diff < inc) {
+			inc, best = diff, n
+		}
+	}
+	if best == 0 {
+		return 0, fmt.Errorf("not enough strikes for %s %s", underlying, expKey(exp))
+	}
+	return inc, nil
+}
+
+// NearestStrike is the index entry closest to the price, the lower strike wins a tie
+func (x *ChainIndex) NearestStrike(underlying string, exp time.Time, optType options.OptionType, price float64) (float64, string, error) {
+	entries := x.entries(underlying, exp, optType)
+	if len(entries) == 0 {
+		return 0, "", fmt.Errorf("no %s strikes for %s %s", optType, underlying, expKey(exp))
+	}
+	i := sort.Search(len(entries), func(i int) bool { return entries[i].Strike >= price })
+	if i == len(entries) || (i > 0 && price-entries[i-1].Strike <= entries[i].Strike-price) {
+		i--
+	}
+	return entries[i].Strike, entries[i].Symbol, nil
+}
@@ -0,0 +1,180 @@
+package dxlink
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/go-playground/assert/v2"
+	"github.com/jamesonhm/gochain/internal/options"
+)
+
+func TestChainIndex(t *testing.T) {
+	x := NewChainIndex()
+	exp := time.Date(2025, 8, 8, 0, 0, 0, 0, time.UTC)
+	for _, strike := range []int{600, 590, 605, 595, 585, 620} {
+		assert.Equal(t, x.Add(fmt.Sprintf(".XSP250808P%d", strike)), nil)
+	}
+	x.Add(".XSP250808C600")
+	x.Add(".XSP250815P600")
+	assert.NotEqual(t, x.Add(""), nil)
+	assert.NotEqual(t, x.Add(".XSP"), nil)
+
+	assert.Equal(t, x.Strikes("XSP", exp, options.PutOption), []float64{585, 590, 595, 600, 605, 620})
+	assert.Equal(t, x.StrikesBetween("XSP", exp, options.PutOption, 590, 605), []float64{590, 595, 600, 605})
+	assert.Equal(t, len(x.Expirations("XSP")), 2)
+
+	inc, err := x.StrikeIncrement("XSP", exp)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, inc, 5.0)
+
+	// the date is matched by calendar day in any time zone
+	nyExp := time.Date(2025, 8, 8, 0, 0, 0, 0, time.FixedZone("EDT", -4*3600))
+	strike, sym, err := x.NearestStrike("XSP", nyExp, options.PutOption, 612)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, strike, 605.0)
+	assert.Equal(t, sym, ".XSP250808P605")
+
+	x.Remove(".XSP250808P605")
+	x.Remove(".XSP250808C600")
+	strike, _, _ = x.NearestStrike("XSP", exp, options.PutOption, 612)
+	assert.Equal(t, strike, 620.0)
+	assert.Equal(t, len(x.Strikes("XSP", exp, options.CallOption)), 0)
+}
+
+func TestOptionDataRetryStopsWithContext(t *testing.T) {
+	c := New(context.Background(), "", "")
+	c.dxlog = discardLogger()
+	c.AddOptionSubs([]string{".XSP250808P600"})
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	// the retries would wait seconds for the first quote
+	start := time.Now()
+	_, err := c.GetOptData(ctx, ".XSP250808P600")
+	assert.Equal(t, errors.Is(err, context.DeadlineExceeded), true)
+	_, err = c.NearestDelta(ctx, "XSP", time.Date(2025, 8, 8, 0, 0, 0, 0, time.UTC), options.PutOption, 0, -0.3)
+	assert.Equal(t, errors.Is(err, context.DeadlineExceeded), true)
+	assert.Equal(t, time.Since(start) < time.Second, true)
+}
+
+func TestNearestDeltaSearchesWholeExpiration(t *testing.T) {
+	c := New(context.Background(), "", "")
+	c.dxlog = discardLogger()
+	c.retries = 1
+	c.delay = time.Millisecond
+	exp := time.Date(2025, 8, 8, 0, 0, 0, 0, time.UTC)
+
+	// a gap in the strikes and a local minimum near the money
+	deltas := map[int]float64{580: -0.10, 585: -0.12, 600: -0.30, 605: -0.28, 610: -0.45}
+	var syms []string
+	for strike := range deltas {
+		syms = append(syms, fmt.Sprintf(".XSP250808P%d", strike))
+	}
+	c.AddOptionSubs(syms)
+	for strike, delta := range deltas {
+		sym := fmt.Sprintf(".XSP250808P%d", strike)
+		c.optionSubs.Update(sym, nil, func(d *OptionData) {
+			d.Greek = GreeksEvent{Symbol: sym, Delta: &delta}
+		})
+	}
+
+	data, err := c.NearestDelta(context.Background(), "XSP", exp, options.PutOption, 0, -0.11)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, *data.Greek.Delta, -0.10)
+
+	// only strikes on the round value
+	data, err = c.NearestDelta(context.Background(), "XSP", exp, options.PutOption, 10, -0.29)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, data.Greek.Symbol, ".XSP250808P600")
+
+	_, err = c.NearestDelta(context.Background(), "XSP", exp.AddDate(0, 0, 7), options.PutOption, 0, -0.3)
+	assert.NotEqual(t, err, nil)
+}
+
+func TestNearestDeltaFallsBackToModelGreeks(t *testing.T) {
+	c := New(context.Background(), "", "")
+	c.dxlog = discardLogger()
+	c.retries = 1
+	spot := 600.0
+	c.underlyingSubs.StoreNew("XSP", &UnderlyingData{Trade: TradeEvent{Price: &spot}})
+	exp := time.Now().AddDate(0, 0, 30)
+
+	strikes := []float64{560, 580, 600}
+	var syms []string
+	for _, strike := range strikes {
+		opt := options.OptionSymbol{Underlying: "XSP", Date: exp, Strike: strike, OptionType: options.PutOption}
+		syms = append(syms, opt.DxLinkString())
+	}
+	c.AddOptionSubs(syms)
+	for i, strike := range strikes {
+		sym := syms[i]
+		// a 20 vol market on each strike
+		g, _ := options.Price(options.PricingInputs{
+			Type: options.PutOption, Spot: spot, Strike: strike,
+			Years: options.Years(expiresAt(exp).Sub(time.Now()).Hours() / 24), Rate: 0.04, Vol: 0.2,
+		})
+		bid, ask := g.Price-0.05, g.Price+0.05
+		d := NewOptionData()
+		d.Quote = QuoteEvent{Symbol: sym, BidPrice: &bid, AskPrice: &ask, ReceivedAt: time.Now()}
+		c.optionSubs.Update(sym, nil, func(old *OptionData) { *old = *d })
+	}
+
+	data, err := c.NearestDelta(context.Background(), "XSP", exp, options.PutOption, 0, -0.25)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, data.Greek.EventType, ModelEventType)
+	assert.Equal(t, data.Greek.Symbol, syms[1])
+	assert.Equal(t, math.Abs(*data.Greek.Volatility-0.2) < 0.01, true)
+
+	snap, err := c.Snapshot(syms[1:2])
+	assert.Equal(t, err, nil)
+	leg, _ := snap.Leg(syms[1])
+	assert.Equal(t, math.Abs(*leg.Delta-*data.Greek.Delta) < 1e-6, true)
+}
+
+func TestNearestDeltaFindsWeeklyRoot(t *testing.T) {
+	c := New(context.Background(), "", "")
+	c.dxlog = discardLogger()
+	c.retries = 1
+	c.delay = time.Millisecond
+	exp := time.Date(2025, 8, 15, 0, 0, 0, 0, time.UTC)
+
+	// the third friday lists both the AM settled SPX and the PM settled SPXW
+	deltas := map[string]float64{
+		".SPXW250815P6300": -0.15, ".SPXW250815P6350": -0.22, ".SPXW250815P6400": -0.31,
+		".SPX250815P6350": -0.21,
+	}
+	var syms []string
+	for sym := range deltas {
+		syms = append(syms, sym)
+	}
+	c.AddOptionSubs(syms)
+	for sym, delta := range deltas {
+		c.optionSubs.Update(sym, nil, func(d *OptionData) {
+			d.Greek = GreeksEvent{Symbol: sym, Delta: &delta}
+		})
+	}
+
+	data, err := c.NearestDelta(context.Background(), "SPX", exp, options.PutOption, 0, -0.2)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, data.Greek.Symbol, ".SPXW250815P6350")
+	assert.Equal(t, c.chain.Strikes("SPX", exp, options.PutOption), []float64{6300, 6350, 6400})
+	assert.Equal(t, c.chain.Strikes("SPXW", exp, options.PutOption), []float64{6300, 6350, 6400})
+
+	// the underlying prefers the PM settled weekly at a shared strike
+	strike, sym, err := c.chain.NearestStrike("SPX", exp, options.PutOption, 6340)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, strike, 6350.0)
+	assert.Equal(t, sym, ".SPXW250815P6350")
+	_, ok := c.chain.Symbol("SPXW", exp, options.PutOption, 6350)
+	assert.Equal(t, ok, true)
+	c.chain.Remove(".SPXW250815P6350")
+	sym, _ = c.chain.Symbol("SPX", exp, options.PutOption, 6350)
+	assert.Equal(t, sym, ".SPX250815P6350")
+	_, ok = c.chain.Symbol("SPXW", exp, options.PutOption, 6350)
+	assert.Equal(t, ok, false)
+	assert.Equal(t, len(c.chain.Expirations("SPX")), 1)
+}
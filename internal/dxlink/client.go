@@ -6,57 +6,133 @@ import (
 	"fmt"
 	"iter"
 	"log/slog"
-	"maps"
-	"net/url"
 	"slices"
 
 	"sync"
+	"sync/atomic"
 	"time"
 
-	"github.com/gorilla/websocket"
+	"github.com/jamesonhm/gochain/internal/clock"
 	"github.com/jamesonhm/gochain/internal/dt"
 	"github.com/jamesonhm/gochain/internal/options"
+	"github.com/jamesonhm/gochain/internal/wsconn"
 )
 
 type DxLinkClient struct {
-	conn           *websocket.Conn
-	url            string
-	token          string
-	optionSubs     map[string]*OptionData
-	underlyingSubs map[string]*UnderlyingData
+	ws    *wsconn.Conn
+	url   string
+	token string
+	// streamed state, written only by the pipeline goroutine
+	optionSubs     *shardedMap[OptionData]
+	underlyingSubs *shardedMap[UnderlyingData]
+	// subscribed options by underlying, expiration, type and strike
+	chain *ChainIndex
+	// held by the pipeline while a feed message is applied, see Snapshot
+	applyMu sync.RWMutex
+	// raw messages in arrival order, see pipeline
+	inbox chan inboundMsg
+	// counts connections, messages read on an earlier connection are dropped
+	gen atomic.Uint64
+	// held by the pipeline while a message is applied and by onConnect while the
+	// connection state is reset, so no stale message lands after the reset
+	connMu sync.Mutex
+	// candle symbol to the start of its series
+	candleSubs     map[string]time.Time
+	candles        *CandleStore
 	mu             sync.RWMutex
-	connected      bool
 	messageCounter int
-	callbacks      map[string]MessageCallback
-	ctx            context.Context
-	cancel         context.CancelFunc
-	retries        int
-	delay          time.Duration
-	expBackoff     bool
-	dxlog          *slog.Logger
+	// feed channels subscribed on the current connection
+	subscribed map[int]bool
+	// typed listeners, see SubscribeQuotes
+	quoteTopic  topic[QuoteEvent]
+	greeksTopic topic[GreeksEvent]
+	tradeTopic  topic[TradeEvent]
+	feedFields  map[int]FeedEventFields
+	ctx         context.Context
+	cancel      context.CancelFunc
+	retries     int
+	delay       time.Duration
+	expBackoff  bool
+	// risk free rate and dividend yield for model greeks, see SetRates
+	rate  float64
+	div   float64
+	clock clock.Clock
+	dxlog *slog.Logger
 }
 
 func New(ctx context.Context, url string, token string) *DxLinkClient {
 	ctx, cancel := context.WithCancel(ctx)
 	dxlog := slog.Default()
-	return &DxLinkClient{
+	c := &DxLinkClient{
 		url:            url,
-		optionSubs:     make(map[string]*OptionData),
-		underlyingSubs: make(map[string]*UnderlyingData),
-		callbacks:      make(map[string]MessageCallback),
+		optionSubs:     newShardedMap[OptionData](),
+		underlyingSubs: newShardedMap[UnderlyingData](),
+		chain:          NewChainIndex(),
+		inbox:          make(chan inboundMsg, inboxSize),
+		candleSubs:     make(map[string]time.Time),
+		candles:        NewCandleStore(2000),
+		feedFields:     make(map[int]FeedEventFields),
+		subscribed:     make(map[int]bool),
 		ctx:            ctx,
 		cancel:         cancel,
 		token:          token,
 		retries:        3,
 		delay:          1 * time.Second,
 		expBackoff:     false,
+		rate:           0.04,
+		clock:          clock.Real{},
 		dxlog:          dxlog,
 	}
+	// candle moves follow the client clock, see SetClock
+	c.candles.now = c.now
+	c.ws = wsconn.New(wsconn.Config{
+		Name:              "dxlink",
+		URL:               url,
+		HeartbeatInterval: 30 * time.Second,
+		// the server sends keepalives within the 60s timeout requested in SETUP
+		ReadTimeout: 90 * time.Second,
+		MinBackoff:  time.Second,
+		MaxBackoff:  60 * time.Second,
+	}, wsconn.Handlers{
+		OnConnect: c.onConnect,
+		OnMessage: c.enqueue,
+		Heartbeat: func() any {
+			return KeepAliveMsg{Type: KeepAlive, Channel: 0}
+		},
+	})
+	go c.pipeline()
+	return c
+}
+
+// SetToken replaces the token used to authorize new connections
+func (c *DxLinkClient) SetToken(token string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.token = token
+}
+
+func (c *DxLinkClient) State() wsconn.State {
+	return c.ws.State()
+}
+
+// Events reports connection state changes
+func (c *DxLinkClient) Events() <-chan wsconn.Event {
+	return c.ws.Events()
+}
+
+// Done is closed when the client is closed or stops reconnecting, see Err
+func (c *DxLinkClient) Done() <-chan struct{} {
+	return c.ws.Done()
+}
+
+func (c *DxLinkClient) Err() error {
+	return c.ws.Err()
 }
 
 func (c *DxLinkClient) ResetData() {
-	clear(c.optionSubs)
-	clear(c.underlyingSubs)
+	c.optionSubs.Clear()
+	c.underlyingSubs.Clear()
+	c.chain.Clear()
 }
 
 func (c *DxLinkClient) UpdateOptionSubs(
@@ -69,26 +145,182 @@ func (c *DxLinkClient) UpdateOptionSubs(
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	c.underlyingSubs[symbol] = NewUnderlying()
+	c.underlyingSubs.StoreNew(symbol, NewUnderlying())
 	//err := c.filterOptions(options, days, mktPrice, pctRange)
 	filtered := filter(options, mktPrice, pctRange)
 	for _, option := range filtered {
-		//c.optionSubs[option] = &OptionData{}
-		c.optionSubs[option] = NewOptionData()
+		if c.optionSubs.StoreNew(option, NewOptionData()) {
+			c.indexOption(option)
+		}
 	}
 	return nil
 }
 
 func (c *DxLinkClient) LenOptionSubs() int {
-	return len(c.optionSubs)
+	return c.optionSubs.Len()
+}
+
+// AddOptionSubs subscribes to quotes and greeks for the options. The subscription is
+// sent right away when the option channel is up, otherwise with the channel setup
+func (c *DxLinkClient) AddOptionSubs(symbols []string) error {
+	c.mu.Lock()
+	var added []string
+	for _, sym := range symbols {
+		if c.optionSubs.StoreNew(sym, NewOptionData()) {
+			c.indexOption(sym)
+			added = append(added, sym)
+		}
+	}
+	live := c.subscribed[3]
+	c.mu.Unlock()
+
+	if !live || len(added) == 0 {
+		return nil
+	}
+	for _, chunk := range chunkSlice(added, 45) {
+		if err := c.sendMessage(FeedSubscriptionMsg{Type: FeedSubscription, Channel: 3, Add: optionSubItems(chunk)}); err != nil {
+			return fmt.Errorf("unable to add option subs: %w", err)
+		}
+	}
+	return nil
+}
+
+// RemoveOptionSubs unsubscribes the options and drops their data
+func (c *DxLinkClient) RemoveOptionSubs(symbols []string) error {
+	c.mu.Lock()
+	var removed []string
+	for _, sym := range symbols {
+		if c.optionSubs.Delete(sym) {
+			c.chain.Remove(sym)
+			removed = append(removed, sym)
+		}
+	}
+	live := c.subscribed[3]
+	c.mu.Unlock()
+
+	if !live || len(removed) == 0 {
+		return nil
+	}
+	for _, chunk := range chunkSlice(removed, 45) {
+		if err := c.sendMessage(FeedSubscriptionMsg{Type: FeedSubscription, Channel: 3, Remove: optionSubItems(chunk)}); err != nil {
+			return fmt.Errorf("unable to remove option subs: %w", err)
+		}
+	}
+	return nil
+}
+
+// AddUnderlyingSub subscribes to the underlyingSubTypes events for an index or equity
+func (c *DxLinkClient) AddUnderlyingSub(symbol string) error {
+	c.mu.Lock()
+	added := c.underlyingSubs.StoreNew(symbol, NewUnderlying())
+	live := c.subscribed[1]
+	c.mu.Unlock()
+
+	if !added || !live {
+		return nil
+	}
+	return c.sendMessage(FeedSubscriptionMsg{
+		Type:    FeedSubscription,
+		Channel: 1,
+		Add:     underlyingSubItems(symbol),
+	})
+}
+
+// AddCandleSub subscribes to the candles of a symbol from the given time, the bars
+// are kept in the candle store
+func (c *DxLinkClient) AddCandleSub(symbol string, period CandlePeriod, from time.Time) error {
+	candleSym := CandleSymbol(symbol, period)
+	c.mu.Lock()
+	_, ok := c.candleSubs[candleSym]
+	if !ok {
+		c.candleSubs[candleSym] = from
+	}
+	live := c.subscribed[1]
+	c.mu.Unlock()
+
+	if ok || !live {
+		return nil
+	}
+	return c.sendMessage(FeedSubscriptionMsg{
+		Type:    FeedSubscription,
+		Channel: 1,
+		Add:     []FeedSubItem{c.candleSubItem(candleSym, from)},
+	})
+}
+
+// Candles is the store of streamed bars, it implements the strategy CandlesProvider
+func (c *DxLinkClient) Candles() *CandleStore {
+	return c.candles
+}
+
+// candleSubItem requests bars from the newest one already stored, so a
+// resubscribe only backfills what was missed
+func (c *DxLinkClient) candleSubItem(candleSym string, from time.Time) FeedSubItem {
+	if last, ok := c.candles.lastTime(candleSym); ok && last.After(from) {
+		from = last
+	}
+	return FeedSubItem{Type: "Candle", Symbol: candleSym, FromTime: from.UnixMilli()}
+}
+
+// Chain indexes the subscribed options for strike searches
+func (c *DxLinkClient) Chain() *ChainIndex {
+	return c.chain
+}
+
+// options that can't be parsed stay subscribed but are not searchable
+func (c *DxLinkClient) indexOption(sym string) {
+	if err := c.chain.Add(sym); err != nil {
+		c.dxlog.Warn("option not indexed", "symbol", sym, "error", err)
+	}
+}
+
+// Underlying is a copy of the streamed data for an index or equity
+func (c *DxLinkClient) Underlying(symbol string) (UnderlyingData, bool) {
+	data, ok := c.underlyingSubs.Load(symbol)
+	if !ok {
+		return UnderlyingData{}, false
+	}
+	return *data, true
+}
+
+// OptionSubs lists the subscribed option symbols
+func (c *DxLinkClient) OptionSubs() []string {
+	return c.optionSubs.Keys()
+}
+
+// UnderlyingPrice is the last streamed trade price, false until a trade is received
+func (c *DxLinkClient) UnderlyingPrice(symbol string) (float64, bool) {
+	data, ok := c.underlyingSubs.Load(symbol)
+	if !ok || data.Trade.Price == nil || *data.Trade.Price == 0 {
+		return 0, false
+	}
+	return *data.Trade.Price, true
+}
+
+func optionSubItems(symbols []string) []FeedSubItem {
+	items := make([]FeedSubItem, 0, len(optionSubTypes)*len(symbols))
+	for _, sym := range symbols {
+		for _, typ := range optionSubTypes {
+			items = append(items, FeedSubItem{Type: typ, Symbol: sym})
+		}
+	}
+	return items
+}
+
+func underlyingSubItems(symbol string) []FeedSubItem {
+	items := make([]FeedSubItem, 0, len(underlyingSubTypes))
+	for _, typ := range underlyingSubTypes {
+		items = append(items, FeedSubItem{Type: typ, Symbol: symbol})
+	}
+	return items
 }
 
 type filterFunc func(rawOptions []string, mktPrice float64, pctRange float64) []string
 
-func FilterOptionsDays(days int) filterFunc {
+func FilterOptionsDays(clk clock.Clock, days int) filterFunc {
 	return func(rawOptions []string, mktPrice float64, pctRange float64) []string {
 		fmt.Printf("Length Options before filter: %d\n", len(rawOptions))
-		today, _ := dt.EndOfDay(time.Now())
+		today, _ := dt.EndOfDay(clk.Now())
 
 		cut_date := today.AddDate(0, 0, days)
 		upper := mktPrice * (1 + pctRange/100)
@@ -142,27 +374,22 @@ func FilterOptionsDates(dates []time.Time) filterFunc {
 	}
 }
 
+// Connect opens the connection, it is reopened and resubscribed after any failure
+// until the client is closed
 func (c *DxLinkClient) Connect() error {
+	return c.ws.Start(c.ctx)
+}
 
-	if c.connected {
-		return fmt.Errorf("client already connected")
-	}
-
-	u, err := url.Parse(c.url)
-	if err != nil {
-		return fmt.Errorf("invalid URL: %w", err)
-	}
-
-	conn, _, err := websocket.DefaultDialer.Dial(u.String(), nil)
-	if err != nil {
-		return fmt.Errorf("DXLINK dial error for url: %s : %w", u.String(), err)
-	}
-	c.conn = conn
-	c.connected = true
-
-	// Start message handler
-	go c.handleMessages()
-	go c.keepAlive()
+// onConnect starts the SETUP, AUTH, CHANNEL and FEED exchange on each new connection,
+// the subscriptions are sent once the feed config is received
+func (c *DxLinkClient) onConnect(_ *wsconn.Conn) error {
+	c.connMu.Lock()
+	c.gen.Add(1)
+	c.mu.Lock()
+	clear(c.subscribed)
+	clear(c.feedFields)
+	c.mu.Unlock()
+	c.connMu.Unlock()
 
 	setupMsg := SetupMsg{
 		Type:                   "SETUP",
@@ -171,112 +398,26 @@ func (c *DxLinkClient) Connect() error {
 		AcceptKeepAliveTimeout: 60,
 		Version:                "0.1-golang",
 	}
-
-	err = c.sendMessage(setupMsg)
-	if err != nil {
-		c.connected = false
-		c.conn.Close()
+	if err := c.sendMessage(setupMsg); err != nil {
 		return fmt.Errorf("failed to send setup message: %w", err)
 	}
-
 	return nil
 }
 
-func (c *DxLinkClient) reconnect() {
-	if !c.connected {
-		return
-	}
-	// Close existing conn if any
-	if c.conn != nil {
-		c.conn.Close()
-		c.conn = nil
-	}
-	c.connected = false
-
-	// cancel the message handler
-	c.cancel()
-
-	// Try reconnect with backoff
-	for retry := 0; retry < c.retries; retry++ {
-		slog.Warn("Attempting to reconnect", "try", retry+1, "of max", c.retries)
-
-		backoff := time.Duration(1<<uint(retry)) * time.Second
-		time.Sleep(backoff)
-
-		u, err := url.Parse(c.url)
-		if err != nil {
-			slog.Error("invalid URL during reconnect", "error", err)
-			continue
-		}
-
-		conn, _, err := websocket.DefaultDialer.Dial(u.String(), nil)
-		if err != nil {
-			slog.Error("dial error during reconnect", "url", u.String(), "err", err)
-			continue
-		}
-
-		c.conn = conn
-		c.connected = true
-
-		// Start message handler
-		go c.handleMessages()
-
-		setupMsg := SetupMsg{
-			Type:                   "SETUP",
-			Channel:                0,
-			KeepAliveTimeout:       60,
-			AcceptKeepAliveTimeout: 60,
-			Version:                "0.1-golang",
-		}
-
-		err = c.sendMessage(setupMsg)
-		if err != nil {
-			c.connected = false
-			c.conn.Close()
-			slog.Error("failed to send setup message during reconnect", "err", err)
-			continue
-		}
-	}
-	slog.Error("failed to reconnect")
-}
-
 func (c *DxLinkClient) Close() error {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-
 	slog.Info("Closing DxLink WS connection")
-	if !c.connected {
-		return fmt.Errorf("client not connected")
-	}
-
+	err := c.ws.Close()
 	c.cancel()
-
-	err := c.conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
-	if err != nil {
-		slog.Error("Error sending close message", "err", err)
-	}
-
-	c.connected = false
-	err = c.conn.Close()
 	if err != nil {
 		return fmt.Errorf("error closing connection: %w", err)
 	}
-
 	return nil
 }
 
 func (c *DxLinkClient) sendMessage(msg interface{}) error {
-	if c.conn == nil {
-		return fmt.Errorf("unable to send message, no connection")
-	}
 	c.mu.Lock()
-	defer c.mu.Unlock()
-
 	c.messageCounter++
-	data, err := json.Marshal(msg)
-	if err != nil {
-		return fmt.Errorf("error marshaling message: %w", err)
-	}
+	c.mu.Unlock()
 
 	n := 100
 	msgStr := fmt.Sprintf("%+v", msg)
@@ -287,66 +428,60 @@ func (c *DxLinkClient) sendMessage(msg interface{}) error {
 	//fd, _ := json.MarshalIndent(msg, "", "  ")
 	//fmt.Printf("sent message: %s\n", string(fd))
 
-	err = c.conn.WriteMessage(websocket.TextMessage, data)
-	if err != nil {
-		return fmt.Errorf("error sending message: %w", err)
-	}
+	return c.ws.Send(msg)
+}
 
-	return nil
+type inboundMsg struct {
+	data     []byte
+	received time.Time
+	// connection the message was read on
+	gen uint64
 }
 
-func (c *DxLinkClient) keepAlive() {
-	for {
-		select {
-		case <-c.ctx.Done():
-			return
-		default:
-			time.Sleep(30 * time.Second)
-			c.sendMessage(KeepAliveMsg{
-				Type:    KeepAlive,
-				Channel: 0,
-			})
-		}
+const inboxSize = 1024
+
+// enqueue hands a message from the read loop to the pipeline, the read loop
+// waits once the pipeline is a full inbox behind
+func (c *DxLinkClient) enqueue(message []byte) {
+	select {
+	case c.inbox <- inboundMsg{data: message, received: c.now(), gen: c.gen.Load()}:
+	case <-c.ctx.Done():
 	}
 }
 
-// handleMessages reads and processes incoming
-func (c *DxLinkClient) handleMessages() {
+// pipeline is the single stage that decodes and applies messages, in the order
+// they were read, it is the only writer of the streamed state
+func (c *DxLinkClient) pipeline() {
 	for {
 		select {
+		case msg := <-c.inbox:
+			c.connMu.Lock()
+			if msg.gen == c.gen.Load() {
+				c.processMessage(msg.data, msg.received)
+			} else {
+				c.dxlog.Debug("dropping message from a previous connection", "gen", msg.gen)
+			}
+			c.connMu.Unlock()
 		case <-c.ctx.Done():
 			return
-		default:
-			if c.conn == nil {
-				time.Sleep(100 * time.Millisecond)
-				continue
-			}
-
-			_, message, err := c.conn.ReadMessage()
-			if err != nil {
-				slog.Error("Error reading DxLink message", "err", err)
-				c.reconnect()
-			}
-
-			go c.processMessage(message)
 		}
 	}
 }
 
-func (c *DxLinkClient) processMessage(message []byte) {
-	var msgMap map[string]interface{}
-	if err := json.Unmarshal(message, &msgMap); err != nil {
+func (c *DxLinkClient) processMessage(message []byte, received time.Time) {
+	var envelope struct {
+		Type *string `json:"type"`
+	}
+	if err := json.Unmarshal(message, &envelope); err != nil {
 		slog.Error("Error unmarshaling message", "err", err)
 		return
 	}
-
-	msgType, ok := msgMap["type"].(string)
-	if !ok {
+	if envelope.Type == nil {
 		slog.Info("Unknown message format", "msg", string(message))
 		return
 	}
 
-	switch msgType {
+	switch *envelope.Type {
 	case string(Setup):
 		resp := SetupMsg{}
 		err := json.Unmarshal(message, &resp)
@@ -364,10 +499,13 @@ func (c *DxLinkClient) processMessage(message []byte) {
 		}
 		c.dxlog.Info("SERVER <-", "", resp)
 		if resp.State == "UNAUTHORIZED" {
+			c.mu.RLock()
+			token := c.token
+			c.mu.RUnlock()
 			authMsg := AuthMsg{
 				Type:    Auth,
 				Channel: 0,
-				Token:   c.token,
+				Token:   token,
 			}
 			c.sendMessage(authMsg)
 		} else if resp.State == "AUTHORIZED" {
@@ -401,7 +539,6 @@ func (c *DxLinkClient) processMessage(message []byte) {
 			return
 		}
 		c.dxlog.Info("SERVER <-", "", resp)
-		// TODO: add eventTime to quote for age validation
 		var feedSetup FeedSetupMsg
 		if resp.Channel == 1 {
 			// UNDERLYING
@@ -410,11 +547,7 @@ func (c *DxLinkClient) processMessage(message []byte) {
 				Channel:                 1,
 				AcceptAggregationPeriod: 60,
 				AcceptDataFormat:        CompactFormat,
-				AcceptEventFields: FeedEventFields{
-					//Quote: []string{"eventType", "eventSymbol", "bidPrice", "askPrice"},
-					Trade: []string{"eventType", "eventSymbol", "price", "size"},
-					//Candle: []string{"eventType", "eventSymbol", "time", "open", "high", "low", "close", "volume", "impVolatility", "openInterest"},
-				},
+				AcceptEventFields:       underlyingEventFields,
 			}
 		} else if resp.Channel == 3 {
 			// OPTIONS
@@ -423,10 +556,7 @@ func (c *DxLinkClient) processMessage(message []byte) {
 				Channel:                 3,
 				AcceptAggregationPeriod: 60,
 				AcceptDataFormat:        CompactFormat,
-				AcceptEventFields: FeedEventFields{
-					Quote:  []string{"eventType", "eventSymbol", "bidPrice", "askPrice"},
-					Greeks: []string{"eventType", "eventSymbol", "price", "volatility", "delta", "gamma", "theta", "rho", "vega"},
-				},
+				AcceptEventFields:       optionEventFields,
 			}
 		}
 		c.sendMessage(feedSetup)
@@ -439,53 +569,41 @@ func (c *DxLinkClient) processMessage(message []byte) {
 			return
 		}
 		c.dxlog.Info("SERVER <-", "", resp)
-		var feedSub FeedSubscriptionMsg
+		// symbols added after the channel is marked subscribed are sent by AddOptionSubs,
+		// those added before are in the snapshot
+		c.mu.Lock()
+		c.feedFields[resp.Channel] = defaultEventFields().merge(resp.EventFields)
+		c.subscribed[resp.Channel] = true
+		ready := c.subscribed[1] && c.subscribed[3]
+		var subs []FeedSubscriptionMsg
 		if resp.Channel == 1 {
-			feedSub = c.underlyingFeedSub()
-			c.sendMessage(feedSub)
+			subs = append(subs, c.underlyingFeedSub())
 		} else if resp.Channel == 3 {
-			fmt.Println("Channel 3 response, getting option feed subs")
-			//feedSub = c.optionFeedSub()
-			for m := range c.optionFeedIter() {
-				c.sendMessage(m)
-			}
+			subs = slices.Collect(c.optionFeedIter())
+		}
+		c.mu.Unlock()
+		for _, m := range subs {
+			c.sendMessage(m)
+		}
+		if ready {
+			c.ws.MarkReady()
 		}
 	case string(FeedData):
-		resp := FeedDataMsg{}
-		err := json.Unmarshal(message, &resp)
+		resp, err := c.decodeFeedData(message)
 		if err != nil {
 			slog.Error("unable to unmarshal feed data msg", "err", err)
 			fmt.Printf("%s\n", string(message))
 			return
 		}
 
-		c.mu.Lock()
-		defer c.mu.Unlock()
+		c.applyMu.Lock()
 		switch resp.Channel {
 		case 1:
-			if len(resp.Data.Trades) > 0 {
-				c.dxlog.Info("SERVER <-", "trades rec'd", resp.Data.Trades[0], "trades", len(resp.Data.Trades))
-				for _, trade := range resp.Data.Trades {
-					if _, ok := c.underlyingSubs[trade.Symbol]; !ok {
-						c.underlyingSubs[trade.Symbol] = NewUnderlying()
-					}
-					c.underlyingSubs[trade.Symbol].Trade = trade
-				}
-			}
+			c.applyUnderlyingData(resp.Data, received)
 		case 3:
-			if len(resp.Data.Quotes) > 0 {
-				c.dxlog.Info("SERVER <-", "quotes rec'd", resp.Data.Quotes[0], "size", len(resp.Data.Quotes))
-				for _, quote := range resp.Data.Quotes {
-					c.optionSubs[quote.Symbol].Quote = quote
-				}
-			}
-			if len(resp.Data.Greeks) > 0 {
-				c.dxlog.Info("SERVER <-", "greeks rec'd", resp.Data.Greeks[0], "size", len(resp.Data.Greeks))
-				for _, greek := range resp.Data.Greeks {
-					c.optionSubs[greek.Symbol].Greek = greek
-				}
-			}
+			c.applyOptionData(resp.Data, received)
 		}
+		c.applyMu.Unlock()
 	case string(Error):
 		resp := ErrorMsg{}
 		err := json.Unmarshal(message, &resp)
@@ -507,6 +625,105 @@ func (c *DxLinkClient) processMessage(message []byte) {
 	}
 }
 
+// applyUnderlyingData stores channel 1 events
+func (c *DxLinkClient) applyUnderlyingData(data ProcessedFeedData, received time.Time) {
+	update := func(sym string, fn func(*UnderlyingData)) {
+		c.underlyingSubs.Update(sym, NewUnderlying, fn)
+	}
+	if len(data.Trades) > 0 {
+		c.dxlog.Info("SERVER <-", "trades rec'd", data.Trades[0], "trades", len(data.Trades))
+		for _, trade := range data.Trades {
+			update(trade.Symbol, func(u *UnderlyingData) { u.Trade = trade })
+			c.tradeTopic.publish(trade.Symbol, trade)
+		}
+	}
+	for _, quote := range data.Quotes {
+		quote.ReceivedAt = received
+		update(quote.Symbol, func(u *UnderlyingData) { u.Quote = quote })
+		c.quoteTopic.publish(quote.Symbol, quote)
+	}
+	for _, summary := range data.Summaries {
+		update(summary.Symbol, func(u *UnderlyingData) { u.Summary = summary })
+	}
+	for _, profile := range data.Profiles {
+		update(profile.Symbol, func(u *UnderlyingData) {
+			if profile.Halted() != u.Halted() {
+				c.dxlog.Warn("trading status changed", "symbol", profile.Symbol, "status", profile.TradingStatus, "reason", profile.StatusReason)
+			}
+			u.Profile = profile
+		})
+	}
+	for _, sale := range data.TimeAndSales {
+		update(sale.Symbol, func(u *UnderlyingData) { u.Sale = sale })
+	}
+	if len(data.Candles) > 0 {
+		c.dxlog.Info("SERVER <-", "candles rec'd", data.Candles[0].Symbol, "size", len(data.Candles))
+		for _, candle := range data.Candles {
+			c.candles.Add(candle)
+		}
+	}
+}
+
+// applyOptionData stores channel 3 events. Data can arrive for a symbol after
+// it is unsubscribed, it is dropped
+func (c *DxLinkClient) applyOptionData(data ProcessedFeedData, received time.Time) {
+	update := func(sym string, fn func(*OptionData)) bool {
+		return c.optionSubs.Update(sym, nil, fn)
+	}
+	if len(data.Quotes) > 0 {
+		c.dxlog.Info("SERVER <-", "quotes rec'd", data.Quotes[0], "size", len(data.Quotes))
+		for _, quote := range data.Quotes {
+			quote.ReceivedAt = received
+			if update(quote.Symbol, func(d *OptionData) { d.Quote = quote }) {
+				c.quoteTopic.publish(quote.Symbol, quote)
+			}
+		}
+	}
+	if len(data.Greeks) > 0 {
+		c.dxlog.Info("SERVER <-", "greeks rec'd", data.Greeks[0], "size", len(data.Greeks))
+		for _, greek := range data.Greeks {
+			greek.ReceivedAt = received
+			if update(greek.Symbol, func(d *OptionData) { d.Greek = greek }) {
+				c.greeksTopic.publish(greek.Symbol, greek)
+			}
+		}
+	}
+	for _, trade := range data.Trades {
+		if update(trade.Symbol, func(d *OptionData) { d.Trade = trade }) {
+			c.tradeTopic.publish(trade.Symbol, trade)
+		}
+	}
+	for _, summary := range data.Summaries {
+		update(summary.Symbol, func(d *OptionData) { d.Summary = summary })
+	}
+	for _, theo := range data.TheoPrices {
+		update(theo.Symbol, func(d *OptionData) { d.TheoPrice = theo })
+	}
+}
+
+// decodeFeedData parses a FEED_DATA message using the event fields confirmed for its channel
+func (c *DxLinkClient) decodeFeedData(message []byte) (FeedDataMsg, error) {
+	raw := struct {
+		Type    MsgType         `json:"type"`
+		Channel int             `json:"channel"`
+		Data    json.RawMessage `json:"data"`
+	}{}
+	if err := json.Unmarshal(message, &raw); err != nil {
+		return FeedDataMsg{}, err
+	}
+
+	c.mu.RLock()
+	fields, ok := c.feedFields[raw.Channel]
+	c.mu.RUnlock()
+	if !ok {
+		fields = defaultEventFields()
+	}
+
+	resp := FeedDataMsg{Type: raw.Type, Channel: raw.Channel}
+	err := resp.Data.decode(raw.Data, fields)
+	return resp, err
+}
+
 func (c *DxLinkClient) underlyingFeedSub() FeedSubscriptionMsg {
 	feedSub := FeedSubscriptionMsg{
 		Type:    FeedSubscription,
@@ -515,9 +732,11 @@ func (c *DxLinkClient) underlyingFeedSub() FeedSubscriptionMsg {
 		Add:     []FeedSubItem{},
 	}
 
-	for under := range c.underlyingSubs {
-		//feedSub.Add = append(feedSub.Add, FeedSubItem{Type: "Quote", Symbol: under})
-		feedSub.Add = append(feedSub.Add, FeedSubItem{Type: "Trade", Symbol: under})
+	for _, under := range c.underlyingSubs.Keys() {
+		feedSub.Add = append(feedSub.Add, underlyingSubItems(under)...)
+	}
+	for candleSym, from := range c.candleSubs {
+		feedSub.Add = append(feedSub.Add, c.candleSubItem(candleSym, from))
 	}
 	return feedSub
 }
@@ -529,7 +748,7 @@ func (c *DxLinkClient) optionFeedSub() FeedSubscriptionMsg {
 		Reset:   true,
 		Add:     []FeedSubItem{},
 	}
-	for opt := range c.optionSubs {
+	for _, opt := range c.optionSubs.Keys() {
 		//slog.Info("OptionFeedSub method", "OptionSub iter:", opt)
 		if len(feedSub.Add) >= 90 {
 			break
@@ -542,7 +761,7 @@ func (c *DxLinkClient) optionFeedSub() FeedSubscriptionMsg {
 
 func (c *DxLinkClient) optionFeedIter() iter.Seq[FeedSubscriptionMsg] {
 	return func(yield func(FeedSubscriptionMsg) bool) {
-		syms := slices.Collect(maps.Keys(c.optionSubs))
+		syms := c.optionSubs.Keys()
 		chunks := chunkSlice(syms, 45)
 
 		for _, c := range chunks {
@@ -552,10 +771,7 @@ func (c *DxLinkClient) optionFeedIter() iter.Seq[FeedSubscriptionMsg] {
 				Reset:   false,
 				Add:     []FeedSubItem{},
 			}
-			for _, v := range c {
-				feedSub.Add = append(feedSub.Add, FeedSubItem{Type: "Quote", Symbol: v})
-				feedSub.Add = append(feedSub.Add, FeedSubItem{Type: "Greeks", Symbol: v})
-			}
+			feedSub.Add = optionSubItems(c)
 			if !yield(feedSub) {
 				return
 			}
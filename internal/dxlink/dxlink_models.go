@@ -3,6 +3,8 @@ package dxlink
 import (
 	"encoding/json"
 	"fmt"
+	"math"
+	"time"
 )
 
 type MsgType string
@@ -38,9 +40,6 @@ const (
 	CompactFormat FeedDataFormat = "COMPACT"
 )
 
-// MessageCallback is a function type for handling received messages
-type MessageCallback func(message []byte)
-
 type ErrorMsg struct {
 	Type    MsgType `json:"type"`
 	Channel int     `json:"channel"`
@@ -126,10 +125,14 @@ type FeedConfigMsg struct {
 }
 
 type FeedEventFields struct {
-	Quote  []string `json:"Quote,omitempty"`
-	Trade  []string `json:"Trade,omitempty"`
-	Candle []string `json:"Candle,omitempty"`
-	Greeks []string `json:"Greeks,omitempty"`
+	Quote       []string `json:"Quote,omitempty"`
+	Trade       []string `json:"Trade,omitempty"`
+	Candle      []string `json:"Candle,omitempty"`
+	Greeks      []string `json:"Greeks,omitempty"`
+	Summary     []string `json:"Summary,omitempty"`
+	Profile     []string `json:"Profile,omitempty"`
+	TheoPrice   []string `json:"TheoPrice,omitempty"`
+	TimeAndSale []string `json:"TimeAndSale,omitempty"`
 }
 
 type FeedDataMsg struct {
@@ -139,10 +142,14 @@ type FeedDataMsg struct {
 }
 
 type ProcessedFeedData struct {
-	Quotes  []QuoteEvent
-	Trades  []TradeEvent
-	Greeks  []GreeksEvent
-	Candles []CandleEvent
+	Quotes       []QuoteEvent
+	Trades       []TradeEvent
+	Greeks       []GreeksEvent
+	Candles      []CandleEvent
+	Summaries    []SummaryEvent
+	Profiles     []ProfileEvent
+	TheoPrices   []TheoPriceEvent
+	TimeAndSales []TimeAndSaleEvent
 }
 
 // Quote event is a snapshot of the best bid and ask prices,
@@ -152,6 +159,20 @@ type QuoteEvent struct {
 	Symbol    string
 	BidPrice  *float64
 	AskPrice  *float64
+	BidSize   *float64
+	AskSize   *float64
+	// exchange time of the quote, zero when not sent by the feed
+	EventTime time.Time
+	// local time the quote was received from the feed
+	ReceivedAt time.Time
+}
+
+// Time of the quote, the event time when known, otherwise the receive time
+func (q QuoteEvent) Time() time.Time {
+	if !q.EventTime.IsZero() {
+		return q.EventTime
+	}
+	return q.ReceivedAt
 }
 
 type TradeEvent struct {
@@ -159,6 +180,7 @@ type TradeEvent struct {
 	Symbol    string
 	Price     *float64
 	Size      *float64
+	DayVolume *float64
 }
 
 // Greeks event is a snapshot of the option price, Black-Scholes volatility and greeks
@@ -172,6 +194,16 @@ type GreeksEvent struct {
 	Theta      *float64
 	Rho        *float64
 	Vega       *float64
+	EventTime  time.Time
+	ReceivedAt time.Time
+}
+
+// Time of the greeks, the event time when known, otherwise the receive time
+func (g GreeksEvent) Time() time.Time {
+	if !g.EventTime.IsZero() {
+		return g.EventTime
+	}
+	return g.ReceivedAt
 }
 
 type CandleEvent struct {
@@ -187,9 +219,75 @@ type CandleEvent struct {
 	OpenInterest  *float64
 }
 
+// Summary event is the daily open, high, low, prior close and open interest
+type SummaryEvent struct {
+	EventType         string
+	Symbol            string
+	DayOpenPrice      *float64
+	DayHighPrice      *float64
+	DayLowPrice       *float64
+	DayClosePrice     *float64
+	PrevDayClosePrice *float64
+	PrevDayVolume     *float64
+	OpenInterest      *float64
+}
+
+// Profile event describes the instrument and its trading status, ex. halts
+type ProfileEvent struct {
+	EventType       string
+	Symbol          string
+	Description     string
+	TradingStatus   string
+	StatusReason    string
+	HaltStartTime   time.Time
+	HaltEndTime     time.Time
+	High52WeekPrice *float64
+	Low52WeekPrice  *float64
+}
+
+func (p ProfileEvent) Halted() bool {
+	return p.TradingStatus == "HALTED"
+}
+
+// TheoPrice event is the theoretical option price from the feed's own model
+type TheoPriceEvent struct {
+	EventType       string
+	Symbol          string
+	Time            time.Time
+	Price           *float64
+	UnderlyingPrice *float64
+	Delta           *float64
+	Gamma           *float64
+	Dividend        *float64
+	Interest        *float64
+}
+
+// TimeAndSale event is a single trade print with the quote at the time of the sale
+type TimeAndSaleEvent struct {
+	EventType     string
+	Symbol        string
+	Time          time.Time
+	Price         *float64
+	Size          *float64
+	BidPrice      *float64
+	AskPrice      *float64
+	AggressorSide string
+}
+
 type OptionData struct {
-	Quote QuoteEvent
-	Greek GreeksEvent
+	Quote     QuoteEvent
+	Greek     GreeksEvent
+	Trade     TradeEvent
+	Summary   SummaryEvent
+	TheoPrice TheoPriceEvent
+}
+
+func (o *OptionData) OpenInterest() (float64, bool) {
+	return value(o.Summary.OpenInterest)
+}
+
+func (o *OptionData) DayVolume() (float64, bool) {
+	return value(o.Trade.DayVolume)
 }
 
 func NewOptionData() *OptionData {
@@ -210,10 +308,34 @@ func NewOptionData() *OptionData {
 	}
 }
 
+// candles for an underlying are kept in the client CandleStore
 type UnderlyingData struct {
-	Trade TradeEvent
-	//Candles map[int64]CandleEvent
-	//Candles []*CandleEvent
+	Trade   TradeEvent
+	Quote   QuoteEvent
+	Summary SummaryEvent
+	Profile ProfileEvent
+	// last time and sale print
+	Sale TimeAndSaleEvent
+}
+
+func (u *UnderlyingData) PrevClose() (float64, bool) {
+	return value(u.Summary.PrevDayClosePrice)
+}
+
+func (u *UnderlyingData) DayVolume() (float64, bool) {
+	return value(u.Trade.DayVolume)
+}
+
+func (u *UnderlyingData) Halted() bool {
+	return u.Profile.Halted()
+}
+
+// value of an optional feed field, false when it was not sent or is NaN
+func value(v *float64) (float64, bool) {
+	if v == nil || math.IsNaN(*v) {
+		return 0, false
+	}
+	return *v, true
 }
 
 func NewUnderlying() *UnderlyingData {
@@ -221,7 +343,6 @@ func NewUnderlying() *UnderlyingData {
 		Trade: TradeEvent{
 			Price: new(float64),
 		},
-		//Candles: make(map[int64]CandleEvent),
 	}
 }
 
@@ -233,7 +354,144 @@ func jsonDouble(value interface{}) *float64 {
 	return jd
 }
 
+// Event fields requested for each channel, the COMPACT data is decoded with the
+// field order the server confirms in FEED_CONFIG, falling back to these
+var (
+	quoteFields       = []string{"eventType", "eventSymbol", "eventTime", "bidTime", "askTime", "bidPrice", "askPrice", "bidSize", "askSize"}
+	tradeFields       = []string{"eventType", "eventSymbol", "price", "size", "dayVolume"}
+	summaryFields     = []string{"eventType", "eventSymbol", "dayOpenPrice", "dayHighPrice", "dayLowPrice", "dayClosePrice", "prevDayClosePrice", "prevDayVolume", "openInterest"}
+	profileFields     = []string{"eventType", "eventSymbol", "description", "tradingStatus", "statusReason", "haltStartTime", "haltEndTime", "high52WeekPrice", "low52WeekPrice"}
+	theoPriceFields   = []string{"eventType", "eventSymbol", "time", "price", "underlyingPrice", "delta", "gamma", "dividend", "interest"}
+	timeAndSaleFields = []string{"eventType", "eventSymbol", "time", "price", "size", "bidPrice", "askPrice", "aggressorSide"}
+	candleEventFields = []string{"eventType", "eventSymbol", "time", "open", "high", "low", "close", "volume", "impVolatility", "openInterest"}
+
+	// event types shared by both channels use the same layout
+	underlyingEventFields = FeedEventFields{
+		Quote:       quoteFields,
+		Trade:       tradeFields,
+		Candle:      candleEventFields,
+		Summary:     summaryFields,
+		Profile:     profileFields,
+		TimeAndSale: timeAndSaleFields,
+	}
+	optionEventFields = FeedEventFields{
+		Quote:     quoteFields,
+		Trade:     tradeFields,
+		Greeks:    []string{"eventType", "eventSymbol", "eventTime", "time", "price", "volatility", "delta", "gamma", "theta", "rho", "vega"},
+		Summary:   summaryFields,
+		TheoPrice: theoPriceFields,
+	}
+
+	// event types subscribed for each symbol, candles are subscribed separately
+	underlyingSubTypes = []string{"Trade", "Quote", "Summary", "Profile", "TimeAndSale"}
+	optionSubTypes     = []string{"Quote", "Greeks", "Trade", "Summary", "TheoPrice"}
+)
+
+// fields for a single event type
+func (f FeedEventFields) layout(eventType string) []string {
+	switch eventType {
+	case "Quote":
+		return f.Quote
+	case "Trade":
+		return f.Trade
+	case "Candle":
+		return f.Candle
+	case "Greeks":
+		return f.Greeks
+	case "Summary":
+		return f.Summary
+	case "Profile":
+		return f.Profile
+	case "TheoPrice":
+		return f.TheoPrice
+	case "TimeAndSale":
+		return f.TimeAndSale
+	}
+	return nil
+}
+
+// merges the layouts of other into f, other wins for event types it defines
+func (f FeedEventFields) merge(other FeedEventFields) FeedEventFields {
+	if len(other.Quote) > 0 {
+		f.Quote = other.Quote
+	}
+	if len(other.Trade) > 0 {
+		f.Trade = other.Trade
+	}
+	if len(other.Candle) > 0 {
+		f.Candle = other.Candle
+	}
+	if len(other.Greeks) > 0 {
+		f.Greeks = other.Greeks
+	}
+	if len(other.Summary) > 0 {
+		f.Summary = other.Summary
+	}
+	if len(other.Profile) > 0 {
+		f.Profile = other.Profile
+	}
+	if len(other.TheoPrice) > 0 {
+		f.TheoPrice = other.TheoPrice
+	}
+	if len(other.TimeAndSale) > 0 {
+		f.TimeAndSale = other.TimeAndSale
+	}
+	return f
+}
+
+func defaultEventFields() FeedEventFields {
+	return underlyingEventFields.merge(optionEventFields)
+}
+
+// compactRecord is a single event from COMPACT feed data, values are looked up by field name
+type compactRecord struct {
+	index  map[string]int
+	values []interface{}
+}
+
+func (r compactRecord) str(name string) (string, bool) {
+	i, ok := r.index[name]
+	if !ok {
+		return "", false
+	}
+	v, ok := r.values[i].(string)
+	return v, ok
+}
+
+func (r compactRecord) num(name string) *float64 {
+	i, ok := r.index[name]
+	if !ok {
+		return nil
+	}
+	return jsonDouble(r.values[i])
+}
+
+// dxlink times are unix milliseconds, 0 when the time is not known
+func (r compactRecord) time(name string) time.Time {
+	ms := r.num(name)
+	if ms == nil || *ms <= 0 {
+		return time.Time{}
+	}
+	return time.UnixMilli(int64(*ms))
+}
+
+func latest(times ...time.Time) time.Time {
+	var t time.Time
+	for _, ti := range times {
+		if ti.After(t) {
+			t = ti
+		}
+	}
+	return t
+}
+
 func (d *ProcessedFeedData) UnmarshalJSON(data []byte) error {
+	return d.decode(data, defaultEventFields())
+}
+
+// decode parses COMPACT feed data, where each event type is followed by a flat
+// array of values for one or more events in the order of the event fields
+func (d *ProcessedFeedData) decode(data []byte, fields FeedEventFields) error {
 	var content []interface{}
 	if err := json.Unmarshal(data, &content); err != nil {
 		return err
@@ -250,118 +508,121 @@ func (d *ProcessedFeedData) UnmarshalJSON(data []byte) error {
 			continue
 		}
 
-		switch typeName {
-		case "Trade":
-			for j := 0; j < len(values); j += 4 {
-				if j+3 > len(values) {
-					break
-				}
-				evtType, ok := values[j].(string)
-				symbol, ok := values[j+1].(string)
-				if !ok {
-					return fmt.Errorf("unable to unmarshal Trade values")
-				}
-				price := jsonDouble(values[j+2])
-				size := jsonDouble(values[j+3])
-
-				trade := TradeEvent{
+		layout := fields.layout(typeName)
+		if len(layout) == 0 {
+			continue
+		}
+		index := make(map[string]int, len(layout))
+		for pos, name := range layout {
+			index[name] = pos
+		}
+
+		for j := 0; j+len(layout) <= len(values); j += len(layout) {
+			rec := compactRecord{index: index, values: values[j : j+len(layout)]}
+			evtType, _ := rec.str("eventType")
+			symbol, ok := rec.str("eventSymbol")
+			if !ok {
+				return fmt.Errorf("unable to unmarshal %s symbol", typeName)
+			}
+
+			switch typeName {
+			case "Trade":
+				d.Trades = append(d.Trades, TradeEvent{
 					EventType: evtType,
 					Symbol:    symbol,
-					Price:     price,
-					Size:      size,
-				}
-				d.Trades = append(d.Trades, trade)
-			}
-		case "Quote":
-			for j := 0; j < len(values); j += 4 {
-				if j+3 > len(values) {
-					break
-				}
-				evtType, ok := values[j].(string)
-				symbol, ok := values[j+1].(string)
-				askPrice := jsonDouble(values[j+2])
-				bidPrice := jsonDouble(values[j+3])
-				if !ok {
-					return fmt.Errorf("unable to unmarshal Quote values")
-				}
-
-				quote := QuoteEvent{
+					Price:     rec.num("price"),
+					Size:      rec.num("size"),
+					DayVolume: rec.num("dayVolume"),
+				})
+			case "Quote":
+				d.Quotes = append(d.Quotes, QuoteEvent{
 					EventType: evtType,
 					Symbol:    symbol,
-					BidPrice:  bidPrice,
-					AskPrice:  askPrice,
-				}
-				d.Quotes = append(d.Quotes, quote)
-			}
-		case "Greeks":
-			for j := 0; j < len(values); j += 9 {
-				if len(values)-j < 9 {
-					break
-				}
-				evtType, ok := values[j].(string)
-				symbol, ok := values[j+1].(string)
-				if !ok {
-					return fmt.Errorf("unable to unmarshal Greek values")
-				}
-				price := jsonDouble(values[j+2])
-				volatility := jsonDouble(values[j+3])
-				delta := jsonDouble(values[j+4])
-				gamma := jsonDouble(values[j+5])
-				theta := jsonDouble(values[j+6])
-				rho := jsonDouble(values[j+7])
-				vega := jsonDouble(values[j+8])
-
-				greeks := GreeksEvent{
+					BidPrice:  rec.num("bidPrice"),
+					AskPrice:  rec.num("askPrice"),
+					BidSize:   rec.num("bidSize"),
+					AskSize:   rec.num("askSize"),
+					EventTime: latest(rec.time("eventTime"), rec.time("bidTime"), rec.time("askTime")),
+				})
+			case "Greeks":
+				d.Greeks = append(d.Greeks, GreeksEvent{
 					EventType:  evtType,
 					Symbol:     symbol,
-					Price:      price,
-					Volatility: volatility,
-					Delta:      delta,
-					Gamma:      gamma,
-					Theta:      theta,
-					Rho:        rho,
-					Vega:       vega,
-				}
-				d.Greeks = append(d.Greeks, greeks)
-			}
-		case "Candle":
-			for j := 0; j < len(values); j += 10 {
-				if len(values)-j < 10 {
-					break
-				}
-				evtType, ok := values[j].(string)
-				if !ok {
-					return fmt.Errorf("unable to unmarshal Candle type")
-				}
-				symbol, ok := values[j+1].(string)
-				if !ok {
-					return fmt.Errorf("unable to unmarshal Candle symbol")
-				}
-				time := jsonDouble(values[j+2])
-				open := jsonDouble(values[j+3])
-				high := jsonDouble(values[j+4])
-				low := jsonDouble(values[j+5])
-				closep := jsonDouble(values[j+6])
-				volume := jsonDouble(values[j+7])
-				impVol := jsonDouble(values[j+8])
-				openInt := jsonDouble(values[j+9])
-
-				candle := CandleEvent{
+					Price:      rec.num("price"),
+					Volatility: rec.num("volatility"),
+					Delta:      rec.num("delta"),
+					Gamma:      rec.num("gamma"),
+					Theta:      rec.num("theta"),
+					Rho:        rec.num("rho"),
+					Vega:       rec.num("vega"),
+					EventTime:  latest(rec.time("eventTime"), rec.time("time")),
+				})
+			case "Candle":
+				d.Candles = append(d.Candles, CandleEvent{
 					EventType:     evtType,
 					Symbol:        symbol,
-					Time:          time,
-					Open:          open,
-					Low:           low,
-					High:          high,
-					Close:         closep,
-					Volume:        volume,
-					ImpVolatility: impVol,
-					OpenInterest:  openInt,
-				}
-				d.Candles = append(d.Candles, candle)
+					Time:          rec.num("time"),
+					Open:          rec.num("open"),
+					High:          rec.num("high"),
+					Low:           rec.num("low"),
+					Close:         rec.num("close"),
+					Volume:        rec.num("volume"),
+					ImpVolatility: rec.num("impVolatility"),
+					OpenInterest:  rec.num("openInterest"),
+				})
+			case "Summary":
+				d.Summaries = append(d.Summaries, SummaryEvent{
+					EventType:         evtType,
+					Symbol:            symbol,
+					DayOpenPrice:      rec.num("dayOpenPrice"),
+					DayHighPrice:      rec.num("dayHighPrice"),
+					DayLowPrice:       rec.num("dayLowPrice"),
+					DayClosePrice:     rec.num("dayClosePrice"),
+					PrevDayClosePrice: rec.num("prevDayClosePrice"),
+					PrevDayVolume:     rec.num("prevDayVolume"),
+					OpenInterest:      rec.num("openInterest"),
+				})
+			case "Profile":
+				description, _ := rec.str("description")
+				status, _ := rec.str("tradingStatus")
+				reason, _ := rec.str("statusReason")
+				d.Profiles = append(d.Profiles, ProfileEvent{
+					EventType:       evtType,
+					Symbol:          symbol,
+					Description:     description,
+					TradingStatus:   status,
+					StatusReason:    reason,
+					HaltStartTime:   rec.time("haltStartTime"),
+					HaltEndTime:     rec.time("haltEndTime"),
+					High52WeekPrice: rec.num("high52WeekPrice"),
+					Low52WeekPrice:  rec.num("low52WeekPrice"),
+				})
+			case "TheoPrice":
+				d.TheoPrices = append(d.TheoPrices, TheoPriceEvent{
+					EventType:       evtType,
+					Symbol:          symbol,
+					Time:            rec.time("time"),
+					Price:           rec.num("price"),
+					UnderlyingPrice: rec.num("underlyingPrice"),
+					Delta:           rec.num("delta"),
+					Gamma:           rec.num("gamma"),
+					Dividend:        rec.num("dividend"),
+					Interest:        rec.num("interest"),
+				})
+			case "TimeAndSale":
+				side, _ := rec.str("aggressorSide")
+				d.TimeAndSales = append(d.TimeAndSales, TimeAndSaleEvent{
+					EventType:     evtType,
+					Symbol:        symbol,
+					Time:          rec.time("time"),
+					Price:         rec.num("price"),
+					Size:          rec.num("size"),
+					BidPrice:      rec.num("bidPrice"),
+					AskPrice:      rec.num("askPrice"),
+					AggressorSide: side,
+				})
 			}
 		}
-
 	}
 	return nil
 }
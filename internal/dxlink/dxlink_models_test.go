@@ -0,0 +1,64 @@
+package dxlink
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-playground/assert/v2"
+)
+
+func TestDecodeCompactByFieldName(t *testing.T) {
+	data := []byte(`["Quote",["Quote",".XSP250808P600",1754056800000,0,0,1.1,1.25,"Quote",".XSP250808P605",0,0,0,1.5,1.7]]`)
+	fields := FeedEventFields{
+		Quote: []string{"eventType", "eventSymbol", "eventTime", "bidTime", "askTime", "bidPrice", "askPrice"},
+	}
+	var feed ProcessedFeedData
+	err := feed.decode(data, fields)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(feed.Quotes), 2)
+	assert.Equal(t, *feed.Quotes[0].BidPrice, 1.1)
+	assert.Equal(t, *feed.Quotes[0].AskPrice, 1.25)
+	assert.Equal(t, feed.Quotes[0].EventTime, time.UnixMilli(1754056800000))
+	assert.Equal(t, feed.Quotes[1].EventTime.IsZero(), true)
+
+	// field order confirmed by the server differs from the request
+	fields.Quote = []string{"eventType", "eventSymbol", "askPrice", "bidPrice"}
+	feed = ProcessedFeedData{}
+	err = feed.decode([]byte(`["Quote",["Quote",".XSP250808P600",1.25,1.1]]`), fields)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, *feed.Quotes[0].BidPrice, 1.1)
+	assert.Equal(t, *feed.Quotes[0].AskPrice, 1.25)
+}
+
+func TestDecodeMarketEvents(t *testing.T) {
+	data := []byte(`["Summary",["Summary","SPY",630.1,634.5,629.8,"NaN",628.9,61234567,0],` +
+		`"Profile",["Profile","SPY","SPDR S&P 500","HALTED","LUDP",1754056800000,0,"NaN","NaN"],` +
+		`"TheoPrice",["TheoPrice",".SPY250808C630",1754056800000,2.15,631.2,0.52,0.08,0,0.043],` +
+		`"TimeAndSale",["TimeAndSale","SPY",1754056800000,631.25,100,631.24,631.26,"BUY"]]`)
+	var feed ProcessedFeedData
+	err := feed.decode(data, defaultEventFields())
+	assert.Equal(t, err, nil)
+
+	assert.Equal(t, len(feed.Summaries), 1)
+	assert.Equal(t, *feed.Summaries[0].PrevDayClosePrice, 628.9)
+	assert.Equal(t, feed.Summaries[0].DayClosePrice, (*float64)(nil))
+
+	u := NewUnderlying()
+	u.Summary = feed.Summaries[0]
+	u.Profile = feed.Profiles[0]
+	prev, ok := u.PrevClose()
+	assert.Equal(t, ok, true)
+	assert.Equal(t, prev, 628.9)
+	assert.Equal(t, u.Halted(), true)
+	assert.Equal(t, feed.Profiles[0].StatusReason, "LUDP")
+	assert.Equal(t, feed.Profiles[0].HaltEndTime.IsZero(), true)
+
+	assert.Equal(t, *feed.TheoPrices[0].Price, 2.15)
+	assert.Equal(t, *feed.TheoPrices[0].UnderlyingPrice, 631.2)
+	assert.Equal(t, feed.TimeAndSales[0].AggressorSide, "BUY")
+	assert.Equal(t, feed.TimeAndSales[0].Time, time.UnixMilli(1754056800000))
+
+	opt := NewOptionData()
+	_, ok = opt.OpenInterest()
+	assert.Equal(t, ok, false)
+}
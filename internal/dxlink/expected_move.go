@@ -0,0 +1,115 @@
+package dxlink
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/jamesonhm/gochain/internal/options"
+	"github.com/jamesonhm/gochain/internal/symbology"
+)
+
+const (
+	MoveFromStraddle = "straddle"
+	MoveFromIV       = "iv"
+)
+
+// ExpectedMove is the one standard deviation move priced by the options of an
+// expiration, in points of the underlying
+type ExpectedMove struct {
+	Expiration time.Time
+	Spot       float64
+	Strike     float64
+	Move       float64
+	// MoveFromStraddle or MoveFromIV
+	Source string
+}
+
+// Pct is the move as a percent of the underlying price
+func (m ExpectedMove) Pct() float64 {
+	if m.Spot == 0 {
+		return 0
+	}
+	return m.Move / m.Spot * 100
+}
+
+// straddleMid is the mid of the call and put at the strike, both sides must have a bid
+func (c *DxLinkClient) straddleMid(root string, exp time.Time, strike float64) (float64, bool) {
+	var mid float64
+	for _, optType := range []options.OptionType{options.CallOption, options.PutOption} {
+		sym, ok := c.chain.Symbol(root, exp, optType, strike)
+		if !ok {
+			return 0, false
+		}
+		d, ok := c.optionSubs.Load(sym)
+		if !ok {
+			return 0, false
+		}
+		bid, okBid := value(d.Quote.BidPrice)
+		ask, okAsk := value(d.Quote.AskPrice)
+		if !okBid || !okAsk || bid <= 0 || ask < bid {
+			return 0, false
+		}
+		mid += (bid + ask) / 2
+	}
+	return mid, true
+}
+
+// atmIV averages the streamed IVs of the call and put at the strike, falling back
+// to the vol surface of the root
+func (c *DxLinkClient) atmIV(root string, exp time.Time, strike, years float64) (float64, error) {
+	var sum float64
+	var n int
+	for _, optType := range []options.OptionType{options.CallOption, options.PutOption} {
+		sym, ok := c.chain.Symbol(root, exp, optType, strike)
+		if !ok {
+			continue
+		}
+		d, ok := c.optionSubs.Load(sym)
+		if !ok || !hasGreeks(d) {
+			continue
+		}
+		if iv, ok := value(d.Greek.Volatility); ok && iv > 0 {
+			sum += iv
+			n++
+		}
+	}
+	if n > 0 {
+		return sum / float64(n), nil
+	}
+	surface, err := c.Surface(root)
+	if err != nil {
+		return 0, err
+	}
+	return surface.ATMIV(years)
+}
+
+// ExpectedMove is the mid of the ATM straddle of the expiration, or the move implied
+// by the ATM IV over the time to expiration when the straddle isn't quoted on both sides
+func (c *DxLinkClient) ExpectedMove(root string, exp time.Time) (ExpectedMove, error) {
+	underlying := symbology.Underlying(root)
+	spot, ok := c.UnderlyingPrice(underlying)
+	if !ok {
+		return ExpectedMove{}, fmt.Errorf("expected move: no price for underlying %s", underlying)
+	}
+	strike, _, err := c.chain.NearestStrike(root, exp, options.CallOption, spot)
+	if err != nil {
+		return ExpectedMove{}, fmt.Errorf("expected move: %w", err)
+	}
+	em := ExpectedMove{Expiration: exp, Spot: spot, Strike: strike}
+	if mid, ok := c.straddleMid(root, exp, strike); ok {
+		em.Move, em.Source = mid, MoveFromStraddle
+		return em, nil
+	}
+
+	years := options.Years(expiresAt(exp).Sub(c.now()).Hours() / 24)
+	if years <= 0 {
+		return ExpectedMove{}, fmt.Errorf("expected move: %s %s has expired", root, expKey(exp))
+	}
+	iv, err := c.atmIV(root, exp, strike, years)
+	if err != nil {
+		return ExpectedMove{}, fmt.Errorf("expected move: no straddle quote or ATM IV for %s %s: %w", root, expKey(exp), err)
+	}
+	em.Move, em.Source = spot*iv*math.Sqrt(years), MoveFromIV
+	return em, nil
+}
@@ -0,0 +1,55 @@
+package dxlink
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/go-playground/assert/v2"
+	"github.com/jamesonhm/gochain/internal/clock"
+	"github.com/jamesonhm/gochain/internal/dt"
+	"github.com/jamesonhm/gochain/internal/options"
+)
+
+func TestExpectedMove(t *testing.T) {
+	c := New(context.Background(), "", "")
+	c.dxlog = discardLogger()
+	spot := 601.0
+	c.underlyingSubs.StoreNew("XSP", &UnderlyingData{Trade: TradeEvent{Price: &spot}})
+	now := time.Date(2025, 7, 7, 10, 0, 0, 0, dt.TZNY())
+	c.SetClock(clock.NewFixed(now))
+	exp := dt.Midnight(now).AddDate(0, 0, 3)
+
+	quote := func(strike float64, optType options.OptionType, bid, ask, iv float64) {
+		sym := options.OptionSymbol{Underlying: "XSP", Date: exp, Strike: strike, OptionType: optType}.DxLinkString()
+		c.AddOptionSubs([]string{sym})
+		c.optionSubs.Update(sym, nil, func(d *OptionData) {
+			d.Quote = QuoteEvent{Symbol: sym, BidPrice: &bid, AskPrice: &ask}
+			d.Greek = GreeksEvent{Symbol: sym, Delta: new(float64), Volatility: &iv}
+		})
+	}
+	quote(595, options.CallOption, 8, 8.2, 0.2)
+	quote(600, options.CallOption, 4.9, 5.1, 0.18)
+	quote(600, options.PutOption, 3.9, 4.1, 0.2)
+	quote(605, options.CallOption, 2, 2.2, 0.17)
+
+	em, err := c.ExpectedMove("XSP", exp)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, em.Source, MoveFromStraddle)
+	assert.Equal(t, em.Strike, 600.0)
+	assert.Equal(t, em.Move, 9.0)
+	assert.Equal(t, math.Round(em.Pct()*1000)/1000, 1.498)
+
+	// without a put bid the ATM IVs are used
+	quote(600, options.PutOption, 0, 4.1, 0.2)
+	em, err = c.ExpectedMove("XSP", exp)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, em.Source, MoveFromIV)
+	// 3 days and 6 hours to the close on expiration
+	years := options.Years(3.25)
+	assert.Equal(t, math.Abs(em.Move-spot*0.19*math.Sqrt(years)) < 1e-9, true)
+
+	_, err = c.ExpectedMove("XSP", exp.AddDate(0, 0, 7))
+	assert.NotEqual(t, err, nil)
+}
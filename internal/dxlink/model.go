@@ -0,0 +1,115 @@
+package dxlink
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/jamesonhm/gochain/internal/clock"
+	"github.com/jamesonhm/gochain/internal/dt"
+	"github.com/jamesonhm/gochain/internal/options"
+	"github.com/jamesonhm/gochain/internal/symbology"
+)
+
+// ModelEventType marks greeks computed locally from the option mid instead of streamed
+const ModelEventType = "Model"
+
+// SetRates sets the risk free rate and dividend yield used for model greeks
+func (c *DxLinkClient) SetRates(rate, div float64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.rate = rate
+	c.div = div
+}
+
+// SetClock replaces the wall clock used to stamp streamed data and to value options
+func (c *DxLinkClient) SetClock(clk clock.Clock) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.clock = clk
+}
+
+func (c *DxLinkClient) now() time.Time {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.clock.Now()
+}
+
+// hasGreeks is false until greeks are streamed, NewOptionData starts out all zero
+func hasGreeks(d *OptionData) bool {
+	g := d.Greek
+	if g.Symbol == "" || g.Delta == nil || math.IsNaN(*g.Delta) {
+		return false
+	}
+	return *g.Delta != 0 || (g.Volatility != nil && *g.Volatility != 0)
+}
+
+// expiration of a PM settled option, 16:00 New York on the expiration date
+func expiresAt(exp time.Time) time.Time {
+	return time.Date(exp.Year(), exp.Month(), exp.Day(), 16, 0, 0, 0, dt.TZNY())
+}
+
+// ModelGreeks prices the option from its mid with Black-Scholes, the implied
+// volatility is solved from the mid and the last underlying trade
+func (c *DxLinkClient) ModelGreeks(d *OptionData, now time.Time) (options.Greeks, float64, error) {
+	sym := d.Quote.Symbol
+	opt, err := options.ParseDxLinkOption(sym)
+	if err != nil {
+		return options.Greeks{}, 0, err
+	}
+	bid, okBid := value(d.Quote.BidPrice)
+	ask, okAsk := value(d.Quote.AskPrice)
+	if !okBid || !okAsk || ask <= 0 || ask < bid {
+		return options.Greeks{}, 0, fmt.Errorf("no usable quote for %s", sym)
+	}
+	underlying := symbology.Underlying(opt.Underlying)
+	spot, ok := c.UnderlyingPrice(underlying)
+	if !ok {
+		return options.Greeks{}, 0, fmt.Errorf("no price for underlying %s of %s", underlying, sym)
+	}
+
+	c.mu.RLock()
+	in := options.PricingInputs{
+		Model:  options.BlackScholes,
+		Type:   opt.OptionType,
+		Spot:   spot,
+		Strike: opt.Strike,
+		Years:  options.Years(expiresAt(opt.Date).Sub(now).Hours() / 24),
+		Rate:   c.rate,
+		Div:    c.div,
+	}
+	c.mu.RUnlock()
+	vol, err := options.ImpliedVol(in, (bid+ask)/2)
+	if err != nil {
+		return options.Greeks{}, 0, fmt.Errorf("implied vol for %s: %w", sym, err)
+	}
+	in.Vol = vol
+	g, err := options.Price(in)
+	return g, vol, err
+}
+
+// withModelGreeks returns a copy of the option data with model greeks in place of
+// the missing streamed greeks, it is unchanged when greeks were streamed
+func (c *DxLinkClient) withModelGreeks(d *OptionData, now time.Time) (*OptionData, bool) {
+	if hasGreeks(d) {
+		return d, true
+	}
+	g, vol, err := c.ModelGreeks(d, now)
+	if err != nil {
+		return d, false
+	}
+	cp := *d
+	cp.Greek = GreeksEvent{
+		EventType:  ModelEventType,
+		Symbol:     d.Quote.Symbol,
+		Price:      &g.Price,
+		Volatility: &vol,
+		Delta:      &g.Delta,
+		Gamma:      &g.Gamma,
+		Theta:      &g.Theta,
+		Rho:        &g.Rho,
+		Vega:       &g.Vega,
+		ReceivedAt: d.Quote.Time(),
+	}
+	return &cp, true
+}
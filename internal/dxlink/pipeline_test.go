@@ -0,0 +1,150 @@
+package dxlink
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-playground/assert/v2"
+)
+
+func discardLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+// quoteFeedData builds a channel 3 FEED_DATA message with one quote per symbol
+func quoteFeedData(syms []string, bid float64) []byte {
+	var b strings.Builder
+	b.WriteString(`{"type":"FEED_DATA","channel":3,"data":["Quote",[`)
+	for i, sym := range syms {
+		if i > 0 {
+			b.WriteString(",")
+		}
+		fmt.Fprintf(&b, `"Quote","%s",1754056800000,0,0,%g,%g,10,12`, sym, bid, bid+0.05)
+	}
+	b.WriteString("]]}")
+	return []byte(b.String())
+}
+
+func optionChain(n int) []string {
+	syms := make([]string, n)
+	for i := range syms {
+		syms[i] = fmt.Sprintf(".SPXW250808%s%d", []string{"C", "P"}[i%2], 5000+5*(i/2))
+	}
+	return syms
+}
+
+func TestPipelineAppliesInOrder(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	c := New(ctx, "", "")
+	c.dxlog = discardLogger()
+	sym := ".XSP250808P600"
+	c.optionSubs.StoreNew(sym, NewOptionData())
+	sub := c.SubscribeQuotes([]string{sym}, SubOptions{Buffer: 100})
+	defer sub.Close()
+
+	for i := 1; i <= 50; i++ {
+		c.enqueue(quoteFeedData([]string{sym}, float64(i)))
+	}
+	assert.Equal(t, len(bids(sub, 50)), 50)
+	data, err := c.GetOptData(context.Background(), sym)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, *data.Quote.BidPrice, 50.0)
+	assert.Equal(t, sub.Dropped(), uint64(0))
+}
+
+func TestPipelineDropsStaleConnection(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	c := New(ctx, "", "")
+	c.dxlog = discardLogger()
+	sym := ".XSP250808P600"
+	c.optionSubs.StoreNew(sym, NewOptionData())
+	sub := c.SubscribeQuotes([]string{sym}, SubOptions{Buffer: 10})
+	defer sub.Close()
+
+	// a message read before the reconnect is still in the inbox
+	stale := c.gen.Load()
+	c.gen.Add(1)
+	c.inbox <- inboundMsg{data: quoteFeedData([]string{sym}, 1), received: time.Now(), gen: stale}
+	c.enqueue(quoteFeedData([]string{sym}, 2))
+
+	assert.Equal(t, bids(sub, 1), []float64{2})
+	data, _ := c.GetOptData(context.Background(), sym)
+	assert.Equal(t, *data.Quote.BidPrice, 2.0)
+}
+
+func TestOptionSnapshotsAreImmutable(t *testing.T) {
+	c := New(context.Background(), "", "")
+	c.dxlog = discardLogger()
+	sym := ".XSP250808P600"
+	c.optionSubs.StoreNew(sym, NewOptionData())
+	c.processMessage(quoteFeedData([]string{sym}, 1.1), time.Now())
+	before, _ := c.GetOptData(context.Background(), sym)
+
+	c.processMessage(quoteFeedData([]string{sym}, 1.5), time.Now())
+	after, _ := c.GetOptData(context.Background(), sym)
+	assert.Equal(t, *before.Quote.BidPrice, 1.1)
+	assert.Equal(t, *after.Quote.BidPrice, 1.5)
+	assert.Equal(t, *after.Quote.BidSize, 10.0)
+}
+
+// a full SPX chain for the traded expirations, quotes arrive in batches of 250
+func BenchmarkApplyChainQuotes(b *testing.B) {
+	c := New(context.Background(), "", "")
+	c.dxlog = discardLogger()
+	chain := optionChain(4000)
+	for _, sym := range chain {
+		c.optionSubs.StoreNew(sym, NewOptionData())
+	}
+	batches := chunkSlice(chain, 250)
+	msgs := make([][]byte, len(batches))
+	for i, batch := range batches {
+		msgs[i] = quoteFeedData(batch, 12.5)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		c.processMessage(msgs[i%len(msgs)], time.Now())
+	}
+	b.ReportMetric(float64(b.N*250)/b.Elapsed().Seconds(), "quotes/s")
+}
+
+// readers polling option data while the chain is updated
+func BenchmarkReadDuringUpdates(b *testing.B) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	c := New(ctx, "", "")
+	c.dxlog = discardLogger()
+	chain := optionChain(4000)
+	for _, sym := range chain {
+		c.optionSubs.StoreNew(sym, NewOptionData())
+		c.processMessage(quoteFeedData([]string{sym}, 1), time.Now())
+	}
+	msg := quoteFeedData(chain[:250], 12.5)
+	var stop atomic.Bool
+	go func() {
+		for !stop.Load() {
+			c.processMessage(msg, time.Now())
+		}
+	}()
+	defer stop.Store(true)
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			if _, ok := c.optionSubs.Load(chain[i%len(chain)]); !ok {
+				b.Fatal("missing option")
+			}
+			i++
+		}
+	})
+}
@@ -0,0 +1,93 @@
+package dxlink
+
+import (
+	"fmt"
+	"time"
+)
+
+// LegQuote is the market for one option when a snapshot was taken
+type LegQuote struct {
+	Symbol     string    `json:"symbol"`
+	Bid        float64   `json:"bid"`
+	Ask        float64   `json:"ask"`
+	Mid        float64   `json:"mid"`
+	Delta      *float64  `json:"delta,omitempty"`
+	Volatility *float64  `json:"volatility,omitempty"`
+	QuoteTime  time.Time `json:"quote-time"`
+	GreeksTime time.Time `json:"greeks-time,omitempty"`
+}
+
+// QuoteSnapshot holds the data of a set of options from the same point in the
+// feed, no feed message is partially applied across its legs
+type QuoteSnapshot struct {
+	TakenAt time.Time `json:"taken-at"`
+	// time between the oldest and newest leg quote
+	Skew time.Duration `json:"skew"`
+	Legs []LegQuote    `json:"legs"`
+	data map[string]*OptionData
+}
+
+func (s *QuoteSnapshot) Leg(symbol string) (LegQuote, bool) {
+	for _, leg := range s.Legs {
+		if leg.Symbol == symbol {
+			return leg, true
+		}
+	}
+	return LegQuote{}, false
+}
+
+// Data is the full option data behind a leg
+func (s *QuoteSnapshot) Data(symbol string) (*OptionData, bool) {
+	d, ok := s.data[symbol]
+	return d, ok
+}
+
+// Snapshot reads the options together between feed messages, every symbol must be
+// subscribed. Legs without streamed greeks carry model greeks when they can be priced
+func (c *DxLinkClient) Snapshot(symbols []string) (*QuoteSnapshot, error) {
+	data := make(map[string]*OptionData, len(symbols))
+	c.applyMu.RLock()
+	for _, sym := range symbols {
+		d, ok := c.optionSubs.Load(sym)
+		if !ok {
+			c.applyMu.RUnlock()
+			return nil, fmt.Errorf("snapshot: %s is not subscribed", sym)
+		}
+		data[sym] = d
+	}
+	c.applyMu.RUnlock()
+
+	snap := &QuoteSnapshot{TakenAt: c.now(), data: data}
+	var oldest, newest time.Time
+	for _, sym := range symbols {
+		if _, ok := snap.Leg(sym); ok {
+			continue
+		}
+		d, _ := c.withModelGreeks(data[sym], snap.TakenAt)
+		data[sym] = d
+		bid, _ := value(d.Quote.BidPrice)
+		ask, _ := value(d.Quote.AskPrice)
+		leg := LegQuote{
+			Symbol:     sym,
+			Bid:        bid,
+			Ask:        ask,
+			Mid:        (bid + ask) / 2,
+			Delta:      d.Greek.Delta,
+			Volatility: d.Greek.Volatility,
+			QuoteTime:  d.Quote.Time(),
+			GreeksTime: d.Greek.Time(),
+		}
+		snap.Legs = append(snap.Legs, leg)
+		if leg.QuoteTime.IsZero() {
+			continue
+		}
+		if oldest.IsZero() || leg.QuoteTime.Before(oldest) {
+			oldest = leg.QuoteTime
+		}
+		if leg.QuoteTime.After(newest) {
+			newest = leg.QuoteTime
+		}
+	}
+	snap.Skew = newest.Sub(oldest)
+	return snap, nil
+}
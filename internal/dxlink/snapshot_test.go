@@ -0,0 +1,40 @@
+package dxlink
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-playground/assert/v2"
+)
+
+func TestSnapshot(t *testing.T) {
+	c := New(context.Background(), "", "")
+	c.dxlog = discardLogger()
+	short, long := ".XSP250808P600", ".XSP250808P595"
+	c.AddOptionSubs([]string{short, long})
+
+	at := time.Date(2025, 8, 8, 14, 0, 0, 0, time.UTC)
+	shortQ, longQ := quote(short, 1.10), quote(long, 0.60)
+	ask1, ask2 := 1.20, 0.70
+	shortQ.AskPrice, longQ.AskPrice = &ask1, &ask2
+	shortQ.EventTime, longQ.EventTime = at, at.Add(-1500*time.Millisecond)
+	c.applyOptionData(ProcessedFeedData{Quotes: []QuoteEvent{shortQ, longQ}}, at)
+
+	snap, err := c.Snapshot([]string{short, long})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(snap.Legs), 2)
+	assert.Equal(t, snap.Skew, 1500*time.Millisecond)
+	leg, ok := snap.Leg(short)
+	assert.Equal(t, ok, true)
+	assert.Equal(t, leg.Mid, 1.15)
+	assert.Equal(t, leg.QuoteTime, at)
+
+	// later ticks don't change a snapshot already taken
+	c.applyOptionData(ProcessedFeedData{Quotes: []QuoteEvent{quote(short, 2)}}, at)
+	data, _ := snap.Data(short)
+	assert.Equal(t, *data.Quote.BidPrice, 1.10)
+
+	_, err = c.Snapshot([]string{short, ".XSP250808P590"})
+	assert.NotEqual(t, err, nil)
+}
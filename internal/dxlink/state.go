@@ -0,0 +1,116 @@
+package dxlink
+
+import (
+	"hash/maphash"
+	"sync"
+)
+
+const stateShards = 64
+
+// shardedMap holds per-symbol state split across shards so readers only contend
+// with writers of the same shard. Values are copy-on-write snapshots, a pointer
+// returned by Load is never modified and is safe to read without a lock.
+type shardedMap[V any] struct {
+	seed   maphash.Seed
+	shards [stateShards]stateShard[V]
+}
+
+type stateShard[V any] struct {
+	mu sync.RWMutex
+	m  map[string]*V
+}
+
+func newShardedMap[V any]() *shardedMap[V] {
+	s := &shardedMap[V]{seed: maphash.MakeSeed()}
+	for i := range s.shards {
+		s.shards[i].m = make(map[string]*V)
+	}
+	return s
+}
+
+func (s *shardedMap[V]) shard(key string) *stateShard[V] {
+	return &s.shards[maphash.String(s.seed, key)%stateShards]
+}
+
+func (s *shardedMap[V]) Load(key string) (*V, bool) {
+	sh := s.shard(key)
+	sh.mu.RLock()
+	defer sh.mu.RUnlock()
+	v, ok := sh.m[key]
+	return v, ok && v != nil
+}
+
+// StoreNew sets the value for key if it is not already present, true when it was added
+func (s *shardedMap[V]) StoreNew(key string, v *V) bool {
+	sh := s.shard(key)
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	if _, ok := sh.m[key]; ok {
+		return false
+	}
+	sh.m[key] = v
+	return true
+}
+
+// Update replaces the value for key with a modified copy. Missing keys are
+// created from init, or skipped when init is nil.
+func (s *shardedMap[V]) Update(key string, init func() *V, fn func(*V)) bool {
+	sh := s.shard(key)
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	var next V
+	if cur, ok := sh.m[key]; ok && cur != nil {
+		next = *cur
+	} else if init != nil {
+		next = *init()
+	} else {
+		return false
+	}
+	fn(&next)
+	sh.m[key] = &next
+	return true
+}
+
+func (s *shardedMap[V]) Delete(key string) bool {
+	sh := s.shard(key)
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	if _, ok := sh.m[key]; !ok {
+		return false
+	}
+	delete(sh.m, key)
+	return true
+}
+
+func (s *shardedMap[V]) Keys() []string {
+	var keys []string
+	for i := range s.shards {
+		sh := &s.shards[i]
+		sh.mu.RLock()
+		for k := range sh.m {
+			keys = append(keys, k)
+		}
+		sh.mu.RUnlock()
+	}
+	return keys
+}
+
+func (s *shardedMap[V]) Len() int {
+	n := 0
+	for i := range s.shards {
+		sh := &s.shards[i]
+		sh.mu.RLock()
+		n += len(sh.m)
+		sh.mu.RUnlock()
+	}
+	return n
+}
+
+func (s *shardedMap[V]) Clear() {
+	for i := range s.shards {
+		sh := &s.shards[i]
+		sh.mu.Lock()
+		clear(sh.m)
+		sh.mu.Unlock()
+	}
+}
@@ -0,0 +1,235 @@
+package dxlink
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// Policy decides what happens to an event when a subscriber has fallen behind,
+// the feed never blocks on a subscriber
+type Policy int
+
+const (
+	// DropNewest discards the new event when the buffer is full
+	DropNewest Policy = iota
+	// DropOldest discards the oldest buffered event to make room
+	DropOldest
+	// Coalesce keeps only the latest pending event for each symbol
+	Coalesce
+)
+
+func (p Policy) String() string {
+	switch p {
+	case DropNewest:
+		return "drop-newest"
+	case DropOldest:
+		return "drop-oldest"
+	case Coalesce:
+		return "coalesce"
+	}
+	return "unknown"
+}
+
+const defaultSubBuffer = 64
+
+type SubOptions struct {
+	// channel capacity, defaults to 64
+	Buffer int
+	Policy Policy
+}
+
+// Subscription delivers the events for a set of symbols, all symbols when the set is empty.
+// Listening does not change the feed subscriptions, the symbols must also be
+// subscribed with AddOptionSubs or AddUnderlyingSub.
+type Subscription[T any] struct {
+	symbols map[string]bool
+	policy  Policy
+	out     chan T
+	dropped atomic.Uint64
+
+	mu     sync.Mutex
+	closed bool
+	// coalesced events waiting to be sent, in arrival order of their symbol
+	pending map[string]T
+	order   []string
+	notify  chan struct{}
+	done    chan struct{}
+	remove  func()
+}
+
+func newSubscription[T any](symbols []string, opts SubOptions) *Subscription[T] {
+	if opts.Buffer <= 0 {
+		opts.Buffer = defaultSubBuffer
+	}
+	s := &Subscription[T]{
+		symbols: make(map[string]bool, len(symbols)),
+		policy:  opts.Policy,
+		out:     make(chan T, opts.Buffer),
+		done:    make(chan struct{}),
+	}
+	for _, sym := range symbols {
+		s.symbols[sym] = true
+	}
+	if s.policy == Coalesce {
+		s.pending = make(map[string]T)
+		s.notify = make(chan struct{}, 1)
+		go s.flush()
+	}
+	return s
+}
+
+// C receives the events, it is closed by Close
+func (s *Subscription[T]) C() <-chan T {
+	return s.out
+}
+
+// Dropped counts the events discarded by the policy, coalesced events included
+func (s *Subscription[T]) Dropped() uint64 {
+	return s.dropped.Load()
+}
+
+// OnEvent calls fn for each event on its own goroutine until the subscription is closed
+func (s *Subscription[T]) OnEvent(fn func(T)) {
+	go func() {
+		for evt := range s.out {
+			fn(evt)
+		}
+	}()
+}
+
+func (s *Subscription[T]) Close() {
+	// removed before taking the lock, publish holds the topic lock while delivering
+	if s.remove != nil {
+		s.remove()
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return
+	}
+	s.closed = true
+	close(s.done)
+	// the flush goroutine owns the channel when coalescing
+	if s.policy != Coalesce {
+		close(s.out)
+	}
+}
+
+func (s *Subscription[T]) wants(symbol string) bool {
+	return len(s.symbols) == 0 || s.symbols[symbol]
+}
+
+func (s *Subscription[T]) deliver(symbol string, evt T) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return
+	}
+
+	switch s.policy {
+	case Coalesce:
+		if _, ok := s.pending[symbol]; ok {
+			s.dropped.Add(1)
+		} else {
+			s.order = append(s.order, symbol)
+		}
+		s.pending[symbol] = evt
+		select {
+		case s.notify <- struct{}{}:
+		default:
+		}
+	case DropOldest:
+		for {
+			select {
+			case s.out <- evt:
+				return
+			default:
+			}
+			select {
+			case <-s.out:
+				s.dropped.Add(1)
+			default:
+			}
+		}
+	default:
+		select {
+		case s.out <- evt:
+		default:
+			s.dropped.Add(1)
+		}
+	}
+}
+
+// flush sends the coalesced events, a slow reader only delays this goroutine
+func (s *Subscription[T]) flush() {
+	defer close(s.out)
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-s.notify:
+		}
+
+		s.mu.Lock()
+		order := s.order
+		pending := s.pending
+		s.order = nil
+		s.pending = make(map[string]T, len(pending))
+		s.mu.Unlock()
+
+		for _, sym := range order {
+			select {
+			case s.out <- pending[sym]:
+			case <-s.done:
+				return
+			}
+		}
+	}
+}
+
+// topic fans events of one type out to its subscriptions
+type topic[T any] struct {
+	mu   sync.RWMutex
+	subs map[*Subscription[T]]struct{}
+}
+
+func (t *topic[T]) subscribe(symbols []string, opts SubOptions) *Subscription[T] {
+	s := newSubscription[T](symbols, opts)
+	s.remove = func() {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		delete(t.subs, s)
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.subs == nil {
+		t.subs = make(map[*Subscription[T]]struct{})
+	}
+	t.subs[s] = struct{}{}
+	return s
+}
+
+func (t *topic[T]) publish(symbol string, evt T) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	for s := range t.subs {
+		if s.wants(symbol) {
+			s.deliver(symbol, evt)
+		}
+	}
+}
+
+// SubscribeQuotes listens to quotes for options and underlyings
+func (c *DxLinkClient) SubscribeQuotes(symbols []string, opts SubOptions) *Subscription[QuoteEvent] {
+	return c.quoteTopic.subscribe(symbols, opts)
+}
+
+// SubscribeGreeks listens to option greeks
+func (c *DxLinkClient) SubscribeGreeks(symbols []string, opts SubOptions) *Subscription[GreeksEvent] {
+	return c.greeksTopic.subscribe(symbols, opts)
+}
+
+// SubscribeTrades listens to trades for options and underlyings
+func (c *DxLinkClient) SubscribeTrades(symbols []string, opts SubOptions) *Subscription[TradeEvent] {
+	return c.tradeTopic.subscribe(symbols, opts)
+}
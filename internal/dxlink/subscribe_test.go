@@ -0,0 +1,106 @@
+package dxlink
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-playground/assert/v2"
+)
+
+func quote(sym string, bid float64) QuoteEvent {
+	return QuoteEvent{EventType: "Quote", Symbol: sym, BidPrice: &bid}
+}
+
+func bids(sub *Subscription[QuoteEvent], n int) []float64 {
+	var got []float64
+	for range n {
+		select {
+		case q := <-sub.C():
+			got = append(got, *q.BidPrice)
+		case <-time.After(time.Second):
+			return got
+		}
+	}
+	return got
+}
+
+// waitFor polls cond until it holds, failing the test after a second
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestSubscriptionPolicies(t *testing.T) {
+	var quotes topic[QuoteEvent]
+
+	newest := quotes.subscribe([]string{"A"}, SubOptions{Buffer: 2, Policy: DropNewest})
+	oldest := quotes.subscribe([]string{"A"}, SubOptions{Buffer: 2, Policy: DropOldest})
+	for i := range 4 {
+		quotes.publish("A", quote("A", float64(i)))
+		// filtered out by symbol
+		quotes.publish("B", quote("B", 99))
+	}
+	assert.Equal(t, bids(newest, 2), []float64{0, 1})
+	assert.Equal(t, newest.Dropped(), uint64(2))
+	assert.Equal(t, bids(oldest, 2), []float64{2, 3})
+	assert.Equal(t, oldest.Dropped(), uint64(2))
+
+	newest.Close()
+	oldest.Close()
+	_, open := <-newest.C()
+	assert.Equal(t, open, false)
+	assert.Equal(t, len(quotes.subs), 0)
+}
+
+func TestSubscriptionCoalesce(t *testing.T) {
+	var quotes topic[QuoteEvent]
+	sub := quotes.subscribe(nil, SubOptions{Buffer: 1, Policy: Coalesce})
+	// fill the channel, then block the flush goroutine sending B
+	quotes.publish("A", quote("A", 1))
+	waitFor(t, func() bool { return len(sub.C()) == 1 })
+	quotes.publish("B", quote("B", 1))
+	waitFor(t, func() bool {
+		sub.mu.Lock()
+		defer sub.mu.Unlock()
+		return len(sub.order) == 0
+	})
+	for i := 2; i <= 5; i++ {
+		quotes.publish("A", quote("A", float64(i)))
+		quotes.publish("C", quote("C", float64(i)))
+	}
+
+	assert.Equal(t, bids(sub, 4), []float64{1, 1, 5, 5})
+	sub.Close()
+	_, open := <-sub.C()
+	assert.Equal(t, open, false)
+}
+
+func TestClientPublishesSubscribedOptions(t *testing.T) {
+	c := New(context.Background(), "", "")
+	c.optionSubs.StoreNew(".XSP250808P600", NewOptionData())
+	sub := c.SubscribeQuotes([]string{".XSP250808P600", ".XSP250808P605"}, SubOptions{})
+	defer sub.Close()
+
+	var called = make(chan float64, 1)
+	greeks := c.SubscribeGreeks(nil, SubOptions{})
+	greeks.OnEvent(func(g GreeksEvent) { called <- *g.Delta })
+	defer greeks.Close()
+
+	delta := -0.3
+	c.applyOptionData(ProcessedFeedData{
+		Quotes: []QuoteEvent{quote(".XSP250808P600", 1.1), quote(".XSP250808P605", 1.5)},
+		Greeks: []GreeksEvent{{Symbol: ".XSP250808P600", Delta: &delta}},
+	}, time.Now())
+
+	// quotes for symbols no longer in the feed subscriptions are dropped
+	assert.Equal(t, bids(sub, 1), []float64{1.1})
+	assert.Equal(t, len(sub.C()), 0)
+	assert.Equal(t, <-called, -0.3)
+}
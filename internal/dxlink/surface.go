@@ -0,0 +1,52 @@
+package dxlink
+
+import (
+	"fmt"
+
+	"github.com/jamesonhm/gochain/internal/options"
+	"github.com/jamesonhm/gochain/internal/symbology"
+)
+
+// Surface fits the volatility surface of an option root from the streamed IVs of its
+// out of the money options, puts below the underlying price and calls above it.
+// Expirations with too few quotes are left out
+func (c *DxLinkClient) Surface(root string) (*options.Surface, error) {
+	underlying := symbology.Underlying(root)
+	spot, ok := c.UnderlyingPrice(underlying)
+	if !ok {
+		return nil, fmt.Errorf("surface: no price for underlying %s", underlying)
+	}
+	c.mu.RLock()
+	surface := options.NewSurface(spot, c.rate, c.div)
+	c.mu.RUnlock()
+
+	now := c.now()
+	for _, exp := range c.chain.Expirations(root) {
+		years := options.Years(expiresAt(exp).Sub(now).Hours() / 24)
+		if years <= 0 {
+			continue
+		}
+		var points []options.SmilePoint
+		for _, optType := range []options.OptionType{options.PutOption, options.CallOption} {
+			for _, e := range c.chain.entries(root, exp, optType) {
+				if (optType == options.PutOption) != (e.Strike < spot) {
+					continue
+				}
+				d, ok := c.optionSubs.Load(e.Symbol)
+				if !ok || !hasGreeks(d) {
+					continue
+				}
+				if iv, ok := value(d.Greek.Volatility); ok {
+					points = append(points, options.SmilePoint{Strike: e.Strike, IV: iv})
+				}
+			}
+		}
+		if err := surface.AddSmile(exp, years, points); err != nil {
+			c.dxlog.Debug("skipping smile", "root", root, "expiration", expKey(exp), "error", err)
+		}
+	}
+	if len(surface.Smiles()) == 0 {
+		return nil, fmt.Errorf("surface: no expirations of %s with streamed IVs", root)
+	}
+	return surface, nil
+}
@@ -0,0 +1,50 @@
+package dxlink
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/go-playground/assert/v2"
+	"github.com/jamesonhm/gochain/internal/options"
+)
+
+func TestSurfaceFromStreamedIVs(t *testing.T) {
+	c := New(context.Background(), "", "")
+	c.dxlog = discardLogger()
+	spot := 6000.0
+	c.underlyingSubs.StoreNew("SPX", &UnderlyingData{Trade: TradeEvent{Price: &spot}})
+
+	ivs := make(map[string]float64)
+	var syms []string
+	for _, days := range []int{10, 40} {
+		exp := time.Now().AddDate(0, 0, days)
+		for strike := 5700.0; strike <= 6300; strike += 50 {
+			for _, optType := range []options.OptionType{options.PutOption, options.CallOption} {
+				sym := options.OptionSymbol{Underlying: "SPXW", Date: exp, Strike: strike, OptionType: optType}.DxLinkString()
+				syms = append(syms, sym)
+				// puts richer than calls and the front month richer than the back
+				ivs[sym] = 0.2 - float64(days)/1000 - 0.5*math.Log(strike/spot)
+			}
+		}
+	}
+	c.AddOptionSubs(syms)
+	for sym, iv := range ivs {
+		c.optionSubs.Update(sym, nil, func(d *OptionData) {
+			d.Greek = GreeksEvent{Symbol: sym, Volatility: &iv, Delta: new(float64)}
+		})
+	}
+
+	surface, err := c.Surface("SPXW")
+	assert.Equal(t, err, nil)
+	term := surface.TermStructure()
+	assert.Equal(t, len(term), 2)
+	assert.Equal(t, term[0].IV > term[1].IV, true)
+	skew, err := surface.Smiles()[0].Skew25()
+	assert.Equal(t, err, nil)
+	assert.Equal(t, skew > 0, true)
+
+	_, err = c.Surface("XSP")
+	assert.NotEqual(t, err, nil)
+}
@@ -0,0 +1,259 @@
+package dxlink
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"maps"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/jamesonhm/gochain/internal/clock"
+	"github.com/jamesonhm/gochain/internal/dt"
+	"github.com/jamesonhm/gochain/internal/options"
+	"github.com/jamesonhm/gochain/internal/symbology"
+)
+
+// ChainFunc returns the streamer symbols of every listed option on the underlying
+type ChainFunc func(ctx context.Context, underlying string) ([]string, error)
+
+// Window is the set of options kept subscribed for an underlying
+type Window struct {
+	Underlying string
+	// strikes within PctRange percent of the price are subscribed
+	PctRange float64
+	// the window is re-centered once the price moves RecenterPct percent from its center
+	RecenterPct float64
+	DTEs        []int
+}
+
+type windowState struct {
+	Window
+	center  float64
+	session string
+	chain   []string
+	subs    map[string]bool
+}
+
+type subscriber interface {
+	AddOptionSubs([]string) error
+	RemoveOptionSubs([]string) error
+	AddUnderlyingSub(string) error
+	UnderlyingPrice(string) (float64, bool)
+}
+
+// WindowManager keeps each underlying's option subscriptions centered on the streamed
+// price and rolls the expirations when the session date changes
+type WindowManager struct {
+	client subscriber
+	chains ChainFunc
+	// symbols that must stay subscribed, such as the legs of open positions
+	keep func() []string
+	// market holidays, expirations falling on one roll to the next trading day
+	holidays func() []time.Time
+	clock    clock.Clock
+	// serializes the refreshes and Suspend, which fetch chains and send subscriptions
+	// outside mu
+	refreshMu sync.Mutex
+	mu        sync.Mutex
+	windows   map[string]*windowState
+	// no refreshes between Suspend and Resume
+	suspended bool
+}
+
+func NewWindowManager(client subscriber, chains ChainFunc, keep func() []string, holidays func() []time.Time, clk clock.Clock) *WindowManager {
+	return &WindowManager{
+		client:   client,
+		chains:   chains,
+		keep:     keep,
+		holidays: holidays,
+		clock:    clk,
+		windows:  make(map[string]*windowState),
+	}
+}
+
+// Add starts tracking the window. With a zero price the options are subscribed once
+// the first trade for the underlying is streamed
+func (m *WindowManager) Add(ctx context.Context, w Window, price float64, now time.Time) error {
+	if err := m.client.AddUnderlyingSub(w.Underlying); err != nil {
+		return err
+	}
+	m.refreshMu.Lock()
+	defer m.refreshMu.Unlock()
+	ws := &windowState{Window: w, subs: make(map[string]bool)}
+	m.mu.Lock()
+	m.windows[w.Underlying] = ws
+	m.mu.Unlock()
+	if price > 0 {
+		return m.refresh(ctx, ws, price, now)
+	}
+	return nil
+}
+
+// Run refreshes the windows every interval of wall time, at the time of the manager's clock
+func (m *WindowManager) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			m.Refresh(ctx, m.clock.Now())
+		}
+	}
+}
+
+func (m *WindowManager) Refresh(ctx context.Context, now time.Time) {
+	m.refreshMu.Lock()
+	defer m.refreshMu.Unlock()
+	m.mu.Lock()
+	if m.suspended {
+		m.mu.Unlock()
+		return
+	}
+	windows := make([]*windowState, 0, len(m.windows))
+	for _, ws := range m.windows {
+		windows = append(windows, ws)
+	}
+	m.mu.Unlock()
+
+	for _, ws := range windows {
+		price, ok := m.client.UnderlyingPrice(ws.Underlying)
+		if !ok {
+			center, _, _ := m.state(ws)
+			if center == 0 {
+				slog.Debug("(WindowManager) waiting for underlying price", "underlying", ws.Underlying)
+				continue
+			}
+			price = center
+		}
+		if err := m.refresh(ctx, ws, price, now); err != nil {
+			slog.Error("(WindowManager) unable to refresh option window", "underlying", ws.Underlying, "error", err)
+		}
+	}
+}
+
+// state is a copy of the window's center, session and subscribed symbols
+func (m *WindowManager) state(ws *windowState) (float64, string, map[string]bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return ws.center, ws.session, maps.Clone(ws.subs)
+}
+
+// Suspend unsubscribes the options of every window, except the kept symbols, and stops
+// refreshing them until Resume
+func (m *WindowManager) Suspend() error {
+	m.refreshMu.Lock()
+	defer m.refreshMu.Unlock()
+	kept := make(map[string]bool)
+	if m.keep != nil {
+		for _, sym := range m.keep() {
+			kept[sym] = true
+		}
+	}
+	var remove []string
+	m.mu.Lock()
+	m.suspended = true
+	for _, ws := range m.windows {
+		for sym := range ws.subs {
+			if !kept[sym] {
+				remove = append(remove, sym)
+			}
+		}
+		// the next refresh fetches the chain and subscribes the window again
+		ws.subs = make(map[string]bool)
+		ws.session = ""
+	}
+	m.mu.Unlock()
+	if err := m.client.RemoveOptionSubs(remove); err != nil {
+		return err
+	}
+	slog.Info("(WindowManager) option windows suspended", "removed", len(remove))
+	return nil
+}
+
+// Resume refreshes every window for the session of now, fetching the chains and
+// subscribing the options of the current expirations
+func (m *WindowManager) Resume(ctx context.Context, now time.Time) {
+	m.mu.Lock()
+	m.suspended = false
+	m.mu.Unlock()
+	m.Refresh(ctx, now)
+}
+
+// refresh re-centers or rolls the window, callers hold refreshMu. The chain fetch and
+// subscription changes run outside mu, the new state is stored once they succeed
+func (m *WindowManager) refresh(ctx context.Context, ws *windowState, price float64, now time.Time) error {
+	center, prevSession, subs := m.state(ws)
+	session := now.In(dt.TZNY()).Format(time.DateOnly)
+	rolled := session != prevSession
+	moved := center == 0 || math.Abs(price-center)/center*100 >= ws.RecenterPct
+	if !rolled && !moved {
+		return nil
+	}
+
+	// only refresh writes the chain and it runs under refreshMu
+	chain := ws.chain
+	if rolled || chain == nil {
+		var err error
+		chain, err = m.chains(ctx, ws.Underlying)
+		if err != nil {
+			return fmt.Errorf("unable to get option chain: %w", err)
+		}
+		ws.chain = chain
+	}
+	var holidays []time.Time
+	if m.holidays != nil {
+		holidays = m.holidays()
+	}
+	var dates []time.Time
+	for _, dte := range ws.DTEs {
+		dates = append(dates, dt.DTEToDateHolidays(now, dte, holidays))
+	}
+
+	want := make(map[string]bool)
+	for _, sym := range FilterOptionsDates(dates)(chain, price, ws.PctRange) {
+		want[sym] = true
+	}
+	if m.keep != nil {
+		// legs of weekly roots such as SPXW are kept by the window of their underlying
+		for _, sym := range m.keep() {
+			if opt, err := options.ParseDxLinkOption(sym); err == nil && symbology.Underlying(opt.Underlying) == ws.Underlying {
+				want[sym] = true
+			}
+		}
+	}
+	if len(want) == 0 {
+		return fmt.Errorf("no options within %.1f%% of %.2f for %v", ws.PctRange, price, dates)
+	}
+
+	var add, remove []string
+	for sym := range want {
+		if !subs[sym] {
+			add = append(add, sym)
+		}
+	}
+	for sym := range subs {
+		if !want[sym] {
+			remove = append(remove, sym)
+		}
+	}
+	if err := m.client.AddOptionSubs(add); err != nil {
+		return err
+	}
+	if err := m.client.RemoveOptionSubs(remove); err != nil {
+		return err
+	}
+
+	slog.Info("(WindowManager) option window updated",
+		"underlying", ws.Underlying, "price", price, "previous center", center,
+		"rolled", rolled, "added", len(add), "removed", len(remove), "subscribed", len(want))
+	m.mu.Lock()
+	ws.subs = want
+	ws.center = price
+	ws.session = session
+	m.mu.Unlock()
+	return nil
+}
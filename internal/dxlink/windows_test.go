@@ -0,0 +1,103 @@
+package dxlink
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/go-playground/assert/v2"
+	"github.com/jamesonhm/gochain/internal/clock"
+	"github.com/jamesonhm/gochain/internal/dt"
+)
+
+type fakeSubscriber struct {
+	price float64
+	subs  map[string]bool
+}
+
+func (f *fakeSubscriber) AddOptionSubs(syms []string) error {
+	for _, s := range syms {
+		f.subs[s] = true
+	}
+	return nil
+}
+
+func (f *fakeSubscriber) RemoveOptionSubs(syms []string) error {
+	for _, s := range syms {
+		delete(f.subs, s)
+	}
+	return nil
+}
+
+func (f *fakeSubscriber) AddUnderlyingSub(string) error { return nil }
+
+func (f *fakeSubscriber) UnderlyingPrice(string) (float64, bool) {
+	return f.price, f.price > 0
+}
+
+func (f *fakeSubscriber) sorted() []string {
+	var syms []string
+	for s := range f.subs {
+		syms = append(syms, s)
+	}
+	slices.Sort(syms)
+	return syms
+}
+
+func testChain(ctx context.Context, underlying string) ([]string, error) {
+	var chain []string
+	for _, exp := range []string{"250804", "250805", "250807"} {
+		for strike := 590; strike <= 620; strike += 5 {
+			chain = append(chain, fmt.Sprintf(".%s%sP%d", underlying, exp, strike))
+		}
+	}
+	return chain, nil
+}
+
+func TestWindowManager(t *testing.T) {
+	// Monday 2025-08-04
+	now := time.Date(2025, 8, 4, 10, 0, 0, 0, dt.TZNY())
+	sub := &fakeSubscriber{subs: make(map[string]bool)}
+	held := []string{".XSP250804P590"}
+	// a made up holiday to check expirations roll past it
+	holidays := []time.Time{time.Date(2025, 8, 6, 0, 0, 0, 0, dt.TZNY())}
+	m := NewWindowManager(sub, testChain, func() []string { return held }, func() []time.Time { return holidays }, clock.NewFixed(now))
+
+	w := Window{Underlying: "XSP", PctRange: 1, RecenterPct: 0.5, DTEs: []int{0}}
+	err := m.Add(context.Background(), w, 0, now)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(sub.subs), 0)
+
+	// first streamed price centers the window
+	sub.price = 600
+	m.Refresh(context.Background(), now)
+	assert.Equal(t, sub.sorted(), []string{".XSP250804P590", ".XSP250804P595", ".XSP250804P600", ".XSP250804P605"})
+
+	// small moves keep the window
+	sub.price = 601
+	m.Refresh(context.Background(), now.Add(time.Minute))
+	assert.Equal(t, len(sub.subs), 4)
+
+	// re-centered, the held leg stays subscribed
+	sub.price = 612
+	m.Refresh(context.Background(), now.Add(2*time.Minute))
+	assert.Equal(t, sub.sorted(), []string{".XSP250804P590", ".XSP250804P610", ".XSP250804P615"})
+
+	// next session rolls the expiration
+	held = nil
+	m.Refresh(context.Background(), now.AddDate(0, 0, 1))
+	assert.Equal(t, sub.sorted(), []string{".XSP250805P610", ".XSP250805P615"})
+
+	// after the close only the held leg stays subscribed
+	held = []string{".XSP250805P615"}
+	assert.Equal(t, m.Suspend(), nil)
+	assert.Equal(t, sub.sorted(), held)
+	m.Refresh(context.Background(), now.AddDate(0, 0, 1))
+	assert.Equal(t, sub.sorted(), held)
+
+	// resumed on the holiday, the 0 DTE expiration is the next trading day
+	m.Resume(context.Background(), now.AddDate(0, 0, 2))
+	assert.Equal(t, sub.sorted(), []string{".XSP250805P615", ".XSP250807P610", ".XSP250807P615"})
+}
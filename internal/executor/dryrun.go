@@ -0,0 +1,154 @@
+package executor
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+
+	"github.com/jamesonhm/gochain/internal/options"
+	"github.com/jamesonhm/gochain/internal/strategy"
+	"github.com/jamesonhm/gochain/internal/tasty"
+	"github.com/shopspring/decimal"
+)
+
+var ErrDryRunCheck = errors.New("dry run check")
+
+// option contract multiplier
+var multiplier = decimal.NewFromInt(100)
+
+// orderUnits is the number of spreads in the order, see options.Units
+func orderUnits(order tasty.NewOrder) int {
+	var quantities []float64
+	for _, leg := range order.Legs {
+		quantities = append(quantities, leg.Quantity)
+	}
+	return options.Units(quantities...)
+}
+
+// resizeOrder scales the leg quantities of the order to the given number of units
+func resizeOrder(order *tasty.NewOrder, units int) {
+	current := orderUnits(*order)
+	if current == 0 {
+		return
+	}
+	for i, leg := range order.Legs {
+		order.Legs[i].Quantity = leg.Quantity / float64(current) * float64(units)
+	}
+}
+
+// spreadWidth is the widest strike distance between legs of the same option type
+func spreadWidth(order tasty.NewOrder) (decimal.Decimal, error) {
+	strikes := make(map[options.OptionType][]float64)
+	for _, leg := range order.Legs {
+		sym, err := options.ParseOCCOption(leg.Symbol)
+		if err != nil {
+			return decimal.Zero, err
+		}
+		strikes[sym.OptionType] = append(strikes[sym.OptionType], sym.Strike)
+	}
+	var width float64
+	for _, ls := range strikes {
+		width = math.Max(width, slices.Max(ls)-slices.Min(ls))
+	}
+	return decimal.NewFromFloat(width), nil
+}
+
+// orderPosition models one unit of the order at its net price
+func orderPosition(order tasty.NewOrder, price decimal.Decimal) (options.Position, error) {
+	units := float64(orderUnits(order))
+	pos := options.Position{Premium: price.InexactFloat64()}
+	for _, leg := range order.Legs {
+		sym, err := options.ParseOCCOption(leg.Symbol)
+		if err != nil {
+			return options.Position{}, err
+		}
+		qty := leg.Quantity / units
+		if leg.Action == tasty.STO || leg.Action == tasty.STC {
+			qty = -qty
+		}
+		pos.Legs = append(pos.Legs, options.Leg{Type: sym.OptionType, Strike: sym.Strike, Quantity: qty})
+	}
+	return pos, nil
+}
+
+// signed amount, credits positive and debits negative
+func signed(amt decimal.Decimal, effect tasty.PriceEffect) decimal.Decimal {
+	if effect == tasty.Debit {
+		return amt.Abs().Neg()
+	}
+	return amt.Abs()
+}
+
+// evaluateDryRun checks the dry run response against the strategy thresholds and
+// returns the number of units the order may be submitted with. A result lower
+// than the order units means the order must be resized before going live
+func evaluateDryRun(c strategy.DryRunChecks, order tasty.NewOrder, resp tasty.OrderResponse) (int, error) {
+	units := orderUnits(order)
+	if units <= 0 {
+		return 0, fmt.Errorf("%w: order has no quantity", ErrDryRunCheck)
+	}
+	unitsD := decimal.NewFromInt(int64(units))
+
+	price, err := decimal.NewFromString(order.Price)
+	if err != nil {
+		return 0, fmt.Errorf("%w: unable to parse order price %s: %w", ErrDryRunCheck, order.Price, err)
+	}
+	price = signed(price, order.PriceEffect)
+	fees := signed(resp.FeeCalculation.TotalFees, resp.FeeCalculation.TotalFeesEffect)
+
+	if c.MinNetCredit.IsPositive() {
+		netPerUnit := price.Mul(multiplier).Add(fees.Div(unitsD))
+		if netPerUnit.LessThan(c.MinNetCredit) {
+			return 0, fmt.Errorf("%w: net credit per contract %s after fees is below %s", ErrDryRunCheck, netPerUnit.StringFixed(2), c.MinNetCredit)
+		}
+	}
+
+	if c.MinCreditWidthRatio.IsPositive() {
+		width, err := spreadWidth(order)
+		if err != nil {
+			return 0, fmt.Errorf("%w: %w", ErrDryRunCheck, err)
+		}
+		if width.IsZero() {
+			return 0, fmt.Errorf("%w: credit/width ratio requires legs with different strikes", ErrDryRunCheck)
+		}
+		ratio := price.Div(width)
+		if ratio.LessThan(c.MinCreditWidthRatio) {
+			return 0, fmt.Errorf("%w: credit/width ratio %s is below %s", ErrDryRunCheck, ratio.StringFixed(3), c.MinCreditWidthRatio)
+		}
+	}
+
+	if c.RequireDefinedRisk || c.MaxLossPerContract.IsPositive() {
+		pos, err := orderPosition(order, price)
+		if err != nil {
+			return 0, fmt.Errorf("%w: %w", ErrDryRunCheck, err)
+		}
+		if !pos.DefinedRisk() {
+			return 0, fmt.Errorf("%w: order has undefined risk", ErrDryRunCheck)
+		}
+		if c.MaxLossPerContract.IsPositive() {
+			loss := decimal.NewFromFloat(-pos.MaxLoss()).Sub(fees.Div(unitsD))
+			if loss.GreaterThan(c.MaxLossPerContract) {
+				return 0, fmt.Errorf("%w: max loss per contract %s after fees is above %s", ErrDryRunCheck, loss.StringFixed(2), c.MaxLossPerContract)
+			}
+		}
+	}
+
+	bp := resp.BuyingPowerEffect.ChangeInBuyingPower.Abs()
+	if resp.BuyingPowerEffect.ChangeInBuyingPowerEffect != tasty.Debit {
+		// order frees buying power
+		bp = decimal.Zero
+	}
+	bpPerUnit := bp.Div(unitsD)
+	if c.MaxBuyingPowerPerContract.IsPositive() && bpPerUnit.GreaterThan(c.MaxBuyingPowerPerContract) {
+		return 0, fmt.Errorf("%w: buying power per contract %s is above %s", ErrDryRunCheck, bpPerUnit.StringFixed(2), c.MaxBuyingPowerPerContract)
+	}
+	if c.MaxBuyingPower.IsPositive() && bp.GreaterThan(c.MaxBuyingPower) {
+		fit := int(c.MaxBuyingPower.Div(bpPerUnit).Floor().IntPart())
+		if fit < 1 {
+			return 0, fmt.Errorf("%w: buying power per contract %s is above the max for the order %s", ErrDryRunCheck, bpPerUnit.StringFixed(2), c.MaxBuyingPower)
+		}
+		return fit, nil
+	}
+	return units, nil
+}
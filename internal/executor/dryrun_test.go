@@ -0,0 +1,100 @@
+package executor
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/go-playground/assert/v2"
+	"github.com/jamesonhm/gochain/internal/strategy"
+	"github.com/jamesonhm/gochain/internal/tasty"
+	"github.com/shopspring/decimal"
+)
+
+func putSpread(qty float64, price string) tasty.NewOrder {
+	return tasty.NewOrder{
+		Price:       price,
+		PriceEffect: tasty.Credit,
+		Legs: []tasty.NewOrderLeg{
+			{Symbol: "XSP   250808P00600000", Quantity: qty, Action: tasty.STO},
+			{Symbol: "XSP   250808P00595000", Quantity: qty, Action: tasty.BTO},
+		},
+	}
+}
+
+func dryRunResp(fees string, bp string) tasty.OrderResponse {
+	return tasty.OrderResponse{
+		FeeCalculation: tasty.FeeCalculation{
+			TotalFees:       decimal.RequireFromString(fees),
+			TotalFeesEffect: tasty.Debit,
+		},
+		BuyingPowerEffect: tasty.BuyingPowerEffect{
+			ChangeInBuyingPower:       decimal.RequireFromString(bp),
+			ChangeInBuyingPowerEffect: tasty.Debit,
+		},
+	}
+}
+
+func TestEvaluateDryRun(t *testing.T) {
+	checks := strategy.DryRunChecks{
+		MinNetCredit:              decimal.RequireFromString("50"),
+		MinCreditWidthRatio:       decimal.RequireFromString("0.1"),
+		MaxBuyingPowerPerContract: decimal.RequireFromString("500"),
+		MaxBuyingPower:            decimal.RequireFromString("1000"),
+	}
+
+	// 0.60 credit on a 5 wide spread, 2 contracts, $2.60 fees, 880 bp
+	units, err := evaluateDryRun(checks, putSpread(2, "0.60"), dryRunResp("2.60", "880"))
+	assert.Equal(t, err, nil)
+	assert.Equal(t, units, 2)
+
+	// net credit after fees 0.52*100 - 1.30 = 50.70 per contract passes, 0.51 does not
+	_, err = evaluateDryRun(checks, putSpread(2, "0.51"), dryRunResp("2.60", "880"))
+	assert.Equal(t, errors.Is(err, ErrDryRunCheck), true)
+
+	// credit/width 0.45/5 below 0.1
+	checks.MinNetCredit = decimal.Zero
+	_, err = evaluateDryRun(checks, putSpread(1, "0.45"), dryRunResp("1.30", "455"))
+	assert.Equal(t, errors.Is(err, ErrDryRunCheck), true)
+
+	// 4 contracts at 440 bp each only fit 2 into 1000
+	units, err = evaluateDryRun(checks, putSpread(4, "0.60"), dryRunResp("5.20", "1760"))
+	assert.Equal(t, err, nil)
+	assert.Equal(t, units, 2)
+
+	order := putSpread(4, "0.60")
+	resizeOrder(&order, units)
+	assert.Equal(t, order.Legs[0].Quantity, 2.0)
+	assert.Equal(t, order.Legs[1].Quantity, 2.0)
+
+	// a 1x2 ratio spread of 2 and 4 contracts is 2 units
+	order.Legs[1].Quantity = 4
+	assert.Equal(t, orderUnits(order), 2)
+	resizeOrder(&order, 1)
+	assert.Equal(t, order.Legs[0].Quantity, 1.0)
+	assert.Equal(t, order.Legs[1].Quantity, 2.0)
+
+	// bp per contract over the max
+	_, err = evaluateDryRun(checks, putSpread(1, "0.60"), dryRunResp("1.30", "600"))
+	assert.Equal(t, errors.Is(err, ErrDryRunCheck), true)
+}
+
+func TestEvaluateDryRunMaxLoss(t *testing.T) {
+	checks := strategy.DryRunChecks{MaxLossPerContract: decimal.RequireFromString("450")}
+
+	// 5 wide for 0.60 loses 440 per contract, 441.30 with fees
+	units, err := evaluateDryRun(checks, putSpread(2, "0.60"), dryRunResp("2.60", "880"))
+	assert.Equal(t, err, nil)
+	assert.Equal(t, units, 2)
+	_, err = evaluateDryRun(checks, putSpread(2, "0.45"), dryRunResp("2.60", "910"))
+	assert.Equal(t, errors.Is(err, ErrDryRunCheck), true)
+
+	// a naked put is capped by a zero underlying, a naked call is not
+	checks = strategy.DryRunChecks{RequireDefinedRisk: true}
+	order := putSpread(1, "1.00")
+	order.Legs = order.Legs[:1]
+	_, err = evaluateDryRun(checks, order, dryRunResp("1.30", "6000"))
+	assert.Equal(t, err, nil)
+	order.Legs[0].Symbol = "XSP   250808C00620000"
+	_, err = evaluateDryRun(checks, order, dryRunResp("1.30", "6000"))
+	assert.Equal(t, errors.Is(err, ErrDryRunCheck), true)
+}
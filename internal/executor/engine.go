@@ -2,13 +2,14 @@ package executor
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"strconv"
-	"sync"
 	"time"
 
+	"github.com/jamesonhm/gochain/internal/calendar"
+	"github.com/jamesonhm/gochain/internal/clock"
 	"github.com/jamesonhm/gochain/internal/dt"
 	"github.com/jamesonhm/gochain/internal/dxlink"
 	"github.com/jamesonhm/gochain/internal/options"
@@ -20,25 +21,40 @@ type Engine struct {
 	apiClient      *tasty.TastyAPI
 	acctNum        string
 	optionProvider *dxlink.DxLinkClient
+	calendar       *calendar.Calendar
+	clock          clock.Clock
 	stratStates    StatusTracker
-	semaphore      chan struct{}
-	wg             sync.WaitGroup
+	queue          *jobQueue
+	results        chan Result
 	workerCount    int
+	jobTimeout     time.Duration
 	ctx            context.Context
 	liveOrder      bool
 }
 
 type StatusTracker interface {
-	SubmitOrder(string, time.Time, string, tasty.Order)
-	NextPFID() int
+	SubmitOrder(string, time.Time, string, tasty.Order, *strategy.Strategy) error
+	UpdateOrder(string, time.Time, string, tasty.Order) error
+	SetFees(string, string, tasty.FeeCalculation) error
+	SetPricing(string, string, *dxlink.QuoteSnapshot) error
+	SetState(string, string, strategy.OrderState, string) error
+	NextPFID() (int, error)
 }
 
+var (
+	ErrDuplicateJob = errors.New("job already queued or running")
+	ErrJobExpired   = errors.New("job deadline passed before it was started")
+)
+
 func NewEngine(
 	apiClient *tasty.TastyAPI,
 	acctNum string,
 	optionProvider *dxlink.DxLinkClient,
+	cal *calendar.Calendar,
+	clk clock.Clock,
 	stratStates StatusTracker,
 	workerCount int,
+	jobTimeout time.Duration,
 	ctx context.Context,
 	liveOrder bool,
 ) *Engine {
@@ -47,60 +63,78 @@ func NewEngine(
 		apiClient:      apiClient,
 		acctNum:        acctNum,
 		optionProvider: optionProvider,
+		calendar:       cal,
+		clock:          clk,
 		stratStates:    stratStates,
-		semaphore:      make(chan struct{}, workerCount),
+		queue:          newJobQueue(),
+		results:        make(chan Result, 64),
 		workerCount:    workerCount,
+		jobTimeout:     jobTimeout,
 		ctx:            ctx,
 		liveOrder:      liveOrder,
 	}
 
-	//e.startWorkers()
+	e.startWorkers()
 	return e
 }
 
-// Submit order converts a strategy to an order and queues it
-// called by monitor engine when conditions are met
-// TODO: submit to open vs submit to close...
-func (e *Engine) SubmitOrder(s strategy.Strategy) {
-	order, err := e.orderFromStrategy(s)
-	if err != nil {
-		slog.Error("Unable to create order from strategy:", "error", err)
-		return
-	}
-	pfid := strconv.Itoa(e.stratStates.NextPFID())
-	order.PreflightID = pfid
-	order.Source = s.Name
-	bytes, _ := json.MarshalIndent(order, "", "\t")
-	fmt.Printf("This is where the order goes into the queue:\n%+v\n", string(bytes))
-	//e.stratStates.Submit(s.Name, time.Now().In(dt.TZNY()), pfid, order)
-	//e.stratStates.PPrint()
-	e.semaphore <- struct{}{}
-	e.wg.Add(1)
-	go e.worker(order, s)
-	e.wg.Wait()
+// Results reports the outcome of every job taken off the queue
+func (e *Engine) Results() <-chan Result {
+	return e.results
 }
 
-func (e *Engine) orderFromStrategy(s strategy.Strategy) (tasty.NewOrder, error) {
+// Pending returns the number of jobs waiting for a worker
+func (e *Engine) Pending() int {
+	return e.queue.len()
+}
+
+// SubmitOrder queues an entry order for the strategy and returns immediately.
+// called by monitor engine when conditions are met, window is the entry window
+// the order belongs to so that each window is only attempted once
+func (e *Engine) SubmitOrder(s strategy.Strategy, window string) error {
+	end := dt.ParseTimeAsToday(e.clock.Now().In(dt.TZNY()), s.EntryTime.MaxTime)
+	return e.enqueue(Job{Kind: JobEntry, Strategy: s, Window: window, HoldUntil: end})
+}
+
+// SubmitCancel queues the cancellation of a working broker order, once accepted
+// it is not queued again for the rest of the session
+func (e *Engine) SubmitCancel(s strategy.Strategy, pfid string, orderID int) error {
+	job := Job{Kind: JobCancel, Strategy: s, Window: strconv.Itoa(orderID), PFID: pfid, OrderID: orderID}
+	if session, ok := e.calendar.Session(e.clock.Now()); ok {
+		job.HoldUntil = session.Close
+	}
+	return e.enqueue(job)
+}
+
+func (e *Engine) enqueue(job Job) error {
+	now := e.clock.Now().In(dt.TZNY())
+	job.Queued = now
+	if job.Deadline.IsZero() {
+		job.Deadline = now.Add(e.jobTimeout)
+	}
+	if !e.queue.push(job) {
+		return fmt.Errorf("%s %s (%s): %w", job.Kind, job.Strategy.Name, job.Window, ErrDuplicateJob)
+	}
+	slog.Info("(executor) job queued", "kind", job.Kind.String(), "strategy", job.Strategy.Name, "window", job.Window)
+	return nil
+}
+
+// orderFromStrategy selects the leg options, then prices every leg from one quote snapshot
+func (e *Engine) orderFromStrategy(ctx context.Context, s strategy.Strategy) (tasty.NewOrder, *dxlink.QuoteSnapshot, error) {
 	// for each leg, calculate strike price
 	// create leg(s)
 	// create order struct
 	// TODO: change price/midPrice to decimal type
 	var price float64
 	var effect tasty.PriceEffect
-	var holidays []time.Time
 	var err error
-	holidays, err = e.apiClient.GetMarketHolidaysDT(e.ctx)
-	if err != nil {
-		slog.Error("(orderFromStrategy) Unable to get market holidays:", "error", err)
-		holidays = []time.Time{}
-	}
+	holidays := e.calendar.Holidays()
 
 	orderLegs := make([]tasty.NewOrderLeg, 0)
+	symbols := make([]string, 0, len(s.Legs))
 	for i, leg := range s.Legs {
 		var action tasty.OrderAction
-		var midPrice float64
 		var optSymbol *options.OptionSymbol
-		var err error
 
 		switch leg.StrikeMethod {
 		case strategy.Delta:
@@ -112,6 +146,7 @@ func (e *Engine) orderFromStrategy(s strategy.Strategy) (tasty.NewOrder, error)
 				"strike meth val:", leg.StrikeMethVal,
 			)
 			optData, err := e.optionProvider.OptionDataByDelta(
+				ctx,
 				s.Underlying,
 				leg.DTE,
 				options.OptionType(leg.OptType),
@@ -120,46 +155,39 @@ func (e *Engine) orderFromStrategy(s strategy.Strategy) (tasty.NewOrder, error)
 				holidays,
 			)
 			if err != nil {
-				return tasty.NewOrder{}, fmt.Errorf("Error getting option data: %w", err)
+				return tasty.NewOrder{}, nil, fmt.Errorf("Error getting option data: %w", err)
 			}
 			optSymbol, err = options.ParseDxLinkOption(optData.Greek.Symbol)
 			if err != nil {
-				return tasty.NewOrder{},
+				return tasty.NewOrder{}, nil,
 					fmt.Errorf("Error parsing optData.Greek.Symbol: %s, %w", optData.Greek.Symbol, err)
 			}
-			midPrice = (*optData.Quote.AskPrice + *optData.Quote.BidPrice) / 2
-			fmt.Printf("(orderFromStrategy) mid price for leg %d: %.2f\n", i+1, midPrice)
 		case strategy.Relative:
 			if i == 0 {
-				return tasty.NewOrder{}, fmt.Errorf("Strike Method `Relative` cannot be the first leg")
+				return tasty.NewOrder{}, nil, fmt.Errorf("Strike Method `Relative` cannot be the first leg")
 			}
 			prevSymbol := orderLegs[i-1].Symbol
 			optSymbol, err = options.ParseOCCOption(prevSymbol)
 			if err != nil {
-				return tasty.NewOrder{}, fmt.Errorf("Unable to parse OCC Option: %s, %w", prevSymbol, err)
+				return tasty.NewOrder{}, nil, fmt.Errorf("Unable to parse OCC Option: %s, %w", prevSymbol, err)
 			}
 			optSymbol.IncrementStrike(leg.StrikeMethVal)
-			optData, err := e.optionProvider.GetOptData(optSymbol.DxLinkString())
-			if err != nil {
-				return tasty.NewOrder{}, fmt.Errorf("Unable to get Opt Data with symbol: %s, %w", optSymbol.DxLinkString(), err)
+			// waits for the first quote of the leg
+			if _, err := e.optionProvider.GetOptData(ctx, optSymbol.DxLinkString()); err != nil {
+				return tasty.NewOrder{}, nil, fmt.Errorf("Unable to get Opt Data with symbol: %s, %w", optSymbol.DxLinkString(), err)
 			}
-			midPrice = (*optData.Quote.AskPrice + *optData.Quote.BidPrice) / 2
-			fmt.Printf("(orderFromStrategy) mid price for leg %d: %.2f\n", i+1, midPrice)
 		}
 
+		if optSymbol == nil {
+			return tasty.NewOrder{}, nil, fmt.Errorf("optSymbol is nil for leg %d with strikeMethod %v", i, leg.StrikeMethod)
+		}
 		if leg.Side == strategy.Buy {
 			action = tasty.BTO
-			price -= midPrice
 		} else {
 			action = tasty.STO
-			price += midPrice
-		}
-		fmt.Printf("(orderFromStrategy) updated Price: %.2f\n", price)
-
-		if optSymbol == nil {
-			return tasty.NewOrder{}, fmt.Errorf("optSymbol is nil for leg %d with strikeMethod %v", i, leg.StrikeMethod)
 		}
-		fmt.Printf("(orderFromStrategy) optSymbol for order leg: %s\n", optSymbol.OCCString())
+		slog.Debug("(orderFromStrategy) order leg", "strategy", s.Name, "leg", i+1, "symbol", optSymbol.OCCString())
+		symbols = append(symbols, optSymbol.DxLinkString())
 		orderLegs = append(orderLegs, tasty.NewOrderLeg{
 			InstrumentType: tasty.EquityOptionIT,
 			Symbol:         optSymbol.OCCString(),
@@ -168,6 +196,29 @@ func (e *Engine) orderFromStrategy(s strategy.Strategy) (tasty.NewOrder, error)
 		})
 	}
 
+	snap, err := e.optionProvider.Snapshot(symbols)
+	if err != nil {
+		return tasty.NewOrder{}, nil, err
+	}
+	now := e.clock.Now()
+	for i, leg := range s.Legs {
+		optData, _ := snap.Data(symbols[i])
+		if err := checkQuote(s.QuoteGuards, optData, leg.StrikeMethod == strategy.Delta, now); err != nil {
+			return tasty.NewOrder{}, snap, fmt.Errorf("leg %d: %w", i+1, err)
+		}
+		quote, _ := snap.Leg(symbols[i])
+		slog.Debug("(orderFromStrategy) leg mid price", "strategy", s.Name, "leg", i+1, "mid", quote.Mid)
+		if orderLegs[i].Action == tasty.BTO {
+			price -= quote.Mid
+		} else {
+			price += quote.Mid
+		}
+	}
+	if err := checkSkew(s.QuoteGuards, snap); err != nil {
+		return tasty.NewOrder{}, snap, err
+	}
+	slog.Debug("(orderFromStrategy) order price", "strategy", s.Name, "price", price, "leg skew", snap.Skew)
+
 	// Add Entry Slippage
 	if price > 0.0 {
 		price -= (float64(s.EntrySlippage) / 100)
@@ -185,59 +236,176 @@ func (e *Engine) orderFromStrategy(s strategy.Strategy) (tasty.NewOrder, error)
 		Price:       fmt.Sprintf("%.2f", price),
 		PriceEffect: effect,
 		Legs:        orderLegs,
-	}, nil
+	}, snap, nil
 }
 
-//func (e *Engine) startWorkers() {
-//	for i := 1; i < e.workerCount+1; i++ {
-//		e.wg.Add(1)
-//		go e.worker(i)
-//	}
-//}
+func (e *Engine) startWorkers() {
+	for i := 1; i < e.workerCount+1; i++ {
+		go e.worker(i)
+	}
+}
+
+func (e *Engine) worker(id int) {
+	for {
+		job, ok := e.queue.pop(e.ctx)
+		if !ok {
+			return
+		}
+		res := e.process(job)
+		e.queue.done(job, res.Recorded)
+		if res.Err != nil {
+			slog.Error("(executor.worker) job failed", "worker", id, "kind", job.Kind.String(), "strategy", job.Strategy.Name, "error", res.Err)
+		}
 
-// func (e *Engine) worker(id int) {
-func (e *Engine) worker(newOrder tasty.NewOrder, s strategy.Strategy) {
+		select {
+		case e.results <- res:
+		default:
+			slog.Warn("(executor.worker) results channel full, dropping result", "kind", job.Kind.String(), "strategy", job.Strategy.Name)
+		}
+	}
+}
+
+func (e *Engine) process(job Job) (res Result) {
+	res = Result{Job: job}
 	defer func() {
-		<-e.semaphore
-		e.wg.Done()
+		res.Finished = e.clock.Now().In(dt.TZNY())
 	}()
 
-	//for order := range e.semaphore {
-	resp, err := e.apiClient.SubmitOrderDryRun(e.ctx, e.acctNum, &newOrder)
+	now := e.clock.Now()
+	if now.After(job.Deadline) {
+		res.Err = ErrJobExpired
+		return res
+	}
+	// the deadline is on the engine clock, the context times out in wall time
+	ctx, cancel := context.WithTimeout(e.ctx, job.Deadline.Sub(now))
+	defer cancel()
+
+	switch job.Kind {
+	case JobEntry:
+		res.Order, res.Live, res.Recorded, res.Err = e.enter(ctx, job.Strategy)
+	case JobCancel:
+		res.Order, res.Err = e.cancelOrder(ctx, job)
+		res.Live = res.Err == nil
+		res.Recorded = res.Err == nil
+	}
+	return res
+}
+
+// enter builds, checks and submits an entry order, it also reports whether the order
+// was recorded before the job ended
+func (e *Engine) enter(ctx context.Context, s strategy.Strategy) (tasty.Order, bool, bool, error) {
+	newOrder, snap, err := e.orderFromStrategy(ctx, s)
 	if err != nil {
-		slog.Error("(executor.worker) order dry run", "order", newOrder, "error", err)
-		return
+		return tasty.Order{}, false, false, fmt.Errorf("unable to create order from strategy: %w", err)
 	}
-	orderResp := resp.OrderResponse.Order
-	e.stratStates.SubmitOrder(orderResp.Source, time.Now().In(dt.TZNY()), orderResp.PreflightID, orderResp)
+	seq, err := e.stratStates.NextPFID()
+	if err != nil {
+		return tasty.Order{}, false, false, err
+	}
+	pfid := strconv.Itoa(seq)
+	newOrder.PreflightID = pfid
+	newOrder.Source = s.Name
+	slog.Debug("(executor.enter) entry order", "strategy", s.Name, "pfid", pfid, "order", newOrder)
 
-	respbyt, err := json.MarshalIndent(resp, "", "  ")
+	var recorded bool
+	record := func(order tasty.Order) error {
+		err := e.stratStates.SubmitOrder(s.Name, e.clock.Now().In(dt.TZNY()), pfid, order, &s)
+		recorded = err == nil
+		return err
+	}
+	order, live, err := e.submit(ctx, s, newOrder, snap, record)
+	return order, live, recorded, err
+}
+
+func (e *Engine) cancelOrder(ctx context.Context, job Job) (tasty.Order, error) {
+	order, err := e.apiClient.CancelOrder(ctx, e.acctNum, job.OrderID)
 	if err != nil {
-		slog.Error("(executor.worker) unable to marshal dry run response", "error", err)
-	} else {
-		fmt.Println(string(respbyt))
+		return tasty.Order{}, fmt.Errorf("cancel order %d: %w", job.OrderID, err)
+	}
+	if job.PFID != "" {
+		err = e.stratStates.UpdateOrder(job.Strategy.Name, e.clock.Now().In(dt.TZNY()), job.PFID, *order)
+		if err != nil {
+			slog.Error("(executor.cancelOrder) unable to record cancel", "pfid", job.PFID, "error", err)
+		}
+	}
+	return *order, nil
+}
+
+// submit dry runs the order, records the dry run response and submits it live when enabled.
+// Orders are checked against the strategy dry run thresholds and resized or rejected.
+// Orders that can't be recorded are never submitted live
+func (e *Engine) submit(ctx context.Context, s strategy.Strategy, newOrder tasty.NewOrder, snap *dxlink.QuoteSnapshot, record func(tasty.Order) error) (tasty.Order, bool, error) {
+	resp, err := e.apiClient.SubmitOrderDryRun(ctx, e.acctNum, &newOrder)
+	if err != nil {
+		return tasty.Order{}, false, fmt.Errorf("order dry run: %w", err)
+	}
+
+	units, checkErr := evaluateDryRun(s.DryRunChecks, newOrder, resp.OrderResponse)
+	if checkErr == nil && units < orderUnits(newOrder) {
+		slog.Info("(executor.submit) resizing order for buying power", "strategy", s.Name, "from", orderUnits(newOrder), "to", units)
+		resizeOrder(&newOrder, units)
+		resp, err = e.apiClient.SubmitOrderDryRun(ctx, e.acctNum, &newOrder)
+		if err != nil {
+			return tasty.Order{}, false, fmt.Errorf("resized order dry run: %w", err)
+		}
+		// the resized order is checked again, buying power or fees may not have scaled down with it
+		var resized int
+		resized, checkErr = evaluateDryRun(s.DryRunChecks, newOrder, resp.OrderResponse)
+		if checkErr == nil && resized < units {
+			checkErr = fmt.Errorf("%w: resized order to %d units still allows only %d", ErrDryRunCheck, units, resized)
+		}
+	}
+
+	orderResp := resp.OrderResponse.Order
+	if err := record(orderResp); err != nil {
+		return orderResp, false, fmt.Errorf("unable to record order: %w", err)
+	}
+	if err := e.stratStates.SetFees(s.Name, newOrder.PreflightID, resp.OrderResponse.FeeCalculation); err != nil {
+		slog.Error("(executor.submit) unable to record fees", "pfid", newOrder.PreflightID, "error", err)
 	}
+	if err := e.stratStates.SetPricing(s.Name, newOrder.PreflightID, snap); err != nil {
+		slog.Error("(executor.submit) unable to record pricing", "pfid", newOrder.PreflightID, "error", err)
+	}
+
+	slog.Debug("(executor.submit) dry run response", "strategy", s.Name, "pfid", newOrder.PreflightID, "response", resp.OrderResponse)
 	if len(resp.OrderResponse.Warnings) > 0 {
 		slog.Warn(
-			"(executor.worker) order dry run, will not go live",
+			"(executor.submit) order dry run, will not go live",
 			"warnings", resp.OrderResponse.Warnings,
 		)
-		return
+		checkErr = fmt.Errorf("dry run warnings: %v", resp.OrderResponse.Warnings)
 	}
-	// TODO: do something with the returned buying power effect or fees?
-
-	// Update qty's for strat Allocation
-
-	if e.liveOrder {
-		resp, err = e.apiClient.SubmitOrder(e.ctx, e.acctNum, &newOrder)
+	if checkErr != nil {
+		err := e.stratStates.SetState(s.Name, newOrder.PreflightID, strategy.StateRejected, checkErr.Error())
 		if err != nil {
-			slog.Error("(executor.worker) order submit", "order", newOrder, "error", err)
-			return
+			slog.Error("(executor.submit) unable to reject order", "pfid", newOrder.PreflightID, "error", err)
 		}
+		return orderResp, false, checkErr
 	}
-	//}
-}
 
-func allocationMultiple(s strategy.Strategy, orderResp tasty.OrderResponse) int {
-	return 0
+	if !e.liveOrder {
+		// never goes live, so it doesn't count as an open trade or keep its legs subscribed
+		if err := e.stratStates.SetState(s.Name, newOrder.PreflightID, strategy.StateClosed, "dry run only"); err != nil {
+			slog.Error("(executor.submit) unable to close dry run order", "pfid", newOrder.PreflightID, "error", err)
+		}
+		return orderResp, false, nil
+	}
+	resp, err = e.apiClient.SubmitOrder(ctx, e.acctNum, &newOrder)
+	if err != nil {
+		if err := e.stratStates.SetState(s.Name, newOrder.PreflightID, strategy.StateRejected, err.Error()); err != nil {
+			slog.Error("(executor.submit) unable to reject order", "pfid", newOrder.PreflightID, "error", err)
+		}
+		return orderResp, false, fmt.Errorf("order submit: %w", err)
+	}
+	liveOrder := resp.OrderResponse.Order
+	err = e.stratStates.UpdateOrder(s.Name, e.clock.Now().In(dt.TZNY()), newOrder.PreflightID, liveOrder)
+	if err != nil {
+		slog.Error("(executor.submit) unable to record live order", "order id", liveOrder.ID, "error", err)
+	}
+	if fees := resp.OrderResponse.FeeCalculation; !fees.TotalFees.IsZero() {
+		if err := e.stratStates.SetFees(s.Name, newOrder.PreflightID, fees); err != nil {
+			slog.Error("(executor.submit) unable to record fees", "pfid", newOrder.PreflightID, "error", err)
+		}
+	}
+	return liveOrder, true, nil
 }
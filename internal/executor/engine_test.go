@@ -0,0 +1,64 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-playground/assert/v2"
+	"github.com/jamesonhm/gochain/internal/calendar"
+	"github.com/jamesonhm/gochain/internal/clock"
+	"github.com/jamesonhm/gochain/internal/dt"
+	"github.com/jamesonhm/gochain/internal/dxlink"
+	"github.com/jamesonhm/gochain/internal/strategy"
+)
+
+func TestProcessExpiredJob(t *testing.T) {
+	now := time.Date(2025, 7, 2, 10, 0, 0, 0, dt.TZNY())
+	clk := clock.NewFixed(now)
+	e := &Engine{clock: clk, ctx: context.Background(), queue: newJobQueue(), jobTimeout: 30 * time.Second}
+
+	assert.Equal(t, e.enqueue(Job{Kind: JobEntry, Strategy: strategy.Strategy{Name: "pcs"}, Window: "w"}), nil)
+	job, ok := e.queue.pop(context.Background())
+	assert.Equal(t, ok, true)
+	assert.Equal(t, job.Queued, now)
+	assert.Equal(t, job.Deadline, now.Add(30*time.Second))
+
+	clk.Advance(time.Minute)
+	res := e.process(job)
+	assert.Equal(t, res.Err, ErrJobExpired)
+	assert.Equal(t, res.Finished, now.Add(time.Minute))
+}
+
+func TestFailedEntryReleasesWindow(t *testing.T) {
+	now := time.Date(2025, 7, 2, 10, 0, 0, 0, dt.TZNY())
+	e := &Engine{
+		optionProvider: dxlink.New(context.Background(), "", ""),
+		calendar:       calendar.New(),
+		clock:          clock.NewFixed(now),
+		ctx:            context.Background(),
+		queue:          newJobQueue(),
+		jobTimeout:     30 * time.Second,
+	}
+	s := strategy.Strategy{
+		Name:       "pcs",
+		Underlying: "XSP",
+		Legs:       []strategy.Leg{{OptType: strategy.Put, Side: strategy.Sell, Quantity: 1, StrikeMethod: strategy.Delta, StrikeMethVal: -0.2}},
+		EntryTime:  strategy.EntryTime{MinTime: "9:45AM", MaxTime: "11:00AM"},
+	}
+
+	// no strikes are subscribed, the entry fails before an order is recorded
+	assert.Equal(t, e.SubmitOrder(s, "w"), nil)
+	job, _ := e.queue.pop(context.Background())
+	res := e.process(job)
+	assert.NotEqual(t, res.Err, nil)
+	assert.Equal(t, res.Recorded, false)
+	e.queue.done(job, res.Recorded)
+
+	// so the next scan retries the window
+	assert.Equal(t, e.SubmitOrder(s, "w"), nil)
+	job, _ = e.queue.pop(context.Background())
+	e.queue.done(job, true)
+	assert.Equal(t, errors.Is(e.SubmitOrder(s, "w"), ErrDuplicateJob), true)
+}
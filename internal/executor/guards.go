@@ -0,0 +1,61 @@
+package executor
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/jamesonhm/gochain/internal/dxlink"
+	"github.com/jamesonhm/gochain/internal/strategy"
+)
+
+var ErrQuoteGuard = errors.New("quote guard")
+
+// checkQuote validates the quote of a single leg against the strategy quote guards,
+// the returned error gives the reason order construction was aborted
+func checkQuote(g strategy.QuoteGuards, data *dxlink.OptionData, requireDelta bool, now time.Time) error {
+	quote := data.Quote
+	if quote.ReceivedAt.IsZero() || quote.BidPrice == nil || quote.AskPrice == nil {
+		return fmt.Errorf("%w: no quote received for %s", ErrQuoteGuard, quote.Symbol)
+	}
+	if g.MaxQuoteAgeSecs > 0 {
+		age := now.Sub(quote.Time())
+		if age.Seconds() > g.MaxQuoteAgeSecs {
+			return fmt.Errorf("%w: quote for %s is %s old, max %.0fs", ErrQuoteGuard, quote.Symbol, age.Round(time.Second), g.MaxQuoteAgeSecs)
+		}
+	}
+
+	bid, ask := *quote.BidPrice, *quote.AskPrice
+	if !g.AllowZeroBid && bid <= 0 {
+		return fmt.Errorf("%w: zero bid for %s", ErrQuoteGuard, quote.Symbol)
+	}
+	if ask < bid {
+		return fmt.Errorf("%w: crossed quote for %s, bid %.2f ask %.2f", ErrQuoteGuard, quote.Symbol, bid, ask)
+	}
+	width := ask - bid
+	if g.MaxSpreadCents > 0 && math.Round(width*100) > float64(g.MaxSpreadCents) {
+		return fmt.Errorf("%w: spread for %s is %.2f, max %d cents", ErrQuoteGuard, quote.Symbol, width, g.MaxSpreadCents)
+	}
+	if g.MaxSpreadPct > 0 {
+		mid := (ask + bid) / 2
+		if mid <= 0 || width/mid*100 > g.MaxSpreadPct {
+			return fmt.Errorf("%w: spread for %s is %.2f on a mid of %.2f, max %.1f%%", ErrQuoteGuard, quote.Symbol, width, mid, g.MaxSpreadPct)
+		}
+	}
+
+	if requireDelta || g.RequireDelta {
+		if data.Greek.ReceivedAt.IsZero() || data.Greek.Delta == nil {
+			return fmt.Errorf("%w: no delta received for %s", ErrQuoteGuard, quote.Symbol)
+		}
+	}
+	return nil
+}
+
+// checkSkew rejects orders priced from leg quotes too far apart in time
+func checkSkew(g strategy.QuoteGuards, snap *dxlink.QuoteSnapshot) error {
+	if g.MaxLegSkewSecs > 0 && snap.Skew.Seconds() > g.MaxLegSkewSecs {
+		return fmt.Errorf("%w: leg quotes are %s apart, max %.0fs", ErrQuoteGuard, snap.Skew.Round(time.Millisecond), g.MaxLegSkewSecs)
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package executor
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-playground/assert/v2"
+	"github.com/jamesonhm/gochain/internal/dxlink"
+	"github.com/jamesonhm/gochain/internal/strategy"
+)
+
+func quotedOption(bid, ask float64, quoteTime time.Time, withDelta bool) *dxlink.OptionData {
+	data := dxlink.NewOptionData()
+	data.Quote.Symbol = ".XSP250808P600"
+	*data.Quote.BidPrice = bid
+	*data.Quote.AskPrice = ask
+	data.Quote.ReceivedAt = quoteTime
+	if withDelta {
+		*data.Greek.Delta = -0.3
+		data.Greek.ReceivedAt = quoteTime
+	}
+	return data
+}
+
+func TestCheckQuote(t *testing.T) {
+	now := time.Date(2025, 8, 1, 10, 0, 0, 0, time.UTC)
+	guards := strategy.QuoteGuards{
+		MaxQuoteAgeSecs: 10,
+		MaxSpreadCents:  10,
+		MaxSpreadPct:    20,
+	}
+	tests := []struct {
+		name   string
+		data   *dxlink.OptionData
+		passes bool
+	}{
+		{"good quote", quotedOption(1.00, 1.05, now.Add(-2*time.Second), true), true},
+		{"never quoted", dxlink.NewOptionData(), false},
+		{"stale", quotedOption(1.00, 1.05, now.Add(-time.Minute), true), false},
+		{"zero bid", quotedOption(0, 0.05, now, true), false},
+		{"wide cents", quotedOption(1.00, 1.20, now, true), false},
+		{"wide pct", quotedOption(0.20, 0.30, now, true), false},
+		{"no delta", quotedOption(1.00, 1.05, now, false), false},
+	}
+	for _, tt := range tests {
+		err := checkQuote(guards, tt.data, true, now)
+		assert.Equal(t, err == nil, tt.passes)
+		if err != nil {
+			assert.Equal(t, errors.Is(err, ErrQuoteGuard), true)
+		}
+	}
+}
+
+func TestCheckSkew(t *testing.T) {
+	guards := strategy.QuoteGuards{MaxLegSkewSecs: 2}
+	assert.Equal(t, checkSkew(guards, &dxlink.QuoteSnapshot{Skew: time.Second}), nil)
+	err := checkSkew(guards, &dxlink.QuoteSnapshot{Skew: 3 * time.Second})
+	assert.Equal(t, errors.Is(err, ErrQuoteGuard), true)
+	assert.Equal(t, checkSkew(strategy.QuoteGuards{}, &dxlink.QuoteSnapshot{Skew: time.Hour}), nil)
+}
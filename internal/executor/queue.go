@@ -0,0 +1,171 @@
+package executor
+
+import (
+	"container/heap"
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/jamesonhm/gochain/internal/strategy"
+	"github.com/jamesonhm/gochain/internal/tasty"
+)
+
+// JobKind orders jobs in the queue, lower values are processed first
+type JobKind int
+
+const (
+	JobCancel JobKind = iota
+	JobEntry
+)
+
+func (k JobKind) String() string {
+	switch k {
+	case JobCancel:
+		return "cancel"
+	case JobEntry:
+		return "entry"
+	}
+	return "unknown"
+}
+
+type Job struct {
+	Kind     JobKind
+	Strategy strategy.Strategy
+	// identifies the entry window or broker order the job acts on.
+	// Only one job per kind, strategy and window can be queued or running at a time
+	Window string
+	// the key stays taken until then after a job that recorded an order, so an entry
+	// window is only attempted once. Jobs that failed before recording release the key
+	HoldUntil time.Time
+	// jobs not started by the deadline are dropped, running jobs are cancelled
+	Deadline time.Time
+	// preflight id of the wrapped order to cancel
+	PFID string
+	// broker order id for cancel jobs
+	OrderID int
+	Queued  time.Time
+	seq     uint64
+}
+
+func (j Job) key() string {
+	return fmt.Sprintf("%s|%s|%s", j.Kind, j.Strategy.Name, j.Window)
+}
+
+// Result is reported for every job taken off the queue
+type Result struct {
+	Job Job
+	// last order returned by the broker, dry run or live
+	Order tasty.Order
+	Live  bool
+	// an order or cancel was recorded in the strategy status, even if the job failed later
+	Recorded bool
+	Err      error
+	Finished time.Time
+}
+
+type jobHeap []Job
+
+func (h jobHeap) Len() int { return len(h) }
+func (h jobHeap) Less(i, j int) bool {
+	if h[i].Kind != h[j].Kind {
+		return h[i].Kind < h[j].Kind
+	}
+	return h[i].seq < h[j].seq
+}
+func (h jobHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+func (h *jobHeap) Push(x any)   { *h = append(*h, x.(Job)) }
+func (h *jobHeap) Pop() any {
+	old := *h
+	n := len(old)
+	job := old[n-1]
+	*h = old[:n-1]
+	return job
+}
+
+// jobQueue is a priority queue of jobs with deduplication on the job key
+type jobQueue struct {
+	mu   sync.Mutex
+	jobs jobHeap
+	// taken keys, with the time held keys are released. Zero while queued or running
+	keys   map[string]time.Time
+	seq    uint64
+	notify chan struct{}
+}
+
+func newJobQueue() *jobQueue {
+	return &jobQueue{
+		keys:   make(map[string]time.Time),
+		notify: make(chan struct{}, 1),
+	}
+}
+
+// push adds a job unless one with the same key is queued, running or held
+func (q *jobQueue) push(job Job) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	key := job.key()
+	if until, ok := q.keys[key]; ok && (until.IsZero() || job.Queued.Before(until)) {
+		return false
+	}
+	for k, until := range q.keys {
+		if !until.IsZero() && !job.Queued.Before(until) {
+			delete(q.keys, k)
+		}
+	}
+	q.keys[key] = time.Time{}
+	q.seq++
+	job.seq = q.seq
+	heap.Push(&q.jobs, job)
+	q.signal()
+	return true
+}
+
+// pop blocks until a job is available or the context is done
+func (q *jobQueue) pop(ctx context.Context) (Job, bool) {
+	for {
+		q.mu.Lock()
+		if len(q.jobs) > 0 {
+			job := heap.Pop(&q.jobs).(Job)
+			if len(q.jobs) > 0 {
+				// wake another worker for the remaining jobs
+				q.signal()
+			}
+			q.mu.Unlock()
+			return job, true
+		}
+		q.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return Job{}, false
+		case <-q.notify:
+		}
+	}
+}
+
+// done releases the job key so the same job can be queued again. The keys of jobs
+// that recorded an order are held until HoldUntil passes
+func (q *jobQueue) done(job Job, recorded bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if recorded && !job.HoldUntil.IsZero() {
+		q.keys[job.key()] = job.HoldUntil
+		return
+	}
+	delete(q.keys, job.key())
+}
+
+func (q *jobQueue) len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.jobs)
+}
+
+func (q *jobQueue) signal() {
+	select {
+	case q.notify <- struct{}{}:
+	default:
+	}
+}
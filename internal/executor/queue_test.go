@@ -0,0 +1,66 @@
+package executor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-playground/assert/v2"
+	"github.com/jamesonhm/gochain/internal/strategy"
+)
+
+func TestJobQueuePriority(t *testing.T) {
+	q := newJobQueue()
+	s := strategy.Strategy{Name: "test_strat"}
+	assert.Equal(t, q.push(Job{Kind: JobEntry, Strategy: s, Window: "w1"}), true)
+	assert.Equal(t, q.push(Job{Kind: JobEntry, Strategy: s, Window: "w2"}), true)
+	assert.Equal(t, q.push(Job{Kind: JobCancel, Strategy: s, Window: "99"}), true)
+
+	ctx := context.Background()
+	var order []string
+	for q.len() > 0 {
+		job, ok := q.pop(ctx)
+		assert.Equal(t, ok, true)
+		order = append(order, job.Kind.String()+":"+job.Window)
+	}
+	assert.Equal(t, order, []string{"cancel:99", "entry:w1", "entry:w2"})
+}
+
+func TestJobQueueDedup(t *testing.T) {
+	q := newJobQueue()
+	job := Job{Kind: JobEntry, Strategy: strategy.Strategy{Name: "test_strat"}, Window: "w1"}
+	assert.Equal(t, q.push(job), true)
+	assert.Equal(t, q.push(job), false)
+
+	// still deduped while running
+	popped, _ := q.pop(context.Background())
+	assert.Equal(t, q.push(job), false)
+
+	q.done(popped, false)
+	assert.Equal(t, q.push(job), true)
+
+	// an entry key is released when the job failed before recording an order
+	q = newJobQueue()
+	start := time.Date(2025, 8, 1, 10, 0, 0, 0, time.UTC)
+	job.Queued, job.HoldUntil = start, start.Add(time.Hour)
+	assert.Equal(t, q.push(job), true)
+	popped, _ = q.pop(context.Background())
+	q.done(popped, false)
+	assert.Equal(t, q.push(job), true)
+
+	// and held until its window ends once an order was recorded
+	popped, _ = q.pop(context.Background())
+	q.done(popped, true)
+	job.Queued = start.Add(5 * time.Second)
+	assert.Equal(t, q.push(job), false)
+	job.Queued = start.Add(time.Hour)
+	assert.Equal(t, q.push(job), true)
+}
+
+func TestJobQueuePopCancelled(t *testing.T) {
+	q := newJobQueue()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, ok := q.pop(ctx)
+	assert.Equal(t, ok, false)
+}
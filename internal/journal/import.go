@@ -0,0 +1,272 @@
+package journal
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/jamesonhm/gochain/internal/dt"
+	"github.com/jamesonhm/gochain/internal/options"
+	"github.com/jamesonhm/gochain/internal/store"
+	"github.com/jamesonhm/gochain/internal/tasty"
+	"github.com/shopspring/decimal"
+)
+
+// ManualStrategy tags imported trades whose opening order wasn't placed by a strategy
+const ManualStrategy = "manual"
+
+type HistoryProvider interface {
+	GetAllTransactions(context.Context, string, *tasty.TransactionsParams) ([]tasty.Transaction, error)
+	GetOrders(context.Context, string, *tasty.OrdersParams) ([]tasty.Order, error)
+}
+
+// ImportHistory rebuilds the closed trades between from and to out of the account
+// transactions. Trades already in the journal are kept, so the import can be rerun
+func (j *Journal) ImportHistory(ctx context.Context, api HistoryProvider, acctNum string, from, to time.Time, strategies []string) (int, error) {
+	start := from.In(dt.TZNY()).Format(time.DateOnly)
+	end := to.In(dt.TZNY()).Format(time.DateOnly)
+	txns, err := api.GetAllTransactions(ctx, acctNum, &tasty.TransactionsParams{
+		StartDate: start,
+		EndDate:   end,
+		Types:     []tasty.TransactionType{tasty.TradeTT, tasty.ReceiveDeliverTT},
+		Sort:      tasty.Asc,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("import: unable to get transactions: %w", err)
+	}
+	orders, err := orderHistory(ctx, api, acctNum, start, end)
+	if err != nil {
+		return 0, err
+	}
+	return j.importTrades(TradesFromTransactions(txns, orders, strategies))
+}
+
+func orderHistory(ctx context.Context, api HistoryProvider, acctNum string, start, end string) (map[int]tasty.Order, error) {
+	const perPage = 200
+	byID := make(map[int]tasty.Order)
+	for offset := 0; ; offset++ {
+		page, err := api.GetOrders(ctx, acctNum, &tasty.OrdersParams{
+			StartDate:  start,
+			EndDate:    end,
+			PerPage:    perPage,
+			PageOffset: offset,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("import: unable to get order history: %w", err)
+		}
+		for _, order := range page {
+			byID[order.ID] = order
+		}
+		if len(page) < perPage {
+			return byID, nil
+		}
+	}
+}
+
+func (j *Journal) importTrades(trades []Trade) (int, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	var count int
+	err := j.db.Update(func(tx *store.Tx) error {
+		for _, t := range trades {
+			var existing Trade
+			if found, err := tx.Get([]string{tradesBucket, t.Strategy}, t.PFID, &existing); err != nil {
+				return err
+			} else if found {
+				continue
+			}
+			if err := tx.Put([]string{tradesBucket, t.Strategy}, t.PFID, t); err != nil {
+				return err
+			}
+			count++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("import: unable to store trades: %w", err)
+	}
+	return count, nil
+}
+
+type importLeg struct {
+	symbol     string
+	action     tasty.OrderAction
+	multiplier int64
+	openQty    decimal.Decimal
+	remaining  decimal.Decimal
+	openCash   decimal.Decimal
+	closeCash  decimal.Decimal
+	closeQty   decimal.Decimal
+}
+
+type importTrade struct {
+	trade Trade
+	legs  []*importLeg
+}
+
+func (it *importTrade) leg(symbol string) *importLeg {
+	for _, leg := range it.legs {
+		if leg.symbol == symbol {
+			return leg
+		}
+	}
+	return nil
+}
+
+func (it *importTrade) closed() bool {
+	return !slices.ContainsFunc(it.legs, func(leg *importLeg) bool {
+		return leg.remaining.IsPositive()
+	})
+}
+
+// TradesFromTransactions groups opening fills by order and matches closing fills,
+// expirations and assignments to them first in first out by symbol. Trades are
+// assigned to the strategy named by the opening order source when it is one of
+// strategies, otherwise to ManualStrategy. Only fully closed trades are returned
+func TradesFromTransactions(txns []tasty.Transaction, orders map[int]tasty.Order, strategies []string) []Trade {
+	txns = slices.Clone(txns)
+	slices.SortStableFunc(txns, func(a, b tasty.Transaction) int {
+		return a.ExecutedAt.Compare(b.ExecutedAt)
+	})
+
+	var open []*importTrade
+	byOrder := make(map[int]*importTrade)
+	for _, txn := range txns {
+		switch {
+		case txn.TransactionType == tasty.TradeTT && (txn.Action == tasty.STO || txn.Action == tasty.BTO):
+			it, ok := byOrder[txn.OrderID]
+			if !ok {
+				it = newImportTrade(txn, orders, strategies)
+				byOrder[txn.OrderID] = it
+				open = append(open, it)
+			}
+			leg := it.leg(txn.Symbol)
+			if leg == nil {
+				leg = &importLeg{
+					symbol:     txn.Symbol,
+					action:     txn.Action,
+					multiplier: instrumentMultiplier(txn.InstrumentType),
+				}
+				it.legs = append(it.legs, leg)
+			}
+			leg.openQty = leg.openQty.Add(txn.Quantity.Abs())
+			leg.remaining = leg.remaining.Add(txn.Quantity.Abs())
+			leg.openCash = leg.openCash.Add(txn.Cash())
+			it.trade.Fees = it.trade.Fees.Add(txn.Fees())
+
+		case txn.Action == tasty.STC || txn.Action == tasty.BTC || txn.TransactionType == tasty.ReceiveDeliverTT:
+			matchClose(open, txn)
+
+		default:
+			slog.Debug("(journal.import) transaction skipped", "id", txn.ID, "type", txn.TransactionType, "action", txn.Action)
+		}
+	}
+
+	var trades []Trade
+	for _, it := range open {
+		if it.closed() {
+			trades = append(trades, it.build())
+		}
+	}
+	return trades
+}
+
+func newImportTrade(txn tasty.Transaction, orders map[int]tasty.Order, strategies []string) *importTrade {
+	name, pfid := ManualStrategy, fmt.Sprintf("order-%d", txn.OrderID)
+	if order, ok := orders[txn.OrderID]; ok && slices.Contains(strategies, order.Source) {
+		name = order.Source
+		if order.PreflightID != "" {
+			// same key the journal uses for trades synced from the strategy state
+			pfid = order.PreflightID
+		}
+	}
+	return &importTrade{
+		trade: Trade{
+			Strategy:   name,
+			PFID:       pfid,
+			Underlying: txn.UnderlyingSymbol,
+			OpenedAt:   txn.ExecutedAt,
+			Imported:   true,
+		},
+	}
+}
+
+// matchClose allocates a closing transaction across the open trades holding the symbol
+func matchClose(open []*importTrade, txn tasty.Transaction) {
+	qty := txn.Quantity.Abs()
+	if qty.IsZero() {
+		return
+	}
+	cash, fees := txn.Cash(), txn.Fees()
+	left := qty
+	for _, it := range open {
+		if !left.IsPositive() {
+			break
+		}
+		leg := it.leg(txn.Symbol)
+		if leg == nil || !leg.remaining.IsPositive() {
+			continue
+		}
+		take := decimal.Min(left, leg.remaining)
+		share := take.Div(qty)
+		leg.remaining = leg.remaining.Sub(take)
+		leg.closeQty = leg.closeQty.Add(take)
+		leg.closeCash = leg.closeCash.Add(cash.Mul(share))
+		it.trade.Fees = it.trade.Fees.Add(fees.Mul(share))
+		if txn.ExecutedAt.After(it.trade.ClosedAt) {
+			it.trade.ClosedAt = txn.ExecutedAt
+		}
+		if txn.TransactionType == tasty.ReceiveDeliverTT {
+			it.trade.Expired = true
+		}
+		left = left.Sub(take)
+	}
+	if left.IsPositive() {
+		slog.Debug("(journal.import) closing transaction without an opening", "id", txn.ID, "symbol", txn.Symbol, "quantity", left)
+	}
+}
+
+func (it *importTrade) build() Trade {
+	t := it.trade
+	var quantities []float64
+	mult := int64(100)
+	for _, leg := range it.legs {
+		t.EntryCash = t.EntryCash.Add(leg.openCash)
+		t.ExitCash = t.ExitCash.Add(leg.closeCash)
+		quantities = append(quantities, float64(leg.openQty.IntPart()))
+		mult = leg.multiplier
+		t.Legs = append(t.Legs, TradeLeg{
+			Symbol:     leg.symbol,
+			Action:     leg.action,
+			Quantity:   leg.openQty.InexactFloat64(),
+			EntryPrice: perShare(leg.openCash, leg.openQty, leg.multiplier),
+			ExitPrice:  perShare(leg.closeCash, leg.closeQty, leg.multiplier),
+		})
+	}
+	units := options.Units(quantities...)
+	t.Quantity = units
+	if units > 0 {
+		per := decimal.NewFromInt(int64(units) * mult)
+		t.EntryPrice = t.EntryCash.Div(per).Round(4)
+		t.ExitPrice = t.ExitCash.Div(per).Round(4)
+	}
+	t.Fees = t.Fees.Round(2)
+	t.EntryCash = t.EntryCash.Round(2)
+	t.ExitCash = t.ExitCash.Round(2)
+	t.RealizedPnL = t.EntryCash.Add(t.ExitCash).Add(t.Fees)
+	if t.ClosedAt.After(t.OpenedAt) {
+		t.HoldTime = t.ClosedAt.Sub(t.OpenedAt)
+	}
+	return t
+}
+
+// perShare is the unsigned average price of the fills on a leg
+func perShare(cash, qty decimal.Decimal, mult int64) decimal.Decimal {
+	if qty.IsZero() {
+		return decimal.Zero
+	}
+	return cash.Abs().Div(qty.Mul(decimal.NewFromInt(mult))).Round(4)
+}
@@ -0,0 +1,69 @@
+package journal
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-playground/assert/v2"
+	"github.com/jamesonhm/gochain/internal/dt"
+	"github.com/jamesonhm/gochain/internal/tasty"
+	"github.com/shopspring/decimal"
+)
+
+func txn(orderID int, typ tasty.TransactionType, action tasty.OrderAction, sym string, qty int64, value string, effect tasty.PriceEffect, at time.Time) tasty.Transaction {
+	return tasty.Transaction{
+		OrderID:          orderID,
+		TransactionType:  typ,
+		Action:           action,
+		Symbol:           sym,
+		InstrumentType:   tasty.EquityOptionIT,
+		UnderlyingSymbol: "XSP",
+		Quantity:         decimal.NewFromInt(qty),
+		Value:            decimal.RequireFromString(value),
+		ValueEffect:      effect,
+		Commission:       decimal.RequireFromString("1"),
+		CommissionEffect: tasty.Debit,
+		ExecutedAt:       at,
+	}
+}
+
+func TestTradesFromTransactions(t *testing.T) {
+	at := time.Date(2025, 8, 1, 10, 0, 0, 0, dt.TZNY())
+	short, long := "XSP   250808P00600000", "XSP   250808P00595000"
+	txns := []tasty.Transaction{
+		// bot spread, closed early
+		txn(1, tasty.TradeTT, tasty.STO, short, 1, "150", tasty.Credit, at),
+		txn(1, tasty.TradeTT, tasty.BTO, long, 1, "100", tasty.Debit, at),
+		txn(2, tasty.TradeTT, tasty.BTC, short, 1, "40", tasty.Debit, at.Add(time.Hour)),
+		txn(2, tasty.TradeTT, tasty.STC, long, 1, "20", tasty.Credit, at.Add(time.Hour)),
+		// manual short put, expired
+		txn(3, tasty.TradeTT, tasty.STO, "XSP   250801P00590000", 2, "60", tasty.Credit, at),
+		txn(0, tasty.ReceiveDeliverTT, tasty.BTC, "XSP   250801P00590000", 2, "0", tasty.None, at.Add(6*time.Hour)),
+		// still open
+		txn(4, tasty.TradeTT, tasty.STO, "XSP   250815P00580000", 1, "80", tasty.Credit, at),
+	}
+	orders := map[int]tasty.Order{
+		1: {ID: 1, Source: "test_strat", PreflightID: "12"},
+		3: {ID: 3, Source: "WBT"},
+	}
+	trades := TradesFromTransactions(txns, orders, []string{"test_strat"})
+	assert.Equal(t, len(trades), 2)
+
+	bot := trades[0]
+	assert.Equal(t, bot.Strategy, "test_strat")
+	assert.Equal(t, bot.PFID, "12")
+	assert.Equal(t, bot.EntryCash.String(), "50")
+	assert.Equal(t, bot.ExitCash.String(), "-20")
+	assert.Equal(t, bot.Fees.String(), "-4")
+	assert.Equal(t, bot.RealizedPnL.String(), "26")
+	assert.Equal(t, bot.EntryPrice.String(), "0.5")
+	assert.Equal(t, bot.HoldTime, time.Hour)
+	assert.Equal(t, bot.Legs[0].ExitPrice.String(), "0.4")
+
+	manual := trades[1]
+	assert.Equal(t, manual.Strategy, ManualStrategy)
+	assert.Equal(t, manual.PFID, "order-3")
+	assert.Equal(t, manual.Quantity, 2)
+	assert.Equal(t, manual.Expired, true)
+	assert.Equal(t, manual.RealizedPnL.String(), "58")
+}
@@ -0,0 +1,189 @@
+package journal
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/jamesonhm/gochain/internal/store"
+	"github.com/jamesonhm/gochain/internal/strategy"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	tradesBucket     = "trades"
+	excursionsBucket = "excursions"
+)
+
+var journalMigrations = []store.Migration{
+	// v1: trade and excursion buckets
+	func(tx *store.Tx) error {
+		for _, name := range []string{tradesBucket, excursionsBucket} {
+			if err := tx.CreateBucket(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+}
+
+// Excursion tracks the range of the unrealized P&L of an open trade
+type Excursion struct {
+	MAE      decimal.Decimal `json:"mae"`
+	MFE      decimal.Decimal `json:"mfe"`
+	LastMark decimal.Decimal `json:"last-mark"`
+	Marks    int             `json:"marks"`
+	Updated  time.Time       `json:"updated"`
+}
+
+// Journal stores closed trades, built once from the wrapped orders and never rewritten
+type Journal struct {
+	mu sync.Mutex
+	db *store.Store
+}
+
+func Open(fpath string) (*Journal, error) {
+	db, err := store.Open(fpath, journalMigrations)
+	if err != nil {
+		return nil, err
+	}
+	return &Journal{db: db}, nil
+}
+
+func (j *Journal) Close() error {
+	return j.db.Close()
+}
+
+// Mark records the unrealized P&L of an open trade
+func (j *Journal) Mark(stratname string, pfid string, pnl decimal.Decimal, at time.Time) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	return j.db.Update(func(tx *store.Tx) error {
+		path := []string{excursionsBucket, stratname}
+		var exc Excursion
+		if _, err := tx.Get(path, pfid, &exc); err != nil {
+			return err
+		}
+		if exc.Marks == 0 || pnl.LessThan(exc.MAE) {
+			exc.MAE = decimal.Min(pnl, decimal.Zero)
+		}
+		if exc.Marks == 0 || pnl.GreaterThan(exc.MFE) {
+			exc.MFE = decimal.Max(pnl, decimal.Zero)
+		}
+		exc.LastMark = pnl
+		exc.Marks++
+		exc.Updated = at
+		return tx.Put(path, pfid, exc)
+	})
+}
+
+func (j *Journal) Excursion(stratname string, pfid string) (Excursion, error) {
+	var exc Excursion
+	err := j.db.View(func(tx *store.Tx) error {
+		_, err := tx.Get([]string{excursionsBucket, stratname}, pfid, &exc)
+		return err
+	})
+	return exc, err
+}
+
+// Sync journals the closed wrapped orders that aren't trades yet and returns the new trades
+func (j *Journal) Sync(orders map[string][]strategy.WrappedOrder) ([]Trade, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	var added []Trade
+	err := j.db.Update(func(tx *store.Tx) error {
+		for name, wos := range orders {
+			for _, wo := range wos {
+				if wo.State != strategy.StateClosed {
+					continue
+				}
+				var existing Trade
+				if found, err := tx.Get([]string{tradesBucket, name}, wo.PreflightID, &existing); err != nil {
+					return err
+				} else if found {
+					continue
+				}
+				var exc Excursion
+				if _, err := tx.Get([]string{excursionsBucket, name}, wo.PreflightID, &exc); err != nil {
+					return err
+				}
+				trade, err := BuildTrade(name, wo, exc)
+				if err != nil {
+					slog.Debug("(journal.Sync) not a trade", "reason", err)
+					continue
+				}
+				if err := tx.Put([]string{tradesBucket, name}, wo.PreflightID, trade); err != nil {
+					return err
+				}
+				if err := tx.Delete([]string{excursionsBucket, name}, wo.PreflightID); err != nil {
+					return err
+				}
+				added = append(added, trade)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("journal sync: %w", err)
+	}
+	return added, nil
+}
+
+// Filter selects trades by strategy and the time they closed, zero values match all
+type Filter struct {
+	Strategy string
+	From     time.Time
+	To       time.Time
+}
+
+func (f Filter) match(t Trade) bool {
+	if f.Strategy != "" && f.Strategy != t.Strategy {
+		return false
+	}
+	if !f.From.IsZero() && t.ClosedAt.Before(f.From) {
+		return false
+	}
+	if !f.To.IsZero() && !t.ClosedAt.Before(f.To) {
+		return false
+	}
+	return true
+}
+
+// Trades returns the journaled trades matching the filter ordered by close time
+func (j *Journal) Trades(f Filter) ([]Trade, error) {
+	var trades []Trade
+	err := j.db.View(func(tx *store.Tx) error {
+		names, err := tx.Buckets(tradesBucket)
+		if err != nil {
+			return err
+		}
+		for _, name := range names {
+			if f.Strategy != "" && f.Strategy != name {
+				continue
+			}
+			err := tx.ForEach([]string{tradesBucket, name}, func(pfid string, data []byte) error {
+				var t Trade
+				if err := json.Unmarshal(data, &t); err != nil {
+					return fmt.Errorf("trade %s/%s: %w", name, pfid, err)
+				}
+				if f.match(t) {
+					trades = append(trades, t)
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	slices.SortFunc(trades, func(a, b Trade) int {
+		return a.ClosedAt.Compare(b.ClosedAt)
+	})
+	return trades, err
+}
@@ -0,0 +1,183 @@
+package journal
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-playground/assert/v2"
+	"github.com/jamesonhm/gochain/internal/dt"
+	"github.com/jamesonhm/gochain/internal/strategy"
+	"github.com/jamesonhm/gochain/internal/tasty"
+	"github.com/shopspring/decimal"
+)
+
+func filledLeg(sym string, action tasty.OrderAction, qty float64, price string, at time.Time) tasty.OrderLeg {
+	return tasty.OrderLeg{
+		InstrumentType: tasty.EquityOptionIT,
+		Symbol:         sym,
+		Quantity:       qty,
+		Action:         action,
+		Fills: []tasty.OrderFill{
+			{Quantity: qty, FillPrice: decimal.RequireFromString(price), FilledAt: at},
+		},
+	}
+}
+
+// put credit spread opened for 0.50 credit, closed for 0.20 debit
+func closedSpread(pfid string, open, close time.Time) strategy.WrappedOrder {
+	return strategy.WrappedOrder{
+		PreflightID: pfid,
+		State:       strategy.StateClosed,
+		Order: tasty.Order{
+			Status: tasty.Filled,
+			Legs: []tasty.OrderLeg{
+				filledLeg("XSP   250808P00600000", tasty.STO, 2, "1.50", open),
+				filledLeg("XSP   250808P00595000", tasty.BTO, 2, "1.00", open),
+			},
+		},
+		ClosingOrder: &tasty.Order{
+			Status: tasty.Filled,
+			Legs: []tasty.OrderLeg{
+				filledLeg("XSP   250808P00600000", tasty.BTC, 2, "0.40", close),
+				filledLeg("XSP   250808P00595000", tasty.STC, 2, "0.20", close),
+			},
+		},
+		Fees: &tasty.FeeCalculation{TotalFees: decimal.RequireFromString("2.50"), TotalFeesEffect: tasty.Debit},
+	}
+}
+
+func TestBuildTrade(t *testing.T) {
+	open := time.Date(2025, 8, 1, 10, 0, 0, 0, dt.TZNY())
+	close := open.Add(3 * time.Hour)
+	trade, err := BuildTrade("test_strat", closedSpread("1", open, close), Excursion{MAE: decimal.NewFromInt(-40)})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, trade.Quantity, 2)
+	assert.Equal(t, trade.EntryCash.String(), "100")
+	assert.Equal(t, trade.ExitCash.String(), "-40")
+	assert.Equal(t, trade.EntryPrice.String(), "0.5")
+	assert.Equal(t, trade.ExitPrice.String(), "-0.2")
+	assert.Equal(t, trade.RealizedPnL.String(), "57.5")
+	assert.Equal(t, trade.HoldTime, 3*time.Hour)
+	assert.Equal(t, trade.MAE.String(), "-40")
+	assert.Equal(t, trade.Legs[0].ExitPrice.String(), "0.4")
+
+	pos, err := trade.Position()
+	assert.Equal(t, err, nil)
+	assert.Equal(t, pos.MaxProfit(), 50.0)
+	assert.Equal(t, pos.MaxLoss(), -450.0)
+	assert.Equal(t, pos.Breakevens(), []float64{599.5})
+
+	// unfilled openings are not trades
+	wo := closedSpread("2", open, close)
+	wo.Order.Status = tasty.Cancelled
+	for i := range wo.Order.Legs {
+		wo.Order.Legs[i].Fills = nil
+	}
+	_, err = BuildTrade("test_strat", wo, Excursion{})
+	assert.NotEqual(t, err, nil)
+}
+
+func TestBuildTradePartialFills(t *testing.T) {
+	open := time.Date(2025, 8, 1, 10, 0, 0, 0, dt.TZNY())
+	close := open.Add(time.Hour)
+
+	// 2 of 3 spreads filled before the opening order was cancelled, closed by two
+	// closing orders that each filled 1 before they were cancelled
+	wo := closedSpread("1", open, close)
+	wo.Order.Status = tasty.Cancelled
+	for i := range wo.Order.Legs {
+		wo.Order.Legs[i].Quantity = 3
+	}
+	first := *wo.ClosingOrder
+	first.ID, first.Status = 11, tasty.Cancelled
+	first.Legs = []tasty.OrderLeg{
+		filledLeg("XSP   250808P00600000", tasty.BTC, 1, "0.30", close),
+		filledLeg("XSP   250808P00595000", tasty.STC, 1, "0.20", close),
+	}
+	second := first
+	second.ID = 12
+	second.Legs = []tasty.OrderLeg{
+		filledLeg("XSP   250808P00600000", tasty.BTC, 1, "0.50", close.Add(time.Hour)),
+		filledLeg("XSP   250808P00595000", tasty.STC, 1, "0.20", close.Add(time.Hour)),
+	}
+	for i := range second.Legs {
+		second.Legs[i].Quantity = 2
+	}
+	wo.PriorClosingOrders = []tasty.Order{first}
+	wo.ClosingOrder = &second
+
+	trade, err := BuildTrade("test_strat", wo, Excursion{})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, trade.Quantity, 2)
+	assert.Equal(t, trade.Expired, false)
+	assert.Equal(t, trade.EntryCash.String(), "100")
+	assert.Equal(t, trade.ExitCash.String(), "-40")
+	assert.Equal(t, trade.Legs[0].Quantity, 2.0)
+	assert.Equal(t, trade.Legs[0].ExitPrice.String(), "0.4")
+	assert.Equal(t, trade.ClosedAt, close.Add(time.Hour))
+
+	// a partially filled closing order with the rest left to expire
+	wo.PriorClosingOrders = nil
+	wo.ClosingOrder = &first
+	wo.UpdateTime = open.Add(6 * time.Hour)
+	trade, err = BuildTrade("test_strat", wo, Excursion{})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, trade.Expired, true)
+	assert.Equal(t, trade.ExitCash.String(), "-10")
+	assert.Equal(t, trade.RealizedPnL.String(), "87.5")
+	assert.Equal(t, trade.ClosedAt, wo.UpdateTime)
+}
+
+func TestComputeStats(t *testing.T) {
+	day := time.Date(2025, 8, 1, 15, 0, 0, 0, dt.TZNY())
+	trades := []Trade{
+		{Strategy: "a", ClosedAt: day, RealizedPnL: decimal.NewFromInt(60)},
+		{Strategy: "a", ClosedAt: day, RealizedPnL: decimal.NewFromInt(40)},
+		{Strategy: "b", ClosedAt: day.AddDate(0, 0, 1), RealizedPnL: decimal.NewFromInt(-80)},
+		{Strategy: "b", ClosedAt: day.AddDate(0, 0, 1), RealizedPnL: decimal.Zero},
+	}
+	st := ComputeStats(trades)
+	assert.Equal(t, st.Trades, 4)
+	assert.Equal(t, st.Wins, 2)
+	assert.Equal(t, st.Losses, 1)
+	assert.Equal(t, st.WinRate, 0.5)
+	assert.Equal(t, st.AvgWin.String(), "50")
+	assert.Equal(t, st.AvgLoss.String(), "-80")
+	assert.Equal(t, st.ProfitFactor.String(), "1.25")
+	assert.Equal(t, st.Expectancy.String(), "5")
+
+	assert.Equal(t, ByStrategy(trades)["a"].NetPnL.String(), "100")
+	assert.Equal(t, ByDay(trades)["2025-08-02"].Losses, 1)
+}
+
+func TestJournalSync(t *testing.T) {
+	j, err := Open(filepath.Join(t.TempDir(), "journal.db"))
+	assert.Equal(t, err, nil)
+	defer j.Close()
+
+	open := time.Date(2025, 8, 1, 10, 0, 0, 0, dt.TZNY())
+	j.Mark("test_strat", "1", decimal.NewFromInt(-25), open.Add(time.Hour))
+	j.Mark("test_strat", "1", decimal.NewFromInt(30), open.Add(2*time.Hour))
+	exc, _ := j.Excursion("test_strat", "1")
+	assert.Equal(t, exc.MAE.String(), "-25")
+	assert.Equal(t, exc.MFE.String(), "30")
+
+	orders := map[string][]strategy.WrappedOrder{
+		"test_strat": {closedSpread("1", open, open.Add(3*time.Hour))},
+	}
+	added, err := j.Sync(orders)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(added), 1)
+	assert.Equal(t, added[0].MAE.String(), "-25")
+
+	// already journaled
+	added, _ = j.Sync(orders)
+	assert.Equal(t, len(added), 0)
+
+	trades, err := j.Trades(Filter{Strategy: "test_strat", From: open})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(trades), 1)
+	trades, _ = j.Trades(Filter{From: open.AddDate(0, 0, 1)})
+	assert.Equal(t, len(trades), 0)
+}
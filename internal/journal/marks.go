@@ -0,0 +1,52 @@
+package journal
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jamesonhm/gochain/internal/dxlink"
+	"github.com/jamesonhm/gochain/internal/options"
+	"github.com/jamesonhm/gochain/internal/strategy"
+	"github.com/shopspring/decimal"
+)
+
+type MarkProvider interface {
+	GetOptData(context.Context, string) (*dxlink.OptionData, error)
+}
+
+// MarkToMarket is the unrealized P&L of an open wrapped order if closed at the
+// mid of each leg, excluding fees
+func MarkToMarket(ctx context.Context, wo strategy.WrappedOrder, marks MarkProvider) (decimal.Decimal, error) {
+	pnl := orderCash(wo.Order, orderUnits(wo.Order))
+	for _, order := range wo.ClosingOrders() {
+		if units := orderUnits(order); units > 0 {
+			pnl = pnl.Add(orderCash(order, units))
+		}
+	}
+	for _, leg := range wo.Order.Legs {
+		qty := wo.OpenQuantity(leg.Symbol)
+		if qty <= 0 {
+			continue
+		}
+		sym, err := options.ParseOCCOption(leg.Symbol)
+		if err != nil {
+			return decimal.Zero, fmt.Errorf("unable to parse OCC option %s: %w", leg.Symbol, err)
+		}
+		data, err := marks.GetOptData(ctx, sym.DxLinkString())
+		if err != nil {
+			return decimal.Zero, err
+		}
+		if data.Quote.BidPrice == nil || data.Quote.AskPrice == nil {
+			return decimal.Zero, fmt.Errorf("no quote for %s", leg.Symbol)
+		}
+		mid := decimal.NewFromFloat((*data.Quote.BidPrice + *data.Quote.AskPrice) / 2)
+		value := mid.Mul(decimal.NewFromFloat(qty)).Mul(decimal.NewFromInt(legMultiplier(leg)))
+		// closing a short leg costs the mid, closing a long leg receives it
+		if credit(leg.Action) {
+			pnl = pnl.Sub(value)
+		} else {
+			pnl = pnl.Add(value)
+		}
+	}
+	return pnl.Round(2), nil
+}
@@ -0,0 +1,90 @@
+package journal
+
+import (
+	"time"
+
+	"github.com/jamesonhm/gochain/internal/dt"
+	"github.com/shopspring/decimal"
+)
+
+type Stats struct {
+	Trades  int             `json:"trades"`
+	Wins    int             `json:"wins"`
+	Losses  int             `json:"losses"`
+	WinRate float64         `json:"win-rate"`
+	NetPnL  decimal.Decimal `json:"net-pnl"`
+	// sum of winning trades and sum of losing trades, losses negative
+	GrossProfit decimal.Decimal `json:"gross-profit"`
+	GrossLoss   decimal.Decimal `json:"gross-loss"`
+	AvgWin      decimal.Decimal `json:"avg-win"`
+	AvgLoss     decimal.Decimal `json:"avg-loss"`
+	// gross profit over gross loss, zero when there are no losing trades
+	ProfitFactor decimal.Decimal `json:"profit-factor"`
+	// average P&L per trade
+	Expectancy decimal.Decimal `json:"expectancy"`
+	Fees       decimal.Decimal `json:"fees"`
+	WorstMAE   decimal.Decimal `json:"worst-mae"`
+	AvgHold    time.Duration   `json:"avg-hold"`
+}
+
+func ComputeStats(trades []Trade) Stats {
+	var st Stats
+	var hold time.Duration
+	for _, t := range trades {
+		st.Trades++
+		st.NetPnL = st.NetPnL.Add(t.RealizedPnL)
+		st.Fees = st.Fees.Add(t.Fees)
+		hold += t.HoldTime
+		if t.MAE.LessThan(st.WorstMAE) {
+			st.WorstMAE = t.MAE
+		}
+		switch {
+		case t.RealizedPnL.IsPositive():
+			st.Wins++
+			st.GrossProfit = st.GrossProfit.Add(t.RealizedPnL)
+		case t.RealizedPnL.IsNegative():
+			st.Losses++
+			st.GrossLoss = st.GrossLoss.Add(t.RealizedPnL)
+		}
+	}
+	if st.Trades == 0 {
+		return st
+	}
+	st.WinRate = float64(st.Wins) / float64(st.Trades)
+	st.Expectancy = st.NetPnL.Div(decimal.NewFromInt(int64(st.Trades))).Round(2)
+	st.AvgHold = hold / time.Duration(st.Trades)
+	if st.Wins > 0 {
+		st.AvgWin = st.GrossProfit.Div(decimal.NewFromInt(int64(st.Wins))).Round(2)
+	}
+	if st.Losses > 0 {
+		st.AvgLoss = st.GrossLoss.Div(decimal.NewFromInt(int64(st.Losses))).Round(2)
+		st.ProfitFactor = st.GrossProfit.Div(st.GrossLoss.Abs()).Round(2)
+	}
+	return st
+}
+
+func ByStrategy(trades []Trade) map[string]Stats {
+	groups := make(map[string][]Trade)
+	for _, t := range trades {
+		groups[t.Strategy] = append(groups[t.Strategy], t)
+	}
+	return statsByGroup(groups)
+}
+
+// ByDay groups trades by the New York date they closed
+func ByDay(trades []Trade) map[string]Stats {
+	groups := make(map[string][]Trade)
+	for _, t := range trades {
+		day := t.ClosedAt.In(dt.TZNY()).Format(time.DateOnly)
+		groups[day] = append(groups[day], t)
+	}
+	return statsByGroup(groups)
+}
+
+func statsByGroup(groups map[string][]Trade) map[string]Stats {
+	stats := make(map[string]Stats, len(groups))
+	for key, trades := range groups {
+		stats[key] = ComputeStats(trades)
+	}
+	return stats
+}
@@ -0,0 +1,230 @@
+package journal
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/jamesonhm/gochain/internal/options"
+	"github.com/jamesonhm/gochain/internal/strategy"
+	"github.com/jamesonhm/gochain/internal/tasty"
+	"github.com/shopspring/decimal"
+)
+
+// Trade is a position from its opening fills to its closing fills or expiration.
+// Cash amounts are in dollars, credits positive and debits negative
+type Trade struct {
+	Strategy   string        `json:"strategy"`
+	PFID       string        `json:"pfid"`
+	Underlying string        `json:"underlying"`
+	Legs       []TradeLeg    `json:"legs"`
+	Quantity   int           `json:"quantity"`
+	OpenedAt   time.Time     `json:"opened-at"`
+	ClosedAt   time.Time     `json:"closed-at"`
+	HoldTime   time.Duration `json:"hold-time"`
+	// net price per unit of the opening and closing orders
+	EntryPrice decimal.Decimal `json:"entry-price"`
+	ExitPrice  decimal.Decimal `json:"exit-price"`
+	EntryCash  decimal.Decimal `json:"entry-cash"`
+	ExitCash   decimal.Decimal `json:"exit-cash"`
+	Fees       decimal.Decimal `json:"fees"`
+	// EntryCash + ExitCash + Fees
+	RealizedPnL decimal.Decimal `json:"realized-pnl"`
+	// worst and best unrealized P&L seen in the marks while the trade was open
+	MAE decimal.Decimal `json:"mae"`
+	MFE decimal.Decimal `json:"mfe"`
+	// closed without a closing order, expired or assigned
+	Expired bool `json:"expired"`
+	// rebuilt from broker transactions rather than the bot's own orders
+	Imported bool               `json:"imported,omitempty"`
+	Config   *strategy.Strategy `json:"strategy-config,omitempty"`
+}
+
+type TradeLeg struct {
+	Symbol     string            `json:"symbol"`
+	Action     tasty.OrderAction `json:"action"`
+	Quantity   float64           `json:"quantity"`
+	EntryPrice decimal.Decimal   `json:"entry-price"`
+	ExitPrice  decimal.Decimal   `json:"exit-price"`
+}
+
+func (t Trade) Win() bool {
+	return t.RealizedPnL.IsPositive()
+}
+
+// Position is one unit of the trade at its entry fills, for the expiration payoff
+// and risk of the trade
+func (t Trade) Position() (options.Position, error) {
+	var pos options.Position
+	for _, leg := range t.Legs {
+		sym, err := options.ParseOCCOption(leg.Symbol)
+		if err != nil {
+			return options.Position{}, err
+		}
+		qty := leg.Quantity
+		if t.Quantity > 0 {
+			qty /= float64(t.Quantity)
+		}
+		if credit(leg.Action) {
+			qty = -qty
+		}
+		pos.Legs = append(pos.Legs, options.Leg{
+			Type:     sym.OptionType,
+			Strike:   sym.Strike,
+			Quantity: qty,
+			Price:    leg.EntryPrice.InexactFloat64(),
+		})
+	}
+	return pos, nil
+}
+
+// BuildTrade builds the trade for a closed wrapped order from the fills of its opening
+// and closing orders. Opening orders that never filled are not trades
+func BuildTrade(stratname string, wo strategy.WrappedOrder, exc Excursion) (Trade, error) {
+	if wo.State != strategy.StateClosed {
+		return Trade{}, fmt.Errorf("order %s/%s is %s, not closed", stratname, wo.PreflightID, wo.State)
+	}
+	units := orderUnits(wo.Order)
+	if units == 0 {
+		return Trade{}, fmt.Errorf("opening order %s/%s never filled", stratname, wo.PreflightID)
+	}
+
+	trade := Trade{
+		Strategy:   stratname,
+		PFID:       wo.PreflightID,
+		Underlying: wo.Order.UnderlyingSymbol,
+		Quantity:   units,
+		OpenedAt:   lastFill(wo.Order, wo.SubmitTime),
+		EntryCash:  orderCash(wo.Order, units),
+		Fees:       wo.TotalFees(),
+		MAE:        exc.MAE,
+		MFE:        exc.MFE,
+		Config:     wo.Config,
+	}
+	exitFills := make(map[string][]tasty.OrderFill)
+	for _, order := range wo.ClosingOrders() {
+		closed := orderUnits(order)
+		if closed == 0 {
+			continue
+		}
+		trade.ExitCash = trade.ExitCash.Add(orderCash(order, closed))
+		if at := lastFill(order, wo.UpdateTime); at.After(trade.ClosedAt) {
+			trade.ClosedAt = at
+		}
+		for _, leg := range order.Legs {
+			exitFills[leg.Symbol] = append(exitFills[leg.Symbol], leg.Fills...)
+		}
+	}
+	for _, leg := range wo.Order.Legs {
+		// whatever the closing orders left open expired or was assigned
+		if wo.OpenQuantity(leg.Symbol) > 0 {
+			trade.Expired = true
+			trade.ClosedAt = wo.UpdateTime
+		}
+		trade.Legs = append(trade.Legs, TradeLeg{
+			Symbol:     leg.Symbol,
+			Action:     leg.Action,
+			Quantity:   strategy.FilledQuantity(wo.Order, leg),
+			EntryPrice: avgFillPrice(leg.Fills),
+			ExitPrice:  avgFillPrice(exitFills[leg.Symbol]),
+		})
+	}
+
+	per := decimal.NewFromInt(int64(units) * multiplier(wo.Order))
+	trade.EntryPrice = trade.EntryCash.Div(per).Round(4)
+	trade.ExitPrice = trade.ExitCash.Div(per).Round(4)
+	trade.RealizedPnL = trade.EntryCash.Add(trade.ExitCash).Add(trade.Fees)
+	if trade.ClosedAt.After(trade.OpenedAt) {
+		trade.HoldTime = trade.ClosedAt.Sub(trade.OpenedAt)
+	}
+	return trade, nil
+}
+
+// orderCash is the signed cash of the order from its fills, falling back to the
+// order price when the broker didn't report fills
+func orderCash(order tasty.Order, units int) decimal.Decimal {
+	cash := decimal.Zero
+	var filled bool
+	for _, leg := range order.Legs {
+		mult := decimal.NewFromInt(legMultiplier(leg))
+		for _, fill := range leg.Fills {
+			filled = true
+			amt := fill.FillPrice.Mul(decimal.NewFromFloat(fill.Quantity)).Mul(mult)
+			if credit(leg.Action) {
+				cash = cash.Add(amt)
+			} else {
+				cash = cash.Sub(amt)
+			}
+		}
+	}
+	if filled {
+		return cash
+	}
+	cash = order.Price.Abs().Mul(decimal.NewFromInt(int64(units) * multiplier(order)))
+	if order.PriceEffect == tasty.Debit {
+		return cash.Neg()
+	}
+	return cash
+}
+
+func credit(action tasty.OrderAction) bool {
+	return action == tasty.STO || action == tasty.STC || action == tasty.Sell
+}
+
+func legMultiplier(leg tasty.OrderLeg) int64 {
+	return instrumentMultiplier(leg.InstrumentType)
+}
+
+func instrumentMultiplier(it tasty.InstrumentType) int64 {
+	switch it {
+	case tasty.EquityIT, tasty.Crypto:
+		return 1
+	}
+	return 100
+}
+
+func multiplier(order tasty.Order) int64 {
+	if len(order.Legs) == 0 {
+		return 100
+	}
+	return legMultiplier(order.Legs[0])
+}
+
+func avgFillPrice(fills []tasty.OrderFill) decimal.Decimal {
+	total, qty := decimal.Zero, decimal.Zero
+	for _, fill := range fills {
+		q := decimal.NewFromFloat(fill.Quantity)
+		total = total.Add(fill.FillPrice.Mul(q))
+		qty = qty.Add(q)
+	}
+	if qty.IsZero() {
+		return decimal.Zero
+	}
+	return total.Div(qty).Round(4)
+}
+
+func lastFill(order tasty.Order, fallback time.Time) time.Time {
+	var last time.Time
+	for _, leg := range order.Legs {
+		for _, fill := range leg.Fills {
+			if fill.FilledAt.After(last) {
+				last = fill.FilledAt
+			}
+		}
+	}
+	if last.IsZero() {
+		if !order.TerminalAt.IsZero() {
+			return order.TerminalAt
+		}
+		return fallback
+	}
+	return last
+}
+
+// orderUnits is the number of spreads filled, see options.Units
+func orderUnits(order tasty.Order) int {
+	var filled []float64
+	for _, leg := range order.Legs {
+		filled = append(filled, strategy.FilledQuantity(order, leg))
+	}
+	return options.Units(filled...)
+}
@@ -2,59 +2,70 @@ package monitor
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"time"
 
+	"github.com/jamesonhm/gochain/internal/accounts"
+	"github.com/jamesonhm/gochain/internal/calendar"
+	"github.com/jamesonhm/gochain/internal/clock"
 	"github.com/jamesonhm/gochain/internal/dt"
 	"github.com/jamesonhm/gochain/internal/dxlink"
 	"github.com/jamesonhm/gochain/internal/executor"
 	"github.com/jamesonhm/gochain/internal/strategy"
-	"github.com/jamesonhm/gochain/internal/tasty"
-	"github.com/jamesonhm/gochain/internal/yahoo"
 )
 
 type Engine struct {
-	portfolio    *tasty.TastyAPI
 	options      *dxlink.DxLinkClient
-	candles      *yahoo.YahooAPI
-	strategies   []strategy.Strategy
-	executor     *executor.Engine
-	stratStates  StatusTracker
+	candles      strategy.CandlesProvider
+	calendar     *calendar.Calendar
+	clock        clock.Clock
+	strategies   []binding
 	scanInterval time.Duration
+	results      chan executor.Result
+	executors    map[*executor.Engine]bool
 }
 
-type StatusTracker interface {
-	LastSubmitted(string) (time.Time, error)
-	OpenTrades(string) int
+// binding ties a strategy to the account it trades in
+type binding struct {
+	strategy strategy.Strategy
+	account  *accounts.Account
 }
 
 func NewEngine(
-	portfolio *tasty.TastyAPI,
 	options *dxlink.DxLinkClient,
-	candles *yahoo.YahooAPI,
-	executor *executor.Engine,
-	stratStates StatusTracker,
+	candles strategy.CandlesProvider,
+	cal *calendar.Calendar,
+	clk clock.Clock,
 	scanInterval time.Duration,
 ) *Engine {
 	return &Engine{
-		portfolio:    portfolio,
 		options:      options,
 		candles:      candles,
-		executor:     executor,
-		stratStates:  stratStates,
+		calendar:     cal,
+		clock:        clk,
 		scanInterval: scanInterval,
+		results:      make(chan executor.Result, 64),
+		executors:    make(map[*executor.Engine]bool),
 	}
 }
 
-func (e *Engine) AddStrategy(s strategy.Strategy) {
-	e.strategies = append(e.strategies, s)
+// AddStrategy must be called before Run
+func (e *Engine) AddStrategy(s strategy.Strategy, acct *accounts.Account) {
+	e.strategies = append(e.strategies, binding{strategy: s, account: acct})
+	e.executors[acct.Executor] = true
 }
 
+// Run scans the strategies every scanInterval of wall time, each scan reads the time from the clock
 func (e *Engine) Run(ctx context.Context) {
 	ticker := time.NewTicker(e.scanInterval)
 	defer ticker.Stop()
 
+	for exec := range e.executors {
+		go e.forwardResults(ctx, exec)
+	}
+
 	fmt.Printf("-------Monitor Started------\n")
 	for {
 		select {
@@ -62,29 +73,68 @@ func (e *Engine) Run(ctx context.Context) {
 			return
 		case <-ticker.C:
 			e.checkAllStrategies(ctx)
+		case res := <-e.results:
+			e.handleResult(ctx, res)
+		}
+	}
+}
+
+// forwardResults merges the results of each account executor into the monitor loop
+func (e *Engine) forwardResults(ctx context.Context, exec *executor.Engine) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case res := <-exec.Results():
+			select {
+			case e.results <- res:
+			case <-ctx.Done():
+				return
+			}
 		}
 	}
 }
 
 func (e *Engine) checkAllStrategies(ctx context.Context) {
-	for _, s := range e.strategies {
-		// is "now" within the entry window
-		if !s.TimeInEntry(time.Now().In(dt.TZNY())) {
+	now := e.clock.Now().In(dt.TZNY())
+	session, ok := e.calendar.Session(now)
+	if !ok || !session.Contains(now) {
+		slog.LogAttrs(ctx, slog.LevelDebug, "(checkAllStrategies) market closed", slog.Time("now", now))
+		return
+	}
+	for _, b := range e.strategies {
+		s := b.strategy
+		// a failing account only stops its own strategies
+		if err := b.account.Healthy(); err != nil {
+			slog.LogAttrs(
+				ctx,
+				slog.LevelDebug,
+				"(checkAllStrategies) account unavailable",
+				slog.String("Strategy", s.Name),
+				slog.String("account", b.account.String()),
+				slog.String("error", err.Error()),
+			)
+			continue
+		}
+		// is "now" within the entry window, cut short by an early close
+		if !s.InEntryWindow(now, session) {
 			slog.LogAttrs(
 				ctx,
 				slog.LevelDebug,
 				"(checkAllStrategies) now not within entry time",
 				slog.String("Strategy", s.Name),
-				slog.Time("now", time.Now().In(dt.TZNY())),
+				slog.Time("now", now),
 				slog.String("min time", s.EntryTime.MinTime),
 				slog.String("max time", s.EntryTime.MaxTime),
+				slog.Time("close", session.Close),
 			)
+			e.cancelWorkingEntries(ctx, b)
 			continue
 		}
 		slog.Info("(checkAllStrategies) now within entry time")
 		// is the last submit time within the entry window
-		if subTime, err := e.stratStates.LastSubmitted(s.Name); err == nil {
-			if s.TimeInEntry(subTime) {
+		if subTime, err := b.account.Status.LastSubmitted(s.Name); err == nil {
+			if s.TimeInEntry(now, subTime) {
 				slog.LogAttrs(
 					ctx,
 					slog.LevelInfo,
@@ -96,14 +146,60 @@ func (e *Engine) checkAllStrategies(ctx context.Context) {
 			}
 		}
 		slog.Info("(checkAllStrategies) last submitted not within entry time")
-		if s.CheckEntryConditions(e.portfolio, e.candles, e.options, e.stratStates) {
+		if s.CheckEntryConditions(e.options, e.candles, b.account.Balances, b.account.Status) {
 			slog.LogAttrs(
 				ctx,
 				slog.LevelInfo,
 				"(checkAllStrategies) Entry Conditions met",
 				slog.String("Strategy", s.Name),
+				slog.String("account", b.account.String()),
+			)
+			if err := b.account.Executor.SubmitOrder(s, s.EntryWindowKey(now)); err != nil {
+				slog.Info("(checkAllStrategies) order not queued", "strategy", s.Name, "account", b.account.String(), "reason", err)
+			}
+		}
+	}
+}
+
+// cancelWorkingEntries cancels the entry orders still working at the broker outside the entry window
+func (e *Engine) cancelWorkingEntries(ctx context.Context, b binding) {
+	for _, wo := range b.account.Status.WorkingOrders(b.strategy.Name) {
+		err := b.account.Executor.SubmitCancel(b.strategy, wo.PreflightID, wo.Order.ID)
+		if errors.Is(err, executor.ErrDuplicateJob) {
+			continue
+		}
+		if err != nil {
+			slog.LogAttrs(ctx, slog.LevelError, "(cancelWorkingEntries) cancel not queued",
+				slog.String("Strategy", b.strategy.Name),
+				slog.String("pfid", wo.PreflightID),
+				slog.String("error", err.Error()),
 			)
-			e.executor.SubmitOrder(s)
+			continue
 		}
+		slog.LogAttrs(ctx, slog.LevelInfo, "(cancelWorkingEntries) entry window over, cancelling working order",
+			slog.String("Strategy", b.strategy.Name),
+			slog.String("pfid", wo.PreflightID),
+			slog.Int("order id", wo.Order.ID),
+		)
+	}
+}
+
+func (e *Engine) handleResult(ctx context.Context, res executor.Result) {
+	attrs := []slog.Attr{
+		slog.String("kind", res.Job.Kind.String()),
+		slog.String("Strategy", res.Job.Strategy.Name),
+		slog.String("window", res.Job.Window),
+		slog.Duration("elapsed", res.Finished.Sub(res.Job.Queued)),
+	}
+	if res.Err != nil {
+		attrs = append(attrs, slog.String("error", res.Err.Error()))
+		slog.LogAttrs(ctx, slog.LevelError, "(handleResult) job failed", attrs...)
+		return
 	}
+	attrs = append(attrs,
+		slog.Bool("live", res.Live),
+		slog.Int("order id", res.Order.ID),
+		slog.String("status", string(res.Order.Status)),
+	)
+	slog.LogAttrs(ctx, slog.LevelInfo, "(handleResult) job complete", attrs...)
 }
@@ -27,3 +27,12 @@ func TestOCCSymbolParseBuild(t *testing.T) {
 	occAfter := option.OCCString()
 	assert.Equal(t, occ, occAfter)
 }
+
+func TestDxLinkFractionalStrike(t *testing.T) {
+	opt := OptionSymbol{Underlying: "XSP", Date: time.Date(2025, 8, 8, 0, 0, 0, 0, time.UTC), Strike: 662.5, OptionType: PutOption}
+	assert.Equal(t, opt.DxLinkString(), ".XSP250808P662.5")
+	parsed, err := ParseDxLinkOption(opt.DxLinkString())
+	assert.Equal(t, err, nil)
+	assert.Equal(t, *parsed, opt)
+	assert.Equal(t, parsed.OCCString(), "XSP   250808P00662500")
+}
@@ -0,0 +1,236 @@
+package options
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// option contract multiplier used when a position doesn't set one
+const DefaultMultiplier = 100
+
+type Leg struct {
+	Type   OptionType
+	Strike float64
+	// positive long, negative short
+	Quantity float64
+	// premium per share paid or received, always positive
+	Price float64
+	// implied volatility and years to expiration, only needed for greeks
+	Vol   float64
+	Years float64
+}
+
+// Position is a set of legs held to a common expiration
+type Position struct {
+	Legs []Leg
+	// net premium per share for legs priced together instead of by leg Price, credits positive
+	Premium    float64
+	Multiplier float64
+}
+
+type PayoffPoint struct {
+	Price float64
+	PnL   float64
+}
+
+// Units is the number of times the leg ratio fits in the leg quantities, the gcd of
+// the quantities. A 1x2 ratio spread of 2 and 4 contracts is 2 units
+func Units(quantities ...float64) int {
+	units := 0
+	for _, qty := range quantities {
+		a, b := units, int(math.Abs(qty))
+		for b != 0 {
+			a, b = b, a%b
+		}
+		units = a
+	}
+	return units
+}
+
+func (p Position) multiplier() float64 {
+	if p.Multiplier == 0 {
+		return DefaultMultiplier
+	}
+	return p.Multiplier
+}
+
+// PnLAt is the profit or loss in dollars with the underlying at price on expiration
+func (p Position) PnLAt(price float64) float64 {
+	pnl := p.Premium
+	for _, leg := range p.Legs {
+		var intrinsic float64
+		if leg.Type == CallOption {
+			intrinsic = math.Max(price-leg.Strike, 0)
+		} else {
+			intrinsic = math.Max(leg.Strike-price, 0)
+		}
+		pnl += leg.Quantity * (intrinsic - leg.Price)
+	}
+	return pnl * p.multiplier()
+}
+
+// Payoff is the expiration P&L at steps+1 evenly spaced prices from low to high
+func (p Position) Payoff(low, high float64, steps int) []PayoffPoint {
+	if steps < 1 {
+		steps = 1
+	}
+	points := make([]PayoffPoint, 0, steps+1)
+	for i := 0; i <= steps; i++ {
+		price := low + (high-low)*float64(i)/float64(steps)
+		points = append(points, PayoffPoint{Price: price, PnL: p.PnLAt(price)})
+	}
+	return points
+}
+
+// strikes are the kinks of the payoff, sorted and unique
+func (p Position) strikes() []float64 {
+	var strikes []float64
+	for _, leg := range p.Legs {
+		strikes = append(strikes, leg.Strike)
+	}
+	sort.Float64s(strikes)
+	unique := strikes[:0]
+	for i, s := range strikes {
+		if i == 0 || s != strikes[i-1] {
+			unique = append(unique, s)
+		}
+	}
+	return unique
+}
+
+// slopeAbove is the change in P&L per point above the highest strike
+func (p Position) slopeAbove() float64 {
+	var calls float64
+	for _, leg := range p.Legs {
+		if leg.Type == CallOption {
+			calls += leg.Quantity
+		}
+	}
+	return calls * p.multiplier()
+}
+
+// MaxProfit is the best expiration P&L, +Inf when the upside is unlimited
+func (p Position) MaxProfit() float64 {
+	if p.slopeAbove() > 0 {
+		return math.Inf(1)
+	}
+	best := p.PnLAt(0)
+	for _, s := range p.strikes() {
+		best = math.Max(best, p.PnLAt(s))
+	}
+	return best
+}
+
+// MaxLoss is the worst expiration P&L, negative for a loss and -Inf for undefined risk
+func (p Position) MaxLoss() float64 {
+	if p.slopeAbove() < 0 {
+		return math.Inf(-1)
+	}
+	worst := p.PnLAt(0)
+	for _, s := range p.strikes() {
+		worst = math.Min(worst, p.PnLAt(s))
+	}
+	return worst
+}
+
+// DefinedRisk is true when the loss is capped at every underlying price
+func (p Position) DefinedRisk() bool {
+	return !math.IsInf(p.MaxLoss(), -1)
+}
+
+// Breakevens are the underlying prices where the expiration P&L crosses zero, ascending
+func (p Position) Breakevens() []float64 {
+	var out []float64
+	add := func(x float64) {
+		if len(out) == 0 || math.Abs(out[len(out)-1]-x) > 1e-9 {
+			out = append(out, x)
+		}
+	}
+	prev, prevPnL := 0.0, p.PnLAt(0)
+	for _, s := range p.strikes() {
+		pnl := p.PnLAt(s)
+		switch {
+		case prevPnL == 0 && pnl == 0:
+			// flat at breakeven, not a crossing
+		case pnl == 0:
+			add(s)
+		case prevPnL*pnl < 0:
+			add(prev + (s-prev)*prevPnL/(prevPnL-pnl))
+		}
+		prev, prevPnL = s, pnl
+	}
+	slope := p.slopeAbove()
+	if slope != 0 && prevPnL*slope < 0 {
+		add(prev - prevPnL/slope)
+	}
+	return out
+}
+
+// probAbove is the chance the underlying ends above x with a lognormal distribution
+func probAbove(spot, x, vol, years, rate, div float64) float64 {
+	if x <= 0 {
+		return 1
+	}
+	if math.IsInf(x, 1) {
+		return 0
+	}
+	d2 := (math.Log(spot/x) + (rate-div-vol*vol/2)*years) / (vol * math.Sqrt(years))
+	return normCDF(d2)
+}
+
+// ProbabilityOfProfit is the chance of a positive expiration P&L with the underlying
+// lognormally distributed at the implied volatility
+func (p Position) ProbabilityOfProfit(spot, vol, years, rate, div float64) (float64, error) {
+	if spot <= 0 || vol <= 0 || years <= 0 {
+		return 0, fmt.Errorf("spot, vol and years must be positive, got %.4f, %.4f and %.4f", spot, vol, years)
+	}
+	bounds := append([]float64{0}, p.Breakevens()...)
+	bounds = append(bounds, math.Inf(1))
+	var pop float64
+	for i := 1; i < len(bounds); i++ {
+		lo, hi := bounds[i-1], bounds[i]
+		mid := (lo + hi) / 2
+		if math.IsInf(hi, 1) {
+			mid = math.Max(lo, spot) * 2
+		}
+		if p.PnLAt(mid) > 0 {
+			pop += probAbove(spot, lo, vol, years, rate, div) - probAbove(spot, hi, vol, years, rate, div)
+		}
+	}
+	return pop, nil
+}
+
+// Greeks sums the leg greeks in dollars for the whole position, each leg is priced
+// with Black-Scholes at its own Vol and Years. Price is the model value of the position
+func (p Position) Greeks(spot, rate, div float64) (Greeks, error) {
+	var total Greeks
+	mult := p.multiplier()
+	for _, leg := range p.Legs {
+		g, err := Price(PricingInputs{
+			Type:   leg.Type,
+			Spot:   spot,
+			Strike: leg.Strike,
+			Years:  leg.Years,
+			Rate:   rate,
+			Div:    div,
+			Vol:    leg.Vol,
+		})
+		if err != nil {
+			return Greeks{}, fmt.Errorf("leg %s %.2f: %w", leg.Type, leg.Strike, err)
+		}
+		q := leg.Quantity * mult
+		total.Price += q * g.Price
+		total.Delta += q * g.Delta
+		total.Gamma += q * g.Gamma
+		total.Theta += q * g.Theta
+		total.Vega += q * g.Vega
+		total.Rho += q * g.Rho
+		total.Epsilon += q * g.Epsilon
+		total.Vanna += q * g.Vanna
+		total.Charm += q * g.Charm
+		total.Vomma += q * g.Vomma
+		total.Veta += q * g.Veta
+	}
+	return total, nil
+}
@@ -0,0 +1,117 @@
+package options
+
+import (
+	"math"
+	"testing"
+
+	"github.com/go-playground/assert/v2"
+)
+
+func TestPositionRisk(t *testing.T) {
+	tests := []struct {
+		name       string
+		pos        Position
+		maxProfit  float64
+		maxLoss    float64
+		breakevens []float64
+	}{
+		{
+			name: "put credit spread",
+			pos: Position{Legs: []Leg{
+				{Type: PutOption, Strike: 600, Quantity: -1, Price: 2.10},
+				{Type: PutOption, Strike: 595, Quantity: 1, Price: 1.10},
+			}},
+			maxProfit:  100,
+			maxLoss:    -400,
+			breakevens: []float64{599},
+		},
+		{
+			name: "iron condor priced as a whole",
+			pos: Position{Premium: 1.5, Legs: []Leg{
+				{Type: PutOption, Strike: 590, Quantity: 1},
+				{Type: PutOption, Strike: 600, Quantity: -1},
+				{Type: CallOption, Strike: 620, Quantity: -1},
+				{Type: CallOption, Strike: 630, Quantity: 1},
+			}},
+			maxProfit:  150,
+			maxLoss:    -850,
+			breakevens: []float64{598.5, 621.5},
+		},
+		{
+			name:       "naked call",
+			pos:        Position{Legs: []Leg{{Type: CallOption, Strike: 620, Quantity: -2, Price: 3}}},
+			maxProfit:  600,
+			maxLoss:    math.Inf(-1),
+			breakevens: []float64{623},
+		},
+		{
+			name: "long straddle",
+			pos: Position{Legs: []Leg{
+				{Type: CallOption, Strike: 600, Quantity: 1, Price: 8},
+				{Type: PutOption, Strike: 600, Quantity: 1, Price: 7},
+			}},
+			maxProfit:  math.Inf(1),
+			maxLoss:    -1500,
+			breakevens: []float64{585, 615},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, math.Round(tt.pos.MaxProfit()*100)/100, tt.maxProfit)
+			assert.Equal(t, math.Round(tt.pos.MaxLoss()*100)/100, tt.maxLoss)
+			assert.Equal(t, tt.pos.DefinedRisk(), !math.IsInf(tt.maxLoss, -1))
+			breakevens := tt.pos.Breakevens()
+			assert.Equal(t, len(breakevens), len(tt.breakevens))
+			for i, be := range breakevens {
+				assert.Equal(t, near(be, tt.breakevens[i], 1e-9), true)
+			}
+		})
+	}
+}
+
+func TestPayoffCurve(t *testing.T) {
+	pos := Position{Legs: []Leg{
+		{Type: PutOption, Strike: 600, Quantity: -1, Price: 2.10},
+		{Type: PutOption, Strike: 595, Quantity: 1, Price: 1.10},
+	}}
+	points := pos.Payoff(590, 610, 4)
+	assert.Equal(t, len(points), 5)
+	assert.Equal(t, points[0], PayoffPoint{Price: 590, PnL: -400})
+	assert.Equal(t, math.Round(points[2].PnL), 100.0)
+}
+
+func TestProbabilityOfProfit(t *testing.T) {
+	in := PricingInputs{Spot: 600, Years: Years(30), Rate: 0.04, Vol: 0.2}
+	short, long := in, in
+	short.Type, short.Strike = PutOption, 580
+	long.Type, long.Strike = PutOption, 570
+	gs, _ := Price(short)
+	gl, _ := Price(long)
+	pos := Position{Legs: []Leg{
+		{Type: PutOption, Strike: 580, Quantity: -1, Price: gs.Price},
+		{Type: PutOption, Strike: 570, Quantity: 1, Price: gl.Price},
+	}}
+	pop, err := pos.ProbabilityOfProfit(in.Spot, in.Vol, in.Years, in.Rate, 0)
+	assert.Equal(t, err, nil)
+	be := pos.Breakevens()[0]
+	assert.Equal(t, near(pop, probAbove(in.Spot, be, in.Vol, in.Years, in.Rate, 0), 1e-12), true)
+	// above the short strike's chance of expiring out of the money
+	assert.Equal(t, pop > 1-math.Abs(gs.Delta), true)
+
+	_, err = pos.ProbabilityOfProfit(600, 0, in.Years, in.Rate, 0)
+	assert.NotEqual(t, err, nil)
+}
+
+func TestPositionGreeks(t *testing.T) {
+	pos := Position{Legs: []Leg{
+		{Type: CallOption, Strike: 600, Quantity: 1, Vol: 0.2, Years: 0.1},
+		{Type: CallOption, Strike: 600, Quantity: -1, Vol: 0.2, Years: 0.1},
+		{Type: PutOption, Strike: 590, Quantity: -2, Vol: 0.22, Years: 0.1},
+	}}
+	g, err := pos.Greeks(600, 0.04, 0)
+	assert.Equal(t, err, nil)
+	leg, _ := Price(PricingInputs{Type: PutOption, Spot: 600, Strike: 590, Years: 0.1, Rate: 0.04, Vol: 0.22})
+	assert.Equal(t, near(g.Delta, -200*leg.Delta, 1e-9), true)
+	assert.Equal(t, near(g.Theta, -200*leg.Theta, 1e-9), true)
+	assert.Equal(t, g.Gamma < 0, true)
+}
@@ -0,0 +1,227 @@
+package options
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+type Model int
+
+const (
+	// Black-Scholes-Merton on the spot price with a continuous dividend yield
+	BlackScholes Model = iota
+	// Black-76 on the forward or futures price, dividends are ignored
+	Black76
+)
+
+func (m Model) String() string {
+	switch m {
+	case BlackScholes:
+		return "black-scholes"
+	case Black76:
+		return "black-76"
+	}
+	return "unknown"
+}
+
+var (
+	ErrPriceOutOfBounds = errors.New("price outside the no-arbitrage bounds")
+	ErrNoConvergence    = errors.New("implied volatility did not converge")
+)
+
+// PricingInputs are annualized, rates and volatility as decimals and Years to expiration
+type PricingInputs struct {
+	Model  Model
+	Type   OptionType
+	Spot   float64 // the forward price for Black76
+	Strike float64
+	Years  float64
+	Rate   float64
+	Div    float64
+	Vol    float64
+}
+
+// Greeks of an option, theta and charm are per calendar day, vega, vanna and
+// rho per volatility or rate point (1%)
+type Greeks struct {
+	Price float64
+	Delta float64
+	Gamma float64
+	Theta float64
+	Vega  float64
+	Rho   float64
+	// sensitivity to the dividend yield, per point
+	Epsilon float64
+	// change in delta for a volatility point
+	Vanna float64
+	// change in delta per day
+	Charm float64
+	// change in vega for a volatility point
+	Vomma float64
+	// change in vega per day
+	Veta float64
+}
+
+// Years to expiration as a fraction of a 365 day year
+func Years(days float64) float64 {
+	return days / 365
+}
+
+func normCDF(x float64) float64 {
+	return 0.5 * math.Erfc(-x/math.Sqrt2)
+}
+
+func normPDF(x float64) float64 {
+	return math.Exp(-x*x/2) / math.Sqrt(2*math.Pi)
+}
+
+// carry is the cost of carry b of the generalized model, r-q for BSM and 0 for Black-76
+func (in PricingInputs) carry() float64 {
+	if in.Model == Black76 {
+		return 0
+	}
+	return in.Rate - in.Div
+}
+
+func (in PricingInputs) validate() error {
+	if in.Type != CallOption && in.Type != PutOption {
+		return fmt.Errorf("unknown option type %q", in.Type)
+	}
+	if in.Spot <= 0 || in.Strike <= 0 {
+		return fmt.Errorf("spot and strike must be positive, got %.4f and %.4f", in.Spot, in.Strike)
+	}
+	return nil
+}
+
+// intrinsic value at expiration or with no volatility, the price discounted to today
+func (in PricingInputs) intrinsic() Greeks {
+	b, r, t := in.carry(), in.Rate, math.Max(in.Years, 0)
+	fwd := in.Spot * math.Exp(b*t)
+	df := math.Exp(-r * t)
+	var g Greeks
+	switch {
+	case in.Type == CallOption && fwd > in.Strike:
+		g.Price = df * (fwd - in.Strike)
+		g.Delta = math.Exp((b - r) * t)
+	case in.Type == PutOption && fwd < in.Strike:
+		g.Price = df * (in.Strike - fwd)
+		g.Delta = -math.Exp((b - r) * t)
+	}
+	return g
+}
+
+// Price values the option with the generalized Black-Scholes model, Black-76 and
+// BSM only differ in the cost of carry
+func Price(in PricingInputs) (Greeks, error) {
+	if err := in.validate(); err != nil {
+		return Greeks{}, err
+	}
+	if in.Years <= 0 || in.Vol <= 0 {
+		return in.intrinsic(), nil
+	}
+
+	S, K, T, r, b, v := in.Spot, in.Strike, in.Years, in.Rate, in.carry(), in.Vol
+	sqrtT := math.Sqrt(T)
+	d1 := (math.Log(S/K) + (b+v*v/2)*T) / (v * sqrtT)
+	d2 := d1 - v*sqrtT
+	carryDF := math.Exp((b - r) * T)
+	df := math.Exp(-r * T)
+	nd1 := normPDF(d1)
+
+	var g Greeks
+	// shared by calls and puts
+	g.Gamma = carryDF * nd1 / (S * v * sqrtT)
+	vega := S * carryDF * nd1 * sqrtT
+	g.Vega = vega / 100
+	g.Vanna = -carryDF * nd1 * d2 / v / 100
+	g.Vomma = vega * d1 * d2 / v / 10000
+	g.Veta = -vega * (b - r - b*d1/(v*sqrtT) + (1+d1*d2)/(2*T)) / 100 / 365
+	charmShared := nd1 * (b/(v*sqrtT) - d2/(2*T))
+
+	switch in.Type {
+	case CallOption:
+		g.Price = S*carryDF*normCDF(d1) - K*df*normCDF(d2)
+		g.Delta = carryDF * normCDF(d1)
+		g.Theta = -S*carryDF*nd1*v/(2*sqrtT) - (b-r)*S*carryDF*normCDF(d1) - r*K*df*normCDF(d2)
+		g.Charm = -carryDF * (charmShared + (b-r)*normCDF(d1))
+		if in.Model == Black76 {
+			g.Rho = -T * g.Price
+		} else {
+			g.Rho = K * T * df * normCDF(d2)
+			g.Epsilon = -S * T * carryDF * normCDF(d1)
+		}
+	case PutOption:
+		g.Price = K*df*normCDF(-d2) - S*carryDF*normCDF(-d1)
+		g.Delta = carryDF * (normCDF(d1) - 1)
+		g.Theta = -S*carryDF*nd1*v/(2*sqrtT) + (b-r)*S*carryDF*normCDF(-d1) + r*K*df*normCDF(-d2)
+		g.Charm = -carryDF * (charmShared - (b-r)*normCDF(-d1))
+		if in.Model == Black76 {
+			g.Rho = -T * g.Price
+		} else {
+			g.Rho = -K * T * df * normCDF(-d2)
+			g.Epsilon = S * T * carryDF * normCDF(-d1)
+		}
+	}
+	g.Theta /= 365
+	g.Charm /= 365
+	g.Rho /= 100
+	g.Epsilon /= 100
+	return g, nil
+}
+
+const (
+	minVol = 1e-4
+	maxVol = 5.0
+)
+
+// ImpliedVol solves for the volatility that prices the option at price, in.Vol is
+// the starting guess when set. Newton steps fall back to bisection when vega is too
+// small or a step leaves the bracket
+func ImpliedVol(in PricingInputs, price float64) (float64, error) {
+	if err := in.validate(); err != nil {
+		return 0, err
+	}
+	if in.Years <= 0 {
+		return 0, fmt.Errorf("%w: option is expired", ErrPriceOutOfBounds)
+	}
+	lower := in.intrinsic().Price
+	upper := in.Spot * math.Exp((in.carry()-in.Rate)*in.Years)
+	if in.Type == PutOption {
+		upper = in.Strike * math.Exp(-in.Rate*in.Years)
+	}
+	if price <= lower || price >= upper {
+		return 0, fmt.Errorf("%w: %.4f not within (%.4f, %.4f)", ErrPriceOutOfBounds, price, lower, upper)
+	}
+
+	lo, hi := minVol, maxVol
+	vol := in.Vol
+	if vol <= lo || vol >= hi {
+		vol = 0.3
+	}
+	const tolerance = 1e-8
+	for range 100 {
+		in.Vol = vol
+		g, _ := Price(in)
+		diff := g.Price - price
+		if math.Abs(diff) < tolerance {
+			return vol, nil
+		}
+		// price increases with vol
+		if diff > 0 {
+			hi = vol
+		} else {
+			lo = vol
+		}
+		vega := g.Vega * 100
+		next := vol - diff/vega
+		if vega < 1e-10 || next <= lo || next >= hi {
+			next = (lo + hi) / 2
+		}
+		if hi-lo < tolerance {
+			return next, nil
+		}
+		vol = next
+	}
+	return 0, ErrNoConvergence
+}
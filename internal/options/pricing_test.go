@@ -0,0 +1,126 @@
+package options
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/go-playground/assert/v2"
+)
+
+func near(a, b, tol float64) bool {
+	return math.Abs(a-b) <= tol
+}
+
+func TestPriceKnownValues(t *testing.T) {
+	// Hull, S=42 K=40 r=10% vol=20% T=0.5
+	in := PricingInputs{Type: CallOption, Spot: 42, Strike: 40, Years: 0.5, Rate: 0.1, Vol: 0.2}
+	call, err := Price(in)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, near(call.Price, 4.7594, 1e-4), true)
+	in.Type = PutOption
+	put, _ := Price(in)
+	assert.Equal(t, near(put.Price, 0.8086, 1e-4), true)
+
+	// Haug, Black-76 F=19 K=19 r=10% vol=28% T=0.75
+	b76 := PricingInputs{Model: Black76, Type: CallOption, Spot: 19, Strike: 19, Years: 0.75, Rate: 0.1, Vol: 0.28}
+	g, _ := Price(b76)
+	assert.Equal(t, near(g.Price, 1.7011, 1e-4), true)
+
+	_, err = Price(PricingInputs{Type: "X", Spot: 1, Strike: 1})
+	assert.NotEqual(t, err, nil)
+}
+
+func TestPutCallParity(t *testing.T) {
+	in := PricingInputs{Type: CallOption, Spot: 5600, Strike: 5650, Years: Years(30), Rate: 0.045, Div: 0.013, Vol: 0.16}
+	call, _ := Price(in)
+	in.Type = PutOption
+	put, _ := Price(in)
+	parity := in.Spot*math.Exp(-in.Div*in.Years) - in.Strike*math.Exp(-in.Rate*in.Years)
+	assert.Equal(t, near(call.Price-put.Price, parity, 1e-8), true)
+	assert.Equal(t, near(call.Delta-put.Delta, math.Exp(-in.Div*in.Years), 1e-12), true)
+	assert.Equal(t, call.Gamma, put.Gamma)
+	assert.Equal(t, call.Vega, put.Vega)
+}
+
+// every greek matches a central difference of the model
+func TestGreeksMatchFiniteDifferences(t *testing.T) {
+	for _, model := range []Model{BlackScholes, Black76} {
+		for _, optType := range []OptionType{CallOption, PutOption} {
+			base := PricingInputs{Model: model, Type: optType, Spot: 100, Strike: 105, Years: 0.4, Rate: 0.05, Div: 0.02, Vol: 0.25}
+			g, _ := Price(base)
+			bump := func(f func(*PricingInputs, float64), h float64, out func(Greeks) float64) float64 {
+				up, down := base, base
+				f(&up, h)
+				f(&down, -h)
+				gu, _ := Price(up)
+				gd, _ := Price(down)
+				return (out(gu) - out(gd)) / (2 * h)
+			}
+			spot := func(in *PricingInputs, h float64) { in.Spot += h }
+			vol := func(in *PricingInputs, h float64) { in.Vol += h }
+			rate := func(in *PricingInputs, h float64) { in.Rate += h }
+			div := func(in *PricingInputs, h float64) { in.Div += h }
+			// time passing shortens the expiration
+			day := func(in *PricingInputs, h float64) { in.Years -= h / 365 }
+			price := func(g Greeks) float64 { return g.Price }
+			delta := func(g Greeks) float64 { return g.Delta }
+			vega := func(g Greeks) float64 { return g.Vega }
+
+			checks := []struct {
+				name    string
+				got, fd float64
+			}{
+				{"delta", g.Delta, bump(spot, 0.01, price)},
+				{"gamma", g.Gamma, bump(spot, 0.01, delta)},
+				{"vega", g.Vega, bump(vol, 1e-4, price) / 100},
+				{"theta", g.Theta, bump(day, 1e-3, price)},
+				{"rho", g.Rho, bump(rate, 1e-5, price) / 100},
+				{"epsilon", g.Epsilon, bump(div, 1e-5, price) / 100},
+				{"vanna", g.Vanna, bump(vol, 1e-4, delta) / 100},
+				{"charm", g.Charm, bump(day, 1e-3, delta)},
+				{"vomma", g.Vomma, bump(vol, 1e-4, vega) / 100},
+				{"veta", g.Veta, bump(day, 1e-3, vega)},
+			}
+			for _, c := range checks {
+				if !near(c.got, c.fd, 1e-6) {
+					t.Errorf("%s %s %s: got %.10f, finite difference %.10f", model, optType, c.name, c.got, c.fd)
+				}
+			}
+		}
+	}
+}
+
+func TestExpiredIsIntrinsic(t *testing.T) {
+	g, err := Price(PricingInputs{Type: PutOption, Spot: 590, Strike: 600, Vol: 0.2})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, g.Price, 10.0)
+	assert.Equal(t, g.Delta, -1.0)
+	g, _ = Price(PricingInputs{Type: CallOption, Spot: 590, Strike: 600, Vol: 0.2})
+	assert.Equal(t, g, Greeks{})
+}
+
+func TestImpliedVolRoundTrip(t *testing.T) {
+	for _, vol := range []float64{0.05, 0.18, 0.6, 2.5} {
+		for _, strike := range []float64{4800, 5600, 6400} {
+			in := PricingInputs{Type: PutOption, Spot: 5600, Strike: strike, Years: Years(45), Rate: 0.045, Div: 0.013, Vol: vol}
+			g, _ := Price(in)
+			if g.Price-in.intrinsic().Price < 0.01 {
+				// no time value left to recover the vol from
+				continue
+			}
+			in.Vol = 0
+			iv, err := ImpliedVol(in, g.Price)
+			assert.Equal(t, err, nil)
+			if !near(iv, vol, 1e-6) {
+				t.Errorf("strike %.0f: implied %.8f, want %.8f", strike, iv, vol)
+			}
+		}
+	}
+
+	in := PricingInputs{Type: CallOption, Spot: 100, Strike: 90, Years: 0.1, Rate: 0.05}
+	_, err := ImpliedVol(in, 5)
+	assert.Equal(t, errors.Is(err, ErrPriceOutOfBounds), true)
+	_, err = ImpliedVol(in, 100)
+	assert.Equal(t, errors.Is(err, ErrPriceOutOfBounds), true)
+}
@@ -0,0 +1,267 @@
+package options
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+type SmilePoint struct {
+	Strike float64
+	IV     float64
+}
+
+// Smile is the implied volatility across strikes of one expiration. Points are
+// interpolated linearly in total variance against log-moneyness, with flat
+// volatility beyond the outermost strikes
+type Smile struct {
+	Expiration time.Time
+	Years      float64
+	Forward    float64
+	surface    *Surface
+	points     []SmilePoint
+	// log-moneyness and total variance of each point
+	k []float64
+	w []float64
+}
+
+// Surface holds the smiles of one underlying sorted by expiration
+type Surface struct {
+	Spot   float64
+	Rate   float64
+	Div    float64
+	smiles []*Smile
+}
+
+type TermPoint struct {
+	Expiration time.Time
+	Years      float64
+	IV         float64
+}
+
+func NewSurface(spot, rate, div float64) *Surface {
+	return &Surface{Spot: spot, Rate: rate, Div: div}
+}
+
+func (s *Surface) forward(years float64) float64 {
+	return s.Spot * math.Exp((s.Rate-s.Div)*years)
+}
+
+// filterOutliers drops points far from the line through their neighbours, quotes with
+// stale or one sided markets show up as spikes in the smile. Each point is compared to
+// the median prediction of the lines through every pair of its neighbours, so a single
+// spike doesn't pull the estimate of the points next to it
+func filterOutliers(points []SmilePoint) []SmilePoint {
+	const window = 3
+	if len(points) < 2*window+1 {
+		return points
+	}
+	residuals := make([]float64, len(points))
+	for i, p := range points {
+		lo, hi := max(i-window, 0), min(i+window+1, len(points))
+		var predictions []float64
+		for j := lo; j < hi; j++ {
+			for l := j + 1; l < hi; l++ {
+				if j == i || l == i || points[l].Strike == points[j].Strike {
+					continue
+				}
+				slope := (points[l].IV - points[j].IV) / (points[l].Strike - points[j].Strike)
+				predictions = append(predictions, points[j].IV+slope*(p.Strike-points[j].Strike))
+			}
+		}
+		residuals[i] = p.IV - median(predictions)
+	}
+	abs := make([]float64, len(residuals))
+	for i, r := range residuals {
+		abs[i] = math.Abs(r)
+	}
+	// scaled median absolute deviation, floored so a smooth smile keeps every point
+	limit := math.Max(3*1.4826*median(abs), 0.02)
+	var kept []SmilePoint
+	for i, p := range points {
+		if math.Abs(residuals[i]) <= limit {
+			kept = append(kept, p)
+		}
+	}
+	return kept
+}
+
+func median(xs []float64) float64 {
+	if len(xs) == 0 {
+		return 0
+	}
+	s := append([]float64(nil), xs...)
+	sort.Float64s(s)
+	if len(s)%2 == 1 {
+		return s[len(s)/2]
+	}
+	return (s[len(s)/2-1] + s[len(s)/2]) / 2
+}
+
+// AddSmile fits the expiration from its quoted IVs, at least two valid points must
+// remain after outliers are removed
+func (s *Surface) AddSmile(exp time.Time, years float64, points []SmilePoint) error {
+	if years <= 0 {
+		return fmt.Errorf("smile for %s has expired", exp.Format("2006-01-02"))
+	}
+	var valid []SmilePoint
+	for _, p := range points {
+		if p.Strike > 0 && p.IV > 0 && p.IV < maxVol && !math.IsNaN(p.IV) {
+			valid = append(valid, p)
+		}
+	}
+	sort.Slice(valid, func(i, j int) bool { return valid[i].Strike < valid[j].Strike })
+	valid = filterOutliers(valid)
+	if len(valid) < 2 {
+		return fmt.Errorf("smile for %s has %d valid points, need 2", exp.Format("2006-01-02"), len(valid))
+	}
+
+	smile := &Smile{Expiration: exp, Years: years, Forward: s.forward(years), surface: s, points: valid}
+	for _, p := range valid {
+		smile.k = append(smile.k, math.Log(p.Strike/smile.Forward))
+		smile.w = append(smile.w, p.IV*p.IV*years)
+	}
+	i := sort.Search(len(s.smiles), func(i int) bool { return s.smiles[i].Years >= years })
+	if i < len(s.smiles) && s.smiles[i].Years == years {
+		s.smiles[i] = smile
+		return nil
+	}
+	s.smiles = append(s.smiles, nil)
+	copy(s.smiles[i+1:], s.smiles[i:])
+	s.smiles[i] = smile
+	return nil
+}
+
+func (s *Surface) Smiles() []*Smile {
+	return s.smiles
+}
+
+// SmileNear is the smile with the expiration closest to years
+func (s *Surface) SmileNear(years float64) (*Smile, error) {
+	if len(s.smiles) == 0 {
+		return nil, fmt.Errorf("surface has no smiles")
+	}
+	best := s.smiles[0]
+	for _, smile := range s.smiles[1:] {
+		if math.Abs(smile.Years-years) < math.Abs(best.Years-years) {
+			best = smile
+		}
+	}
+	return best, nil
+}
+
+// Points are the quotes kept in the fit
+func (sm *Smile) Points() []SmilePoint {
+	return sm.points
+}
+
+// totalVariance at log-moneyness k
+func (sm *Smile) totalVariance(k float64) float64 {
+	n := len(sm.k)
+	switch {
+	case k <= sm.k[0]:
+		return sm.w[0]
+	case k >= sm.k[n-1]:
+		return sm.w[n-1]
+	}
+	i := sort.SearchFloat64s(sm.k, k)
+	t := (k - sm.k[i-1]) / (sm.k[i] - sm.k[i-1])
+	return sm.w[i-1] + t*(sm.w[i]-sm.w[i-1])
+}
+
+func (sm *Smile) IV(strike float64) float64 {
+	return math.Sqrt(sm.totalVariance(math.Log(strike/sm.Forward)) / sm.Years)
+}
+
+// ATM is the IV at the forward
+func (sm *Smile) ATM() float64 {
+	return sm.IV(sm.Forward)
+}
+
+// DeltaStrike is the strike whose delta at its own smile IV equals delta, calls take
+// a positive and puts a negative delta
+func (sm *Smile) DeltaStrike(optType OptionType, delta float64) (float64, error) {
+	s := sm.surface
+	deltaAt := func(strike float64) float64 {
+		g, _ := Price(PricingInputs{Type: optType, Spot: s.Spot, Strike: strike, Years: sm.Years, Rate: s.Rate, Div: s.Div, Vol: sm.IV(strike)})
+		return g.Delta
+	}
+	// delta falls as the strike rises for calls and puts alike
+	lo, hi := sm.Forward*0.01, sm.Forward*5
+	if delta >= deltaAt(lo) || delta <= deltaAt(hi) {
+		return 0, fmt.Errorf("no %s strike with delta %.2f", optType, delta)
+	}
+	for range 100 {
+		mid := (lo + hi) / 2
+		if deltaAt(mid) > delta {
+			lo = mid
+		} else {
+			hi = mid
+		}
+		if hi-lo < 1e-6*sm.Forward {
+			break
+		}
+	}
+	return (lo + hi) / 2, nil
+}
+
+// Skew25 is the 25 delta put IV minus the 25 delta call IV
+func (sm *Smile) Skew25() (float64, error) {
+	put, err := sm.DeltaStrike(PutOption, -0.25)
+	if err != nil {
+		return 0, err
+	}
+	call, err := sm.DeltaStrike(CallOption, 0.25)
+	if err != nil {
+		return 0, err
+	}
+	return sm.IV(put) - sm.IV(call), nil
+}
+
+// IV interpolates the surface linearly in total variance between expirations at the
+// same log-moneyness, outside the expirations the nearest smile is used
+func (s *Surface) IV(strike, years float64) (float64, error) {
+	if len(s.smiles) == 0 {
+		return 0, fmt.Errorf("surface has no smiles")
+	}
+	if strike <= 0 || years <= 0 {
+		return 0, fmt.Errorf("strike and years must be positive, got %.4f and %.4f", strike, years)
+	}
+	k := math.Log(strike / s.forward(years))
+	i := sort.Search(len(s.smiles), func(i int) bool { return s.smiles[i].Years >= years })
+	switch {
+	case i == 0:
+		return math.Sqrt(s.smiles[0].totalVariance(k) / s.smiles[0].Years), nil
+	case i == len(s.smiles):
+		last := s.smiles[i-1]
+		return math.Sqrt(last.totalVariance(k) / last.Years), nil
+	}
+	near, far := s.smiles[i-1], s.smiles[i]
+	t := (years - near.Years) / (far.Years - near.Years)
+	w := near.totalVariance(k) + t*(far.totalVariance(k)-near.totalVariance(k))
+	return math.Sqrt(w / years), nil
+}
+
+// ATMIV is the surface IV at the forward for years
+func (s *Surface) ATMIV(years float64) (float64, error) {
+	return s.IV(s.forward(years), years)
+}
+
+// TermStructure is the ATM IV of each expiration
+func (s *Surface) TermStructure() []TermPoint {
+	points := make([]TermPoint, 0, len(s.smiles))
+	for _, sm := range s.smiles {
+		points = append(points, TermPoint{Expiration: sm.Expiration, Years: sm.Years, IV: sm.ATM()})
+	}
+	return points
+}
+
+// Price values an option at the surface IV, for strikes and expirations without quotes
+func (s *Surface) Price(optType OptionType, strike, years float64) (Greeks, error) {
+	vol, err := s.IV(strike, years)
+	if err != nil {
+		return Greeks{}, err
+	}
+	return Price(PricingInputs{Type: optType, Spot: s.Spot, Strike: strike, Years: years, Rate: s.Rate, Div: s.Div, Vol: vol})
+}
@@ -0,0 +1,82 @@
+package options
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/go-playground/assert/v2"
+)
+
+// a put skewed smile, the IV rises as strikes fall
+func skewedPoints(fwd, atm float64) []SmilePoint {
+	var points []SmilePoint
+	for strike := fwd * 0.8; strike <= fwd*1.2; strike += fwd * 0.02 {
+		k := math.Log(strike / fwd)
+		points = append(points, SmilePoint{Strike: strike, IV: atm - 0.3*k + 0.8*k*k})
+	}
+	return points
+}
+
+func TestSmileFit(t *testing.T) {
+	s := NewSurface(600, 0.04, 0)
+	exp := time.Date(2025, 9, 19, 0, 0, 0, 0, time.UTC)
+	years := Years(30)
+	fwd := s.forward(years)
+	points := skewedPoints(fwd, 0.18)
+	// a stale quote spikes the smile
+	spike := points[5]
+	points[5].IV += 0.4
+	assert.Equal(t, s.AddSmile(exp, years, points), nil)
+
+	smile, _ := s.SmileNear(years)
+	assert.Equal(t, len(smile.Points()), len(points)-1)
+	assert.Equal(t, near(smile.IV(points[3].Strike), points[3].IV, 1e-12), true)
+	// the dropped point is interpolated from its neighbours
+	assert.Equal(t, near(smile.IV(spike.Strike), spike.IV, 0.002), true)
+	assert.Equal(t, near(smile.ATM(), 0.18, 0.001), true)
+	// flat beyond the quoted strikes
+	assert.Equal(t, smile.IV(fwd*0.5), smile.IV(points[0].Strike))
+
+	put, err := smile.DeltaStrike(PutOption, -0.25)
+	assert.Equal(t, err, nil)
+	g, _ := Price(PricingInputs{Type: PutOption, Spot: 600, Strike: put, Years: years, Rate: 0.04, Vol: smile.IV(put)})
+	assert.Equal(t, near(g.Delta, -0.25, 1e-4), true)
+	skew, err := smile.Skew25()
+	assert.Equal(t, err, nil)
+	assert.Equal(t, skew > 0.02, true)
+
+	assert.NotEqual(t, s.AddSmile(exp, years, points[:1]), nil)
+	assert.NotEqual(t, s.AddSmile(exp, 0, points), nil)
+}
+
+func TestSurfaceTermStructure(t *testing.T) {
+	s := NewSurface(600, 0.04, 0)
+	now := time.Date(2025, 8, 1, 0, 0, 0, 0, time.UTC)
+	// inverted, the front month is richer
+	for _, exp := range []struct {
+		days float64
+		atm  float64
+	}{{7, 0.25}, {30, 0.20}, {60, 0.19}} {
+		years := Years(exp.days)
+		assert.Equal(t, s.AddSmile(now.AddDate(0, 0, int(exp.days)), years, skewedPoints(s.forward(years), exp.atm)), nil)
+	}
+	term := s.TermStructure()
+	assert.Equal(t, len(term), 3)
+	assert.Equal(t, term[0].IV > term[1].IV, true)
+
+	// between expirations the total variance is interpolated
+	years := Years(45)
+	iv, err := s.ATMIV(years)
+	assert.Equal(t, err, nil)
+	w30, w60 := 0.2*0.2*Years(30), 0.19*0.19*Years(60)
+	assert.Equal(t, near(iv, math.Sqrt((w30+w60)/2/years), 0.001), true)
+
+	// an unquoted strike is priced off the surface
+	g, err := s.Price(PutOption, 550, years)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, g.Price > 0, true)
+
+	_, err = NewSurface(600, 0, 0).IV(600, 0.1)
+	assert.NotEqual(t, err, nil)
+}
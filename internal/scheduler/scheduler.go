@@ -0,0 +1,171 @@
+package scheduler
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/jamesonhm/gochain/internal/calendar"
+	"github.com/jamesonhm/gochain/internal/clock"
+)
+
+type Phase int
+
+const (
+	Idle Phase = iota
+	PreMarket
+	Open
+	PostClose
+)
+
+func (p Phase) String() string {
+	switch p {
+	case PreMarket:
+		return "pre-market"
+	case Open:
+		return "open"
+	case PostClose:
+		return "post-close"
+	default:
+		return "idle"
+	}
+}
+
+// Hooks are the jobs run in each phase of a trading day
+type Hooks struct {
+	// prepares the day, retried until it succeeds or the session closes
+	PreMarket func(ctx context.Context, session calendar.Session) error
+	// runs for the open session, ctx is cancelled at the close
+	Open func(ctx context.Context, session calendar.Session)
+	// runs once after the close, even when the day's prep failed
+	PostClose func(ctx context.Context, session calendar.Session) error
+}
+
+// Scheduler cycles through the phases of each trading day so one process can run
+// unattended across days. Days the market is closed are spent idle
+type Scheduler struct {
+	calendar *calendar.Calendar
+	clock    clock.Clock
+	hooks    Hooks
+	// pre-market prep starts this long before the open
+	prepLead time.Duration
+	// post-close jobs start this long after the close
+	postDelay time.Duration
+	// wait between failed pre-market attempts
+	retry time.Duration
+	// longest wall clock sleep between checks of the clock
+	poll  time.Duration
+	mu    sync.RWMutex
+	phase Phase
+}
+
+func New(cal *calendar.Calendar, clk clock.Clock, hooks Hooks, prepLead, postDelay time.Duration) *Scheduler {
+	return &Scheduler{
+		calendar:  cal,
+		clock:     clk,
+		hooks:     hooks,
+		prepLead:  prepLead,
+		postDelay: postDelay,
+		retry:     time.Minute,
+		poll:      30 * time.Second,
+	}
+}
+
+func (s *Scheduler) Phase() Phase {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.phase
+}
+
+func (s *Scheduler) setPhase(p Phase, session calendar.Session) {
+	s.mu.Lock()
+	s.phase = p
+	s.mu.Unlock()
+	slog.Info("(scheduler) phase", "phase", p.String(), "session", session.Open.Format(time.DateOnly), "early close", session.Early)
+}
+
+// nextSession is the first session whose post-close jobs haven't started by now
+func (s *Scheduler) nextSession(now time.Time) calendar.Session {
+	day := now
+	for {
+		if session, ok := s.calendar.Session(day); ok && now.Before(session.Close.Add(s.postDelay)) {
+			return session
+		}
+		day = s.calendar.NextTradingDay(day)
+	}
+}
+
+// waitUntil sleeps until the clock reaches t, false when ctx is done first
+func (s *Scheduler) waitUntil(ctx context.Context, t time.Time) bool {
+	for {
+		remaining := t.Sub(s.clock.Now())
+		if remaining <= 0 {
+			return true
+		}
+		timer := time.NewTimer(min(remaining, s.poll))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return false
+		case <-timer.C:
+		}
+	}
+}
+
+// Run cycles through the days until ctx is done. Started mid session, the day's prep
+// runs right away and the open phase picks up from there
+func (s *Scheduler) Run(ctx context.Context) {
+	for ctx.Err() == nil {
+		session := s.nextSession(s.clock.Now())
+		s.setPhase(Idle, session)
+		if !s.waitUntil(ctx, session.Open.Add(-s.prepLead)) {
+			return
+		}
+
+		if s.clock.Now().Before(session.Close) {
+			s.setPhase(PreMarket, session)
+			if s.prepare(ctx, session) {
+				if !s.waitUntil(ctx, session.Open) {
+					return
+				}
+				s.setPhase(Open, session)
+				s.runOpen(ctx, session)
+			}
+		}
+
+		if !s.waitUntil(ctx, session.Close.Add(s.postDelay)) {
+			return
+		}
+		s.setPhase(PostClose, session)
+		if err := s.hooks.PostClose(ctx, session); err != nil {
+			slog.Error("(scheduler) post-close jobs failed", "session", session.Open.Format(time.DateOnly), "error", err)
+		}
+	}
+}
+
+// prepare retries the pre-market hook until it succeeds, false when the session
+// closes or ctx is done first
+func (s *Scheduler) prepare(ctx context.Context, session calendar.Session) bool {
+	for {
+		err := s.hooks.PreMarket(ctx, session)
+		if err == nil {
+			return true
+		}
+		slog.Error("(scheduler) pre-market prep failed", "session", session.Open.Format(time.DateOnly), "error", err)
+		next := s.clock.Now().Add(s.retry)
+		if !next.Before(session.Close) || !s.waitUntil(ctx, next) {
+			return false
+		}
+	}
+}
+
+func (s *Scheduler) runOpen(ctx context.Context, session calendar.Session) {
+	openCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		s.waitUntil(openCtx, session.Close)
+		cancel()
+	}()
+	s.hooks.Open(openCtx, session)
+}
@@ -0,0 +1,95 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-playground/assert/v2"
+	"github.com/jamesonhm/gochain/internal/calendar"
+	"github.com/jamesonhm/gochain/internal/clock"
+	"github.com/jamesonhm/gochain/internal/dt"
+)
+
+type event struct {
+	phase Phase
+	day   string
+	at    time.Time
+}
+
+func TestRunAcrossHoliday(t *testing.T) {
+	// the day before thanksgiving, the friday after is a half day
+	start := time.Date(2025, 11, 26, 8, 0, 0, 0, dt.TZNY())
+	clk := clock.NewSimulated(start, 200000)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var mu sync.Mutex
+	var events []event
+	record := func(p Phase, session calendar.Session) {
+		mu.Lock()
+		defer mu.Unlock()
+		events = append(events, event{p, session.Open.Format(time.DateOnly), clk.Now()})
+	}
+	failed := false
+	s := New(calendar.New(), clk, Hooks{
+		PreMarket: func(ctx context.Context, session calendar.Session) error {
+			if !failed {
+				failed = true
+				return errors.New("login failed")
+			}
+			record(PreMarket, session)
+			return nil
+		},
+		Open: func(ctx context.Context, session calendar.Session) {
+			<-ctx.Done()
+			record(Open, session)
+		},
+		PostClose: func(ctx context.Context, session calendar.Session) error {
+			record(PostClose, session)
+			if session.Early {
+				cancel()
+			}
+			return nil
+		},
+	}, 30*time.Minute, 5*time.Minute)
+	s.poll = time.Millisecond
+
+	done := make(chan struct{})
+	go func() {
+		s.Run(ctx)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("scheduler did not reach the half day")
+	}
+
+	assert.Equal(t, len(events), 6)
+	wantPhases := []Phase{PreMarket, Open, PostClose, PreMarket, Open, PostClose}
+	wantDays := []string{"2025-11-26", "2025-11-26", "2025-11-26", "2025-11-28", "2025-11-28", "2025-11-28"}
+	for i, e := range events {
+		assert.Equal(t, e.phase, wantPhases[i])
+		assert.Equal(t, e.day, wantDays[i])
+	}
+	// the failed prep is retried before the open
+	assert.Equal(t, events[0].at.After(time.Date(2025, 11, 26, 9, 0, 0, 0, dt.TZNY())), true)
+	assert.Equal(t, events[0].at.Before(time.Date(2025, 11, 26, 9, 30, 0, 0, dt.TZNY())), true)
+	// the open phase ends at the close, early on the half day
+	assert.Equal(t, events[1].at.Before(time.Date(2025, 11, 26, 16, 0, 0, 0, dt.TZNY())), false)
+	assert.Equal(t, events[4].at.Before(time.Date(2025, 11, 28, 13, 0, 0, 0, dt.TZNY())), false)
+	assert.Equal(t, events[4].at.Before(time.Date(2025, 11, 28, 13, 5, 0, 0, dt.TZNY())), true)
+}
+
+func TestNextSession(t *testing.T) {
+	s := New(calendar.New(), clock.Real{}, Hooks{}, 30*time.Minute, 5*time.Minute)
+	// after the post-close jobs on a friday the next session is monday
+	session := s.nextSession(time.Date(2025, 7, 11, 16, 10, 0, 0, dt.TZNY()))
+	assert.Equal(t, session.Open, time.Date(2025, 7, 14, 9, 30, 0, 0, dt.TZNY()))
+	// before them the friday session still has its post-close jobs to run
+	session = s.nextSession(time.Date(2025, 7, 11, 16, 2, 0, 0, dt.TZNY()))
+	assert.Equal(t, session.Open, time.Date(2025, 7, 11, 9, 30, 0, 0, dt.TZNY()))
+}
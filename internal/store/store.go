@@ -0,0 +1,258 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	metaBucket       = []byte("_meta")
+	schemaVersionKey = []byte("schema-version")
+
+	ErrNewerSchema = errors.New("store schema is newer than supported")
+)
+
+// Migration moves the store from schema version i to i+1, where i is the
+// migration's index in the list passed to Open
+type Migration func(*Tx) error
+
+// Store is a single file transactional key value store. Values are JSON encoded
+// and grouped in nested buckets addressed by a path of bucket names
+type Store struct {
+	db   *bolt.DB
+	path string
+}
+
+// Open opens or creates the store file and applies any pending migrations, each
+// in its own transaction together with the schema version bump
+func Open(path string, migrations []Migration) (*Store, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: 2 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("unable to open store %s: %w", path, err)
+	}
+	s := &Store{db: db, path: path}
+	if err := s.migrate(migrations); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+func (s *Store) Path() string {
+	return s.path
+}
+
+func (s *Store) SchemaVersion() (int, error) {
+	var version int
+	err := s.View(func(tx *Tx) error {
+		var err error
+		version, err = tx.schemaVersion()
+		return err
+	})
+	return version, err
+}
+
+func (s *Store) migrate(migrations []Migration) error {
+	version, err := s.SchemaVersion()
+	if err != nil {
+		return err
+	}
+	if version > len(migrations) {
+		return fmt.Errorf("%w: %s is at version %d, latest known %d", ErrNewerSchema, s.path, version, len(migrations))
+	}
+	for v := version; v < len(migrations); v++ {
+		err := s.Update(func(tx *Tx) error {
+			if err := migrations[v](tx); err != nil {
+				return err
+			}
+			meta, err := tx.tx.CreateBucketIfNotExists(metaBucket)
+			if err != nil {
+				return err
+			}
+			return meta.Put(schemaVersionKey, []byte(strconv.Itoa(v+1)))
+		})
+		if err != nil {
+			return fmt.Errorf("migration to schema version %d failed: %w", v+1, err)
+		}
+		slog.Info("store migrated", "path", s.path, "version", v+1)
+	}
+	return nil
+}
+
+// Update runs fn in a read-write transaction, committed only if fn returns nil
+func (s *Store) Update(fn func(*Tx) error) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return fn(&Tx{tx: tx})
+	})
+}
+
+func (s *Store) View(fn func(*Tx) error) error {
+	return s.db.View(func(tx *bolt.Tx) error {
+		return fn(&Tx{tx: tx})
+	})
+}
+
+type Tx struct {
+	tx *bolt.Tx
+}
+
+func (t *Tx) schemaVersion() (int, error) {
+	meta := t.tx.Bucket(metaBucket)
+	if meta == nil {
+		return 0, nil
+	}
+	val := meta.Get(schemaVersionKey)
+	if val == nil {
+		return 0, nil
+	}
+	version, err := strconv.Atoi(string(val))
+	if err != nil {
+		return 0, fmt.Errorf("invalid schema version %q: %w", val, err)
+	}
+	return version, nil
+}
+
+// bucket walks the path, returning nil when a bucket is missing and create is false
+func (t *Tx) bucket(path []string, create bool) (*bolt.Bucket, error) {
+	if len(path) == 0 {
+		return nil, fmt.Errorf("empty bucket path")
+	}
+	var b *bolt.Bucket
+	for i, name := range path {
+		var err error
+		switch {
+		case i == 0 && create:
+			b, err = t.tx.CreateBucketIfNotExists([]byte(name))
+		case i == 0:
+			b = t.tx.Bucket([]byte(name))
+		case create:
+			b, err = b.CreateBucketIfNotExists([]byte(name))
+		default:
+			b = b.Bucket([]byte(name))
+		}
+		if err != nil {
+			return nil, fmt.Errorf("bucket %v: %w", path[:i+1], err)
+		}
+		if b == nil {
+			return nil, nil
+		}
+	}
+	return b, nil
+}
+
+func (t *Tx) CreateBucket(path ...string) error {
+	_, err := t.bucket(path, true)
+	return err
+}
+
+// Put stores v as JSON under key, creating the buckets in path as needed
+func (t *Tx) Put(path []string, key string, v any) error {
+	b, err := t.bucket(path, true)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("unable to encode %v/%s: %w", path, key, err)
+	}
+	return b.Put([]byte(key), data)
+}
+
+// Get decodes the value under key into v, reporting false if it doesn't exist
+func (t *Tx) Get(path []string, key string, v any) (bool, error) {
+	b, err := t.bucket(path, false)
+	if err != nil || b == nil {
+		return false, err
+	}
+	data := b.Get([]byte(key))
+	if data == nil {
+		return false, nil
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return true, fmt.Errorf("unable to decode %v/%s: %w", path, key, err)
+	}
+	return true, nil
+}
+
+func (t *Tx) Delete(path []string, key string) error {
+	b, err := t.bucket(path, false)
+	if err != nil || b == nil {
+		return err
+	}
+	return b.Delete([]byte(key))
+}
+
+// ForEach calls fn with the raw JSON of each value in the bucket, nested buckets are skipped
+func (t *Tx) ForEach(path []string, fn func(key string, data []byte) error) error {
+	b, err := t.bucket(path, false)
+	if err != nil || b == nil {
+		return err
+	}
+	return b.ForEach(func(k, v []byte) error {
+		if v == nil {
+			return nil
+		}
+		return fn(string(k), v)
+	})
+}
+
+// Buckets lists the names of the buckets nested in path, or the top level buckets
+// when path is empty
+func (t *Tx) Buckets(path ...string) ([]string, error) {
+	var names []string
+	if len(path) == 0 {
+		err := t.tx.ForEach(func(name []byte, _ *bolt.Bucket) error {
+			if string(name) != string(metaBucket) {
+				names = append(names, string(name))
+			}
+			return nil
+		})
+		return names, err
+	}
+	b, err := t.bucket(path, false)
+	if err != nil || b == nil {
+		return nil, err
+	}
+	err = b.ForEachBucket(func(k []byte) error {
+		names = append(names, string(k))
+		return nil
+	})
+	return names, err
+}
+
+// NextSequence returns the next value of the bucket's persistent counter
+func (t *Tx) NextSequence(path ...string) (int, error) {
+	b, err := t.bucket(path, true)
+	if err != nil {
+		return 0, err
+	}
+	seq, err := b.NextSequence()
+	return int(seq), err
+}
+
+// SetSequence sets the bucket's counter, used when importing existing state
+func (t *Tx) SetSequence(seq int, path ...string) error {
+	b, err := t.bucket(path, true)
+	if err != nil {
+		return err
+	}
+	return b.SetSequence(uint64(seq))
+}
+
+func (t *Tx) Sequence(path ...string) (int, error) {
+	b, err := t.bucket(path, false)
+	if err != nil || b == nil {
+		return 0, err
+	}
+	return int(b.Sequence()), nil
+}
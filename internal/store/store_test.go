@@ -0,0 +1,77 @@
+package store
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-playground/assert/v2"
+)
+
+func TestMigrations(t *testing.T) {
+	fpath := filepath.Join(t.TempDir(), "test.db")
+	var runs int
+	migrations := []Migration{
+		func(tx *Tx) error {
+			runs++
+			return tx.CreateBucket("items")
+		},
+	}
+
+	s, err := Open(fpath, migrations)
+	assert.Equal(t, err, nil)
+	version, _ := s.SchemaVersion()
+	assert.Equal(t, version, 1)
+	s.Close()
+
+	// applied migrations are not run again
+	migrations = append(migrations, func(tx *Tx) error {
+		return tx.Put([]string{"items"}, "a", 1)
+	})
+	s, err = Open(fpath, migrations)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, runs, 1)
+	var val int
+	s.View(func(tx *Tx) error {
+		_, err := tx.Get([]string{"items"}, "a", &val)
+		return err
+	})
+	assert.Equal(t, val, 1)
+	s.Close()
+
+	_, err = Open(fpath, migrations[:1])
+	assert.Equal(t, errors.Is(err, ErrNewerSchema), true)
+}
+
+func TestUpdateRollback(t *testing.T) {
+	s, err := Open(filepath.Join(t.TempDir(), "test.db"), nil)
+	assert.Equal(t, err, nil)
+	defer s.Close()
+
+	err = s.Update(func(tx *Tx) error {
+		if err := tx.Put([]string{"a", "b"}, "key", "value"); err != nil {
+			return err
+		}
+		return errors.New("abort")
+	})
+	assert.NotEqual(t, err, nil)
+
+	var found bool
+	s.View(func(tx *Tx) error {
+		var val string
+		found, err = tx.Get([]string{"a", "b"}, "key", &val)
+		return err
+	})
+	assert.Equal(t, found, false)
+
+	s.Update(func(tx *Tx) error {
+		return tx.Put([]string{"a", "b"}, "key", "value")
+	})
+	s.View(func(tx *Tx) error {
+		names, _ := tx.Buckets("a")
+		assert.Equal(t, names, []string{"b"})
+		top, _ := tx.Buckets()
+		assert.Equal(t, top, []string{"a"})
+		return nil
+	})
+}
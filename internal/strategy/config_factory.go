@@ -2,21 +2,36 @@ package strategy
 
 import (
 	"fmt"
+
+	"github.com/jamesonhm/gochain/internal/calendar"
+	"github.com/jamesonhm/gochain/internal/clock"
 )
 
 type ConditionFactory struct {
 	factories map[string]FactoryFunc
+	clock     clock.Clock
 }
 
-type FactoryFunc func(params map[string]interface{}) (Condition, error)
+// FactoryFunc builds a condition from its config, conditions that depend on the
+// time of day read it from clk
+type FactoryFunc func(params map[string]interface{}, clk clock.Clock) (Condition, error)
 
-func NewConditionFactory() *ConditionFactory {
+// NewConditionFactory registers the built in conditions, expirations are counted in
+// trading days of cal
+func NewConditionFactory(clk clock.Clock, cal *calendar.Calendar) *ConditionFactory {
 	factory := &ConditionFactory{
 		factories: make(map[string]FactoryFunc),
+		clock:     clk,
 	}
 
 	factory.RegisterFactory("day-of-week", createDayOfWeekCondition)
 	factory.RegisterFactory("vix-overnight-move", createVixONMoveCondition)
+	factory.RegisterFactory("iv-skew", createIVSkewCondition)
+	factory.RegisterFactory("term-structure", createTermStructureCondition)
+	factory.RegisterFactory("expected-move-gap", func(params map[string]interface{}, clk clock.Clock) (Condition, error) {
+		return createExpectedMoveGapCondition(params, clk, cal)
+	})
+	factory.RegisterFactory("max-open-trades", createMaxOpenTradesCondition)
 
 	return factory
 }
@@ -34,7 +49,7 @@ func (f *ConditionFactory) FromConfig(raw map[string]map[string]interface{}) (ma
 			return nil, fmt.Errorf("unknown condition type: %s", name)
 		}
 
-		condition, err := factory(params)
+		condition, err := factory(params, f.clock)
 		if err != nil {
 			return nil, fmt.Errorf("failed to create condition %s: %w", name, err)
 		}
@@ -3,8 +3,14 @@ package strategy
 import (
 	"fmt"
 	"log/slog"
+	"math"
 	"time"
 
+	"github.com/jamesonhm/gochain/internal/calendar"
+	"github.com/jamesonhm/gochain/internal/clock"
+	"github.com/jamesonhm/gochain/internal/dt"
+	"github.com/jamesonhm/gochain/internal/options"
+	"github.com/jamesonhm/gochain/internal/symbology"
 	"github.com/shopspring/decimal"
 )
 
@@ -16,7 +22,7 @@ type Condition func(
 ) bool
 
 // Factory functions for each condition type
-func createDayOfWeekCondition(params map[string]interface{}) (Condition, error) {
+func createDayOfWeekCondition(params map[string]interface{}, clk clock.Clock) (Condition, error) {
 	daysInterface, ok := params["days"]
 	if !ok {
 		return nil, fmt.Errorf("missing 'days' parameter")
@@ -58,7 +64,7 @@ func createDayOfWeekCondition(params map[string]interface{}) (Condition, error)
 	}
 
 	return func(_ OptionsProvider, _ CandlesProvider, _ PortfolioProvider, _ StratStatusProvider) bool {
-		today := time.Now().Weekday()
+		today := clk.Now().In(dt.TZNY()).Weekday()
 		for _, day := range weekdays {
 			if day == today {
 				return true
@@ -80,7 +86,7 @@ func strInterToDec(strInter interface{}) (decimal.Decimal, error) {
 	}
 }
 
-func createVixONMoveCondition(params map[string]interface{}) (Condition, error) {
+func createVixONMoveCondition(params map[string]interface{}, clk clock.Clock) (Condition, error) {
 	minInter, minOk := params["min"]
 	maxInter, maxOk := params["max"]
 	if !minOk && !maxOk {
@@ -138,20 +144,28 @@ func createVixONMoveCondition(params map[string]interface{}) (Condition, error)
 	}, nil
 }
 
-func createMaxOpenTradesCondition(params map[string]interface{}) (Condition, error) {
+func createMaxOpenTradesCondition(params map[string]interface{}, clk clock.Clock) (Condition, error) {
 	maxInter, maxOk := params["max"]
 	nameInter, nameOk := params["strategy-name"]
 	if !maxOk || !nameOk {
 		return nil, fmt.Errorf("Max Open Trades Condition requires both `max` and `strategy-name` parameters")
 	}
 
+	// JSON numbers decode as float64
 	var maxParam int
-	var ok bool
-	if maxParam, ok = maxInter.(int); !ok {
+	switch v := maxInter.(type) {
+	case int:
+		maxParam = v
+	case float64:
+		if v != math.Trunc(v) {
+			return nil, fmt.Errorf("Max Open Trades unable to get integer from max param: %v", maxInter)
+		}
+		maxParam = int(v)
+	default:
 		return nil, fmt.Errorf("Max Open Trades unable to get integer from max param: %v", maxInter)
 	}
-	var nameParam string
-	if nameParam, ok = nameInter.(string); !ok {
+	nameParam, ok := nameInter.(string)
+	if !ok {
 		return nil, fmt.Errorf("Max Open Trades unable to get string from name param: %v", nameInter)
 	}
 
@@ -162,3 +176,188 @@ func createMaxOpenTradesCondition(params map[string]interface{}) (Condition, err
 		return true
 	}, nil
 }
+
+// minMaxParams reads the optional `min` and `max` decimal strings of a range condition
+func minMaxParams(params map[string]interface{}, name string) (decimal.Decimal, decimal.Decimal, error) {
+	minInter, minOk := params["min"]
+	maxInter, maxOk := params["max"]
+	if !minOk && !maxOk {
+		return decimal.Zero, decimal.Zero, fmt.Errorf("%s Condition requires at least one of `min` or `max`", name)
+	}
+	minParam, maxParam := decimal.NewFromInt(-999), decimal.NewFromInt(999)
+	var err error
+	if minOk {
+		if minParam, err = strInterToDec(minInter); err != nil {
+			return decimal.Zero, decimal.Zero, fmt.Errorf("%s unable to get decimal from min param: %v, %w", name, minInter, err)
+		}
+	}
+	if maxOk {
+		if maxParam, err = strInterToDec(maxInter); err != nil {
+			return decimal.Zero, decimal.Zero, fmt.Errorf("%s unable to get decimal from max param: %v, %w", name, maxInter, err)
+		}
+	}
+	return minParam, maxParam, nil
+}
+
+// numberParam reads an optional number, JSON numbers decode as float64
+func numberParam(params map[string]interface{}, key string, def float64) (float64, error) {
+	inter, ok := params[key]
+	if !ok {
+		return def, nil
+	}
+	switch v := inter.(type) {
+	case float64:
+		return v, nil
+	case int:
+		return float64(v), nil
+	}
+	return 0, fmt.Errorf("`%s` must be a number, got %v", key, inter)
+}
+
+func stringParam(params map[string]interface{}, key string) (string, error) {
+	inter, ok := params[key]
+	if !ok {
+		return "", fmt.Errorf("missing `%s` parameter", key)
+	}
+	str, ok := inter.(string)
+	if !ok || str == "" {
+		return "", fmt.Errorf("`%s` must be a string, got %v", key, inter)
+	}
+	return str, nil
+}
+
+// createIVSkewCondition checks the 25 delta put IV minus the 25 delta call IV, in vol
+// points, of the expiration nearest `dte` days out
+func createIVSkewCondition(params map[string]interface{}, clk clock.Clock) (Condition, error) {
+	root, err := stringParam(params, "underlying")
+	if err != nil {
+		return nil, fmt.Errorf("IV Skew Condition: %w", err)
+	}
+	dte, err := numberParam(params, "dte", 0)
+	if err != nil {
+		return nil, fmt.Errorf("IV Skew Condition: %w", err)
+	}
+	minParam, maxParam, err := minMaxParams(params, "IV Skew")
+	if err != nil {
+		return nil, err
+	}
+	return func(opts OptionsProvider, _ CandlesProvider, _ PortfolioProvider, _ StratStatusProvider) bool {
+		surface, err := opts.Surface(root)
+		if err != nil {
+			slog.Error("Unable to get vol surface for Entry Condition", "underlying", root, "error", err)
+			return false
+		}
+		smile, err := surface.SmileNear(options.Years(dte))
+		if err != nil {
+			slog.Error("Unable to get smile for Entry Condition", "underlying", root, "error", err)
+			return false
+		}
+		skew, err := smile.Skew25()
+		if err != nil {
+			slog.Error("Unable to get 25 delta skew for Entry Condition", "underlying", root, "error", err)
+			return false
+		}
+		skewD := decimal.NewFromFloat(skew * 100)
+		return minParam.LessThanOrEqual(skewD) && maxParam.GreaterThanOrEqual(skewD)
+	}, nil
+}
+
+// createTermStructureCondition checks the ATM IV `far-dte` days out minus the ATM IV
+// `near-dte` days out, in vol points. A max of 0 only enters on an inverted term structure
+func createTermStructureCondition(params map[string]interface{}, clk clock.Clock) (Condition, error) {
+	root, err := stringParam(params, "underlying")
+	if err != nil {
+		return nil, fmt.Errorf("Term Structure Condition: %w", err)
+	}
+	nearDTE, err := numberParam(params, "near-dte", 7)
+	if err != nil {
+		return nil, fmt.Errorf("Term Structure Condition: %w", err)
+	}
+	farDTE, err := numberParam(params, "far-dte", 30)
+	if err != nil {
+		return nil, fmt.Errorf("Term Structure Condition: %w", err)
+	}
+	if nearDTE <= 0 || farDTE <= nearDTE {
+		return nil, fmt.Errorf("Term Structure Condition requires 0 < near-dte < far-dte, got %v and %v", nearDTE, farDTE)
+	}
+	minParam, maxParam, err := minMaxParams(params, "Term Structure")
+	if err != nil {
+		return nil, err
+	}
+	return func(opts OptionsProvider, _ CandlesProvider, _ PortfolioProvider, _ StratStatusProvider) bool {
+		surface, err := opts.Surface(root)
+		if err != nil {
+			slog.Error("Unable to get vol surface for Entry Condition", "underlying", root, "error", err)
+			return false
+		}
+		near, err := surface.ATMIV(options.Years(nearDTE))
+		if err != nil {
+			slog.Error("Unable to get near ATM IV for Entry Condition", "underlying", root, "error", err)
+			return false
+		}
+		far, err := surface.ATMIV(options.Years(farDTE))
+		if err != nil {
+			slog.Error("Unable to get far ATM IV for Entry Condition", "underlying", root, "error", err)
+			return false
+		}
+		slope := decimal.NewFromFloat((far - near) * 100)
+		return minParam.LessThanOrEqual(slope) && maxParam.GreaterThanOrEqual(slope)
+	}, nil
+}
+
+// createExpectedMoveGapCondition checks the size of the overnight gap, or the move
+// since the open with `move` "intraday", as a percent of the expected move of the
+// expiration `dte` days out. Candles are read for `symbol`, the underlying of the root by default
+func createExpectedMoveGapCondition(params map[string]interface{}, clk clock.Clock, cal *calendar.Calendar) (Condition, error) {
+	root, err := stringParam(params, "underlying")
+	if err != nil {
+		return nil, fmt.Errorf("Expected Move Gap Condition: %w", err)
+	}
+	symbol := "^" + symbology.Underlying(root)
+	if _, ok := params["symbol"]; ok {
+		if symbol, err = stringParam(params, "symbol"); err != nil {
+			return nil, fmt.Errorf("Expected Move Gap Condition: %w", err)
+		}
+	}
+	dte, err := numberParam(params, "dte", 0)
+	if err != nil {
+		return nil, fmt.Errorf("Expected Move Gap Condition: %w", err)
+	}
+	move := "overnight"
+	if _, ok := params["move"]; ok {
+		if move, err = stringParam(params, "move"); err != nil {
+			return nil, fmt.Errorf("Expected Move Gap Condition: %w", err)
+		}
+	}
+	if move != "overnight" && move != "intraday" {
+		return nil, fmt.Errorf("Expected Move Gap Condition `move` must be overnight or intraday, got %s", move)
+	}
+	minParam, maxParam, err := minMaxParams(params, "Expected Move Gap")
+	if err != nil {
+		return nil, err
+	}
+	return func(opts OptionsProvider, candles CandlesProvider, _ PortfolioProvider, _ StratStatusProvider) bool {
+		exp := cal.DTEToDate(clk.Now(), int(dte))
+		em, err := opts.ExpectedMove(root, exp)
+		if err != nil {
+			slog.Error("Unable to get expected move for Entry Condition", "underlying", root, "error", err)
+			return false
+		}
+		if em.Move <= 0 {
+			slog.Error("Expected move for Entry Condition is not positive", "underlying", root, "move", em.Move)
+			return false
+		}
+		var gap float64
+		if move == "intraday" {
+			gap, err = candles.IntradayMove(symbol)
+		} else {
+			gap, err = candles.ONMove(symbol)
+		}
+		if err != nil {
+			slog.Error("Unable to get move for Entry Condition", "symbol", symbol, "move", move, "error", err)
+			return false
+		}
+		pct := decimal.NewFromFloat(math.Abs(gap) / em.Move * 100)
+		return minParam.LessThanOrEqual(pct) && maxParam.GreaterThanOrEqual(pct)
+	}, nil
+}
@@ -0,0 +1,137 @@
+package strategy
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/go-playground/assert/v2"
+	"github.com/jamesonhm/gochain/internal/calendar"
+	"github.com/jamesonhm/gochain/internal/clock"
+	"github.com/jamesonhm/gochain/internal/dt"
+	"github.com/jamesonhm/gochain/internal/dxlink"
+	"github.com/jamesonhm/gochain/internal/options"
+)
+
+type fakeOptions struct {
+	surface *options.Surface
+	move    float64
+	// expirations asked for
+	asked *[]time.Time
+}
+
+func (f fakeOptions) Surface(string) (*options.Surface, error) {
+	return f.surface, nil
+}
+
+func (f fakeOptions) ExpectedMove(_ string, exp time.Time) (dxlink.ExpectedMove, error) {
+	if f.asked != nil {
+		*f.asked = append(*f.asked, exp)
+	}
+	return dxlink.ExpectedMove{Expiration: exp, Spot: 600, Move: f.move}, nil
+}
+
+type fakeCandles struct {
+	gap, intraday float64
+}
+
+func (f fakeCandles) ONMove(string) (float64, error)       { return f.gap, nil }
+func (f fakeCandles) ONMovePct(string) (float64, error)    { return f.gap / 6, nil }
+func (f fakeCandles) IntradayMove(string) (float64, error) { return f.intraday, nil }
+
+type fakeStatus map[string]int
+
+func (f fakeStatus) OpenTrades(name string) int { return f[name] }
+
+// front month ATM at frontATM, back month at 0.18, puts richer than calls
+func testSurface(frontATM float64) *options.Surface {
+	s := options.NewSurface(600, 0.04, 0)
+	for _, exp := range []struct {
+		days float64
+		atm  float64
+	}{{7, frontATM}, {30, 0.18}} {
+		var points []options.SmilePoint
+		for strike := 540.0; strike <= 660; strike += 5 {
+			points = append(points, options.SmilePoint{Strike: strike, IV: exp.atm - 0.4*math.Log(strike/600)})
+		}
+		s.AddSmile(time.Now().AddDate(0, 0, int(exp.days)), options.Years(exp.days), points)
+	}
+	return s
+}
+
+func TestVolSurfaceConditions(t *testing.T) {
+	f := NewConditionFactory(clock.Real{}, calendar.New())
+	conds, err := f.FromConfig(map[string]map[string]interface{}{
+		"iv-skew":        {"underlying": "SPXW", "dte": 30.0, "min": "1"},
+		"term-structure": {"underlying": "SPXW", "max": "0"},
+	})
+	assert.Equal(t, err, nil)
+
+	contango := fakeOptions{surface: testSurface(0.15)}
+	inverted := fakeOptions{surface: testSurface(0.25)}
+	assert.Equal(t, conds["iv-skew"](contango, nil, nil, nil), true)
+	assert.Equal(t, conds["term-structure"](contango, nil, nil, nil), false)
+	assert.Equal(t, conds["term-structure"](inverted, nil, nil, nil), true)
+
+	_, err = f.FromConfig(map[string]map[string]interface{}{"term-structure": {"underlying": "SPXW", "near-dte": 30.0, "far-dte": 7.0, "max": "0"}})
+	assert.NotEqual(t, err, nil)
+	_, err = f.FromConfig(map[string]map[string]interface{}{"iv-skew": {"min": "1"}})
+	assert.NotEqual(t, err, nil)
+}
+
+func TestExpectedMoveGapCondition(t *testing.T) {
+	f := NewConditionFactory(clock.Real{}, calendar.New())
+	conds, err := f.FromConfig(map[string]map[string]interface{}{
+		"expected-move-gap": {"underlying": "SPXW", "max": "50"},
+	})
+	assert.Equal(t, err, nil)
+	cond := conds["expected-move-gap"]
+	opts := fakeOptions{move: 40}
+	// a 15 point gap down is 37.5% of a 40 point move
+	assert.Equal(t, cond(opts, fakeCandles{gap: -15}, nil, nil), true)
+	assert.Equal(t, cond(opts, fakeCandles{gap: 25}, nil, nil), false)
+	assert.Equal(t, cond(fakeOptions{}, fakeCandles{gap: 1}, nil, nil), false)
+
+	conds, err = f.FromConfig(map[string]map[string]interface{}{
+		"expected-move-gap": {"underlying": "SPXW", "move": "intraday", "min": "50"},
+	})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, conds["expected-move-gap"](opts, fakeCandles{gap: 30, intraday: 10}, nil, nil), false)
+
+	_, err = f.FromConfig(map[string]map[string]interface{}{"expected-move-gap": {"underlying": "SPXW", "move": "weekly", "max": "50"}})
+	assert.NotEqual(t, err, nil)
+
+	// the expiration a day out rolls past the holiday
+	clk := clock.NewFixed(time.Date(2025, 7, 3, 9, 0, 0, 0, dt.TZNY()))
+	conds, err = NewConditionFactory(clk, calendar.New()).FromConfig(map[string]map[string]interface{}{
+		"expected-move-gap": {"underlying": "SPXW", "dte": 1.0, "max": "50"},
+	})
+	assert.Equal(t, err, nil)
+	var asked []time.Time
+	conds["expected-move-gap"](fakeOptions{move: 40, asked: &asked}, fakeCandles{gap: 5}, nil, nil)
+	assert.Equal(t, len(asked), 1)
+	assert.Equal(t, asked[0].Format(time.DateOnly), "2025-07-07")
+}
+
+func TestMaxOpenTradesFromJSON(t *testing.T) {
+	var raw map[string]map[string]interface{}
+	err := json.Unmarshal([]byte(`{"max-open-trades": {"max": 2, "strategy-name": "basic PCS"}}`), &raw)
+	assert.Equal(t, err, nil)
+	conds, err := NewConditionFactory(clock.Real{}, calendar.New()).FromConfig(raw)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, conds["max-open-trades"](nil, nil, nil, fakeStatus{"basic PCS": 1}), true)
+	assert.Equal(t, conds["max-open-trades"](nil, nil, nil, fakeStatus{"basic PCS": 2}), false)
+}
+
+func TestDayOfWeekCondition(t *testing.T) {
+	// a friday
+	clk := clock.NewFixed(time.Date(2025, 7, 11, 10, 0, 0, 0, dt.TZNY()))
+	conds, err := NewConditionFactory(clk, calendar.New()).FromConfig(map[string]map[string]interface{}{
+		"day-of-week": {"days": []interface{}{"mon", "fri"}},
+	})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, conds["day-of-week"](nil, nil, nil, nil), true)
+	clk.Advance(24 * time.Hour)
+	assert.Equal(t, conds["day-of-week"](nil, nil, nil, nil), false)
+}
@@ -0,0 +1,202 @@
+package strategy
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/jamesonhm/gochain/internal/tasty"
+	"github.com/shopspring/decimal"
+)
+
+// OrderState is the lifecycle state of a wrapped order, independent of the raw broker status
+type OrderState string
+
+const (
+	// recorded from a dry run, not yet seen live at the broker
+	StatePending OrderState = "pending"
+	// opening order is working at the broker
+	StateWorking OrderState = "working"
+	// opening order (partially) filled, position is open
+	StateOpen OrderState = "open"
+	// closing order is working at the broker
+	StateClosing OrderState = "closing"
+	// position closed, or the opening order ended without a fill
+	StateClosed OrderState = "closed"
+	// rejected by the broker or never reached it
+	StateRejected OrderState = "rejected"
+)
+
+// Active states are those that count as an open trade for a strategy
+func (s OrderState) Active() bool {
+	switch s {
+	case StatePending, StateWorking, StateOpen, StateClosing:
+		return true
+	}
+	return false
+}
+
+// suffix added to the preflight id of an opening order to build the id of its closing order
+const closingSuffix = "-C"
+
+func ClosingPFID(pfid string) string {
+	return pfid + closingSuffix
+}
+
+// splits a preflight id into the opening order pfid and whether it belongs to a closing order
+func parsePFID(pfid string) (string, bool) {
+	if strings.HasSuffix(pfid, closingSuffix) {
+		return strings.TrimSuffix(pfid, closingSuffix), true
+	}
+	return pfid, false
+}
+
+func hasFills(order tasty.Order) bool {
+	for _, leg := range order.Legs {
+		if len(leg.Fills) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func isWorking(status tasty.OrderStatus) bool {
+	switch status {
+	case tasty.Received, tasty.Routed, tasty.InFlight, tasty.Live,
+		tasty.Contingent, tasty.CancelRequested, tasty.ReplaceRequested:
+		return true
+	}
+	return false
+}
+
+func openingState(order tasty.Order) OrderState {
+	// dry run responses never carry a broker order id
+	if order.ID == 0 {
+		return StatePending
+	}
+	switch {
+	case isWorking(order.Status):
+		return StateWorking
+	case order.Status == tasty.Filled:
+		return StateOpen
+	case order.Status == tasty.Rejected:
+		return StateRejected
+	case hasFills(order):
+		// cancelled or expired after a partial fill
+		return StateOpen
+	case order.Status == "":
+		return StatePending
+	}
+	return StateClosed
+}
+
+func closingState(order tasty.Order) OrderState {
+	switch {
+	case order.ID == 0:
+		return StateOpen
+	case isWorking(order.Status):
+		return StateClosing
+	case order.Status == tasty.Filled:
+		return StateClosed
+	}
+	// rejected, cancelled or expired closing orders leave the position open
+	return StateOpen
+}
+
+// deriveState computes the lifecycle state from the opening and closing orders
+func (wo WrappedOrder) deriveState() OrderState {
+	state := openingState(wo.Order)
+	if state == StateOpen && wo.ClosingOrder != nil {
+		state = closingState(*wo.ClosingOrder)
+	}
+	// closing orders that ended after partial fills may have closed the position between them
+	if state == StateOpen && len(wo.ClosingOrders()) > 0 && !wo.hasOpenQuantity() {
+		state = StateClosed
+	}
+	return state
+}
+
+// FilledQuantity is the quantity of the leg filled so far, a filled order without
+// fill details counts as fully filled
+func FilledQuantity(order tasty.Order, leg tasty.OrderLeg) float64 {
+	if len(leg.Fills) == 0 && order.Status == tasty.Filled {
+		return leg.Quantity
+	}
+	var qty float64
+	for _, fill := range leg.Fills {
+		qty += fill.Quantity
+	}
+	return qty
+}
+
+// ClosingOrders are the closing orders submitted for the position, oldest first
+func (wo WrappedOrder) ClosingOrders() []tasty.Order {
+	orders := slices.Clone(wo.PriorClosingOrders)
+	if wo.ClosingOrder != nil {
+		orders = append(orders, *wo.ClosingOrder)
+	}
+	return orders
+}
+
+// setClosingOrder records the latest state of a closing order, a different closing
+// order replacing one with fills keeps the earlier order so its fills still count
+func (wo *WrappedOrder) setClosingOrder(order tasty.Order) {
+	prev := wo.ClosingOrder
+	if prev != nil && prev.ID != 0 && prev.ID != order.ID && hasFills(*prev) {
+		wo.PriorClosingOrders = append(wo.PriorClosingOrders, *prev)
+	}
+	// a late update for an earlier closing order
+	wo.PriorClosingOrders = slices.DeleteFunc(wo.PriorClosingOrders, func(o tasty.Order) bool {
+		return order.ID != 0 && o.ID == order.ID
+	})
+	wo.ClosingOrder = &order
+}
+
+// OpenQuantity is the filled quantity of the opening leg for the OCC symbol less
+// the quantity filled by the closing orders
+func (wo WrappedOrder) OpenQuantity(symbol string) float64 {
+	var qty float64
+	for _, leg := range wo.Order.Legs {
+		if leg.Symbol == symbol {
+			qty += FilledQuantity(wo.Order, leg)
+		}
+	}
+	for _, order := range wo.ClosingOrders() {
+		for _, leg := range order.Legs {
+			if leg.Symbol == symbol {
+				qty -= FilledQuantity(order, leg)
+			}
+		}
+	}
+	return max(qty, 0)
+}
+
+func (wo WrappedOrder) hasOpenQuantity() bool {
+	return slices.ContainsFunc(wo.legSymbols(), func(sym string) bool {
+		return wo.OpenQuantity(sym) > 0
+	})
+}
+
+// TotalFees is the sum of the opening and closing fees, debits negative
+func (wo WrappedOrder) TotalFees() decimal.Decimal {
+	total := decimal.Zero
+	for _, fees := range []*tasty.FeeCalculation{wo.Fees, wo.ClosingFees} {
+		if fees == nil {
+			continue
+		}
+		if fees.TotalFeesEffect == tasty.Credit {
+			total = total.Add(fees.TotalFees.Abs())
+		} else {
+			total = total.Sub(fees.TotalFees.Abs())
+		}
+	}
+	return total
+}
+
+// OCC symbols of the legs of the opening order
+func (wo WrappedOrder) legSymbols() []string {
+	var syms []string
+	for _, leg := range wo.Order.Legs {
+		syms = append(syms, leg.Symbol)
+	}
+	return syms
+}
@@ -0,0 +1,180 @@
+package strategy
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/jamesonhm/gochain/internal/dt"
+	"github.com/jamesonhm/gochain/internal/store"
+	"github.com/jamesonhm/gochain/internal/tasty"
+)
+
+type BrokerProvider interface {
+	GetLiveOrders(context.Context, string) ([]tasty.Order, error)
+	GetOrders(context.Context, string, *tasty.OrdersParams) ([]tasty.Order, error)
+	GetAccountPositions(context.Context, string, *tasty.AccountPositionParams) ([]tasty.AccountPosition, error)
+}
+
+type ReconcileReport struct {
+	// wrapped orders whose state changed, as "strategy/pfid: old -> new"
+	Changed []string
+	// positions at the broker not owned by any strategy
+	Orphans []tasty.AccountPosition
+}
+
+// orderHistory reads every page of the orders of the day
+func orderHistory(ctx context.Context, broker BrokerProvider, acctNum string, day string) ([]tasty.Order, error) {
+	const perPage = 200
+	var history []tasty.Order
+	for offset := 0; ; offset++ {
+		page, err := broker.GetOrders(ctx, acctNum, &tasty.OrdersParams{
+			StartDate:  day,
+			EndDate:    day,
+			PerPage:    perPage,
+			PageOffset: offset,
+		})
+		if err != nil {
+			return nil, err
+		}
+		history = append(history, page...)
+		if len(page) < perPage {
+			return history, nil
+		}
+	}
+}
+
+// Reconcile brings the local state in line with the broker after a restart.
+// Wrapped orders are matched to live orders and today's order history, first by
+// order id and then by source and preflight id. Positions are used to resolve
+// orders the broker no longer reports, and any position not owned by an active
+// wrapped order is reported as an orphan.
+func (ss *Status) Reconcile(ctx context.Context, broker BrokerProvider, acctNum string, now time.Time) (ReconcileReport, error) {
+	var report ReconcileReport
+
+	today := now.In(dt.TZNY()).Format(time.DateOnly)
+	history, err := orderHistory(ctx, broker, acctNum, today)
+	if err != nil {
+		return report, fmt.Errorf("reconcile: unable to get order history: %w", err)
+	}
+	live, err := broker.GetLiveOrders(ctx, acctNum)
+	if err != nil {
+		return report, fmt.Errorf("reconcile: unable to get live orders: %w", err)
+	}
+	positions, err := broker.GetAccountPositions(ctx, acctNum, nil)
+	if err != nil {
+		return report, fmt.Errorf("reconcile: unable to get positions: %w", err)
+	}
+
+	byID := make(map[int]tasty.Order)
+	bySource := make(map[string]tasty.Order)
+	for _, order := range slices.Concat(history, live) {
+		byID[order.ID] = order
+		if order.Source == "" || order.PreflightID == "" {
+			continue
+		}
+		key := order.Source + "/" + order.PreflightID
+		// prefer the most recent order for a reused preflight id
+		if prev, ok := bySource[key]; !ok || order.ID >= prev.ID {
+			bySource[key] = order
+		}
+	}
+	held := make(map[string]bool)
+	for _, pos := range positions {
+		if pos.Quantity != 0 {
+			held[pos.Symbol] = true
+		}
+	}
+
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	owned := make(map[string]bool)
+	changed := make(map[string]map[string]WrappedOrder)
+	for name, orders := range ss.states.Strategies {
+		for pfid, wo := range orders.WrappedOrders {
+			prev := wo.State
+			if !prev.Active() {
+				continue
+			}
+
+			openMatch, ok := byID[wo.Order.ID]
+			if wo.Order.ID == 0 || !ok {
+				openMatch, ok = bySource[name+"/"+pfid]
+			}
+			if ok {
+				wo.Order = openMatch
+			}
+			if closeMatch, ok := bySource[name+"/"+ClosingPFID(pfid)]; ok {
+				wo.setClosingOrder(closeMatch)
+			}
+			wo.State = wo.deriveState()
+
+			inPositions := slices.ContainsFunc(wo.legSymbols(), func(sym string) bool {
+				return held[sym]
+			})
+			switch {
+			case wo.State == StatePending && !ok:
+				// dry run recorded but the live submit never reached the broker
+				wo.State = StateRejected
+				wo.StateReason = "not found at broker during reconcile"
+			case wo.State == StateWorking && !ok:
+				// working order from a prior session that the broker no longer reports
+				if inPositions {
+					wo.State = StateOpen
+				} else {
+					wo.State = StateClosed
+					wo.StateReason = "order not live and no position found during reconcile"
+				}
+			case (wo.State == StateOpen || wo.State == StateClosing) && !inPositions:
+				wo.State = StateClosed
+				wo.StateReason = "no position found during reconcile"
+			}
+
+			if wo.State.Active() {
+				for _, sym := range wo.legSymbols() {
+					owned[sym] = true
+				}
+			}
+			if wo.State != prev {
+				report.Changed = append(report.Changed, fmt.Sprintf("%s/%s: %s -> %s", name, pfid, prev, wo.State))
+				wo.UpdateTime = now
+			}
+			if changed[name] == nil {
+				changed[name] = make(map[string]WrappedOrder)
+			}
+			changed[name][pfid] = wo
+		}
+	}
+
+	// matched orders are stored even when the state is unchanged, the broker order may have new details
+	err = ss.db.Update(func(tx *store.Tx) error {
+		for name, orders := range changed {
+			for pfid, wo := range orders {
+				if err := putOrder(tx, name, pfid, wo); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return report, fmt.Errorf("reconcile: unable to store order states: %w", err)
+	}
+	for name, orders := range changed {
+		for pfid, wo := range orders {
+			ss.states.Strategies[name].WrappedOrders[pfid] = wo
+		}
+	}
+
+	for _, pos := range positions {
+		if pos.Quantity != 0 && !owned[pos.Symbol] {
+			report.Orphans = append(report.Orphans, pos)
+		}
+	}
+
+	slog.Info("(Reconcile) complete", "account", acctNum, "changed", len(report.Changed), "orphans", len(report.Orphans))
+	return report, nil
+}
@@ -0,0 +1,144 @@
+package strategy
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-playground/assert/v2"
+	"github.com/jamesonhm/gochain/internal/dt"
+	"github.com/jamesonhm/gochain/internal/tasty"
+)
+
+type fakeBroker struct {
+	live      []tasty.Order
+	history   []tasty.Order
+	positions []tasty.AccountPosition
+}
+
+func (b fakeBroker) GetLiveOrders(_ context.Context, _ string) ([]tasty.Order, error) {
+	return b.live, nil
+}
+
+func (b fakeBroker) GetOrders(_ context.Context, _ string, params *tasty.OrdersParams) ([]tasty.Order, error) {
+	start := min(params.PageOffset*params.PerPage, len(b.history))
+	end := min(start+params.PerPage, len(b.history))
+	return b.history[start:end], nil
+}
+
+func (b fakeBroker) GetAccountPositions(_ context.Context, _ string, _ *tasty.AccountPositionParams) ([]tasty.AccountPosition, error) {
+	return b.positions, nil
+}
+
+func openingOrder(id int, pfid string, status tasty.OrderStatus, syms ...string) tasty.Order {
+	order := tasty.Order{ID: id, PreflightID: pfid, Source: "test_strat", Status: status}
+	for _, sym := range syms {
+		order.Legs = append(order.Legs, tasty.OrderLeg{Symbol: sym, Action: tasty.STO})
+	}
+	return order
+}
+
+func TestReconcile(t *testing.T) {
+	stratstates := newTestStatus(t, filepath.Join(t.TempDir(), "states.db"))
+	now := time.Date(2025, 8, 1, 9, 0, 0, 0, dt.TZNY())
+
+	// dry run only, crashed before the live submit
+	stratstates.SubmitOrder("test_strat", now, "1", openingOrder(0, "1", tasty.Received, "XSP   250808P00600000"), nil)
+	// submitted live, filled while the process was down
+	stratstates.SubmitOrder("test_strat", now, "2", openingOrder(0, "2", tasty.Received, "XSP   250808P00610000"), nil)
+	// open position that was closed outside the bot
+	stratstates.SubmitOrder("test_strat", now, "3", openingOrder(0, "3", tasty.Received, "XSP   250808P00620000"), nil)
+	stratstates.UpdateOrder("test_strat", now, "3", openingOrder(30, "3", tasty.Filled, "XSP   250808P00620000"))
+	assert.Equal(t, stratstates.OpenTrades("test_strat"), 3)
+
+	broker := fakeBroker{
+		positions: []tasty.AccountPosition{
+			{Symbol: "XSP   250808P00610000", Quantity: 1},
+			{Symbol: "SPY", Quantity: 100},
+		},
+	}
+	// the fill is on the second page of today's history
+	for i := range 250 {
+		broker.history = append(broker.history, tasty.Order{ID: 1000 + i, Status: tasty.Cancelled})
+	}
+	broker.history = append(broker.history, openingOrder(20, "2", tasty.Filled, "XSP   250808P00610000"))
+	report, err := stratstates.Reconcile(context.Background(), broker, "ACCT", now)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(report.Changed), 3)
+	assert.Equal(t, len(report.Orphans), 1)
+	assert.Equal(t, report.Orphans[0].Symbol, "SPY")
+
+	status, err := stratstates.StatusByName("test_strat")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, status.WrappedOrders["1"].State, StateRejected)
+	assert.Equal(t, status.WrappedOrders["2"].State, StateOpen)
+	assert.Equal(t, status.WrappedOrders["2"].Order.ID, 20)
+	assert.Equal(t, status.WrappedOrders["3"].State, StateClosed)
+	assert.Equal(t, stratstates.OpenTrades("test_strat"), 1)
+}
+
+func TestClosingOrderUpdate(t *testing.T) {
+	stratstates := newTestStatus(t, filepath.Join(t.TempDir(), "states.db"))
+	now := time.Date(2025, 8, 1, 9, 0, 0, 0, dt.TZNY())
+	stratstates.SubmitOrder("test_strat", now, "1", openingOrder(0, "1", tasty.Received, "XSP   250808P00600000"), nil)
+	stratstates.UpdateOrder("test_strat", now, "1", openingOrder(10, "1", tasty.Filled, "XSP   250808P00600000"))
+
+	closing := tasty.Order{ID: 11, PreflightID: ClosingPFID("1"), Source: "test_strat", Status: tasty.Live,
+		Legs: []tasty.OrderLeg{{Symbol: "XSP   250808P00600000", Action: tasty.BTC}}}
+	err := stratstates.UpdateOrder("test_strat", now, closing.PreflightID, closing)
+	assert.Equal(t, err, nil)
+	status, _ := stratstates.StatusByName("test_strat")
+	assert.Equal(t, status.WrappedOrders["1"].State, StateClosing)
+
+	closing.Status = tasty.Filled
+	stratstates.UpdateOrder("test_strat", now, closing.PreflightID, closing)
+	status, _ = stratstates.StatusByName("test_strat")
+	assert.Equal(t, status.WrappedOrders["1"].State, StateClosed)
+	assert.Equal(t, stratstates.OpenTrades("test_strat"), 0)
+}
+
+func TestPartialFillOpenQuantity(t *testing.T) {
+	stratstates := newTestStatus(t, filepath.Join(t.TempDir(), "states.db"))
+	now := time.Date(2025, 8, 1, 9, 0, 0, 0, dt.TZNY())
+	short, long := "XSP   250808P00600000", "XSP   250808P00595000"
+	fill := func(qty float64) []tasty.OrderFill { return []tasty.OrderFill{{Quantity: qty}} }
+
+	// 2 of 3 spreads filled before the opening order was cancelled
+	opening := openingOrder(10, "1", tasty.Cancelled, short, long)
+	for i := range opening.Legs {
+		opening.Legs[i].Quantity = 3
+		opening.Legs[i].Fills = fill(2)
+	}
+	stratstates.SubmitOrder("test_strat", now, "1", openingOrder(0, "1", tasty.Received, short, long), nil)
+	stratstates.UpdateOrder("test_strat", now, "1", opening)
+	status, _ := stratstates.StatusByName("test_strat")
+	wo := status.WrappedOrders["1"]
+	assert.Equal(t, wo.State, StateOpen)
+	assert.Equal(t, wo.OpenQuantity(short), 2.0)
+
+	// the first closing order fills 1 before it is cancelled
+	closing := tasty.Order{ID: 11, PreflightID: ClosingPFID("1"), Source: "test_strat", Status: tasty.Cancelled,
+		Legs: []tasty.OrderLeg{
+			{Symbol: short, Action: tasty.BTC, Quantity: 2, Fills: fill(1)},
+			{Symbol: long, Action: tasty.STC, Quantity: 2, Fills: fill(1)},
+		}}
+	stratstates.UpdateOrder("test_strat", now, closing.PreflightID, closing)
+	status, _ = stratstates.StatusByName("test_strat")
+	wo = status.WrappedOrders["1"]
+	assert.Equal(t, wo.State, StateOpen)
+	assert.Equal(t, wo.OpenQuantity(long), 1.0)
+
+	// the next closing order replaces it, the earlier fill still counts
+	closing.ID, closing.Status = 12, tasty.Cancelled
+	for i := range closing.Legs {
+		closing.Legs[i].Quantity = 1
+		closing.Legs[i].Fills = fill(1)
+	}
+	stratstates.UpdateOrder("test_strat", now, closing.PreflightID, closing)
+	status, _ = stratstates.StatusByName("test_strat")
+	wo = status.WrappedOrders["1"]
+	assert.Equal(t, len(wo.ClosingOrders()), 2)
+	assert.Equal(t, wo.OpenQuantity(short), 0.0)
+	assert.Equal(t, wo.State, StateClosed)
+}
@@ -2,19 +2,21 @@ package strategy
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
 	"log/slog"
 	"os"
 	"sync"
 	"time"
 
+	"github.com/jamesonhm/gochain/internal/dxlink"
+	"github.com/jamesonhm/gochain/internal/store"
 	"github.com/jamesonhm/gochain/internal/tasty"
 )
 
 type Status struct {
 	mu     sync.RWMutex
-	fname  string
+	db     *store.Store
 	states StrategyStatus
 }
 
@@ -38,6 +40,20 @@ type WrappedOrder struct {
 	LastRetry     time.Time   `json:"last-retry"`
 	RetryAttempts int         `json:"retry-attempts"`
 	Order         tasty.Order `json:"order"`
+	// closing order for the position opened by Order, if one was submitted
+	ClosingOrder *tasty.Order `json:"closing-order,omitempty"`
+	// earlier closing orders that ended after a partial fill
+	PriorClosingOrders []tasty.Order `json:"prior-closing-orders,omitempty"`
+	State              OrderState    `json:"state"`
+	StateReason        string        `json:"state-reason,omitempty"`
+	// fees from the dry run of the opening and closing orders
+	Fees        *tasty.FeeCalculation `json:"fees,omitempty"`
+	ClosingFees *tasty.FeeCalculation `json:"closing-fees,omitempty"`
+	// leg quotes the opening and closing orders were priced from
+	Pricing        *dxlink.QuoteSnapshot `json:"pricing,omitempty"`
+	ClosingPricing *dxlink.QuoteSnapshot `json:"closing-pricing,omitempty"`
+	// strategy config at the time the order was submitted
+	Config *Strategy `json:"strategy-config,omitempty"`
 	// Flag Field "Held" to indicate a retry worker is handling this order?
 	// TODO: other submit metrics here?
 	// Short/Long Ratio
@@ -50,28 +66,188 @@ type WrappedOrder struct {
 //	Status  string  `json:"status"`
 //}
 
-func NewStatus(filename string) *Status {
+const (
+	strategiesBucket = "strategies"
+	ordersBucket     = "orders"
+	pfidBucket       = "pfid"
+	importsBucket    = "imports"
+	summaryKey       = "summary"
+)
+
+// statusMigrations upgrade the store layout, append new migrations to the end
+var statusMigrations = []store.Migration{
+	// v1: strategy, preflight id and import buckets
+	func(tx *store.Tx) error {
+		for _, name := range []string{strategiesBucket, pfidBucket, importsBucket} {
+			if err := tx.CreateBucket(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+}
+
+type stratSummary struct {
+	LastSubmitted time.Time `json:"last-submitted"`
+}
+
+func ordersPath(stratname string) []string {
+	return []string{strategiesBucket, stratname, ordersBucket}
+}
+
+// NewStatus opens the state store at filename and loads the strategy states
+func NewStatus(filename string) (*Status, error) {
+	db, err := store.Open(filename, statusMigrations)
+	if err != nil {
+		return nil, err
+	}
+	ss := &Status{
+		db: db,
+		states: StrategyStatus{
+			Strategies: make(map[string]stratOrders),
+		},
+	}
+	if err := ss.load(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("unable to load strategy states from %s: %w", filename, err)
+	}
+	return ss, nil
+}
+
+func (ss *Status) load() error {
+	return ss.db.View(func(tx *store.Tx) error {
+		seq, err := tx.Sequence(pfidBucket)
+		if err != nil {
+			return err
+		}
+		ss.states.SeqPfid = seq
+
+		names, err := tx.Buckets(strategiesBucket)
+		if err != nil {
+			return err
+		}
+		for _, name := range names {
+			var summary stratSummary
+			if _, err := tx.Get([]string{strategiesBucket, name}, summaryKey, &summary); err != nil {
+				return err
+			}
+			orders := stratOrders{
+				LastSubmitted: summary.LastSubmitted,
+				WrappedOrders: make(map[string]WrappedOrder),
+			}
+			err := tx.ForEach(ordersPath(name), func(pfid string, data []byte) error {
+				var wo WrappedOrder
+				if err := json.Unmarshal(data, &wo); err != nil {
+					return fmt.Errorf("order %s/%s: %w", name, pfid, err)
+				}
+				orders.WrappedOrders[pfid] = wo
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			ss.states.Strategies[name] = orders
+		}
+		return nil
+	})
+}
+
+func (ss *Status) Close() error {
+	return ss.db.Close()
+}
+
+// ImportJSON loads a state file written before the store existed. The import is
+// recorded in the store and the file renamed, so it only ever runs once per file.
+// A missing file is not an error.
+func (ss *Status) ImportJSON(filename string) (int, error) {
+	data, err := os.ReadFile(filename)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	} else if err != nil {
+		return 0, fmt.Errorf("unable to read %s: %w", filename, err)
+	}
 	states := StrategyStatus{
 		Strategies: make(map[string]stratOrders),
 	}
-	if data, err := os.ReadFile(filename); err != nil {
-		slog.Info("Unable to read strat states file, creating new")
-		_, err := os.Create(filename)
-		if err != nil {
-			log.Fatal(err)
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &states); err != nil {
+			return 0, fmt.Errorf("unable to decode %s: %w", filename, err)
 		}
-	} else {
-		if len(data) > 0 {
-			if err := json.Unmarshal(data, &states); err != nil {
-				log.Fatal(err)
+	}
+	// state files written before lifecycle states existed
+	for name, orders := range states.Strategies {
+		for pfid, wo := range orders.WrappedOrders {
+			if wo.State == "" {
+				wo.State = wo.deriveState()
+				orders.WrappedOrders[pfid] = wo
+			}
+		}
+		states.Strategies[name] = orders
+	}
+
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	var count int
+	var importedAt time.Time
+	err = ss.db.Update(func(tx *store.Tx) error {
+		if done, err := tx.Get([]string{importsBucket}, filename, &importedAt); err != nil || done {
+			return err
+		}
+		if states.SeqPfid > ss.states.SeqPfid {
+			if err := tx.SetSequence(states.SeqPfid, pfidBucket); err != nil {
+				return err
+			}
+		}
+		for name, orders := range states.Strategies {
+			if err := tx.Put([]string{strategiesBucket, name}, summaryKey, stratSummary{LastSubmitted: orders.LastSubmitted}); err != nil {
+				return err
 			}
+			for pfid, wo := range orders.WrappedOrders {
+				if err := tx.Put(ordersPath(name), pfid, wo); err != nil {
+					return err
+				}
+				count++
+			}
+		}
+		return tx.Put([]string{importsBucket}, filename, time.Now())
+	})
+	if err != nil {
+		return 0, err
+	}
+	if !importedAt.IsZero() {
+		slog.Info("state file already imported, skipping", "file", filename, "imported at", importedAt)
+		return 0, nil
+	}
+
+	if states.SeqPfid > ss.states.SeqPfid {
+		ss.states.SeqPfid = states.SeqPfid
+	}
+	for name, orders := range states.Strategies {
+		current, ok := ss.states.Strategies[name]
+		if !ok {
+			ss.states.Strategies[name] = orders
+			continue
+		}
+		if orders.LastSubmitted.After(current.LastSubmitted) {
+			current.LastSubmitted = orders.LastSubmitted
+		}
+		for pfid, wo := range orders.WrappedOrders {
+			current.WrappedOrders[pfid] = wo
 		}
+		ss.states.Strategies[name] = current
 	}
-	return &Status{
-		states: states,
-		fname:  filename,
+
+	if err := os.Rename(filename, filename+".imported"); err != nil {
+		slog.Warn("unable to rename imported state file", "file", filename, "error", err)
 	}
+	return count, nil
+}
 
+// putOrder persists a single wrapped order, the in memory state is only updated
+// by the caller once the transaction commits
+func putOrder(tx *store.Tx, stratname string, pfid string, wo WrappedOrder) error {
+	return tx.Put(ordersPath(stratname), pfid, wo)
 }
 
 //func newStratOrders(ts time.Time, pfid string, order tasty.NewOrder) stratOrders {
@@ -90,20 +266,22 @@ func NewStatus(filename string) *Status {
 //	}
 //}
 
-func newWrappedOrder(ts time.Time, pfid string, order tasty.Order) WrappedOrder {
+func newWrappedOrder(ts time.Time, pfid string, order tasty.Order, cfg *Strategy) WrappedOrder {
 	return WrappedOrder{
 		PreflightID:   pfid,
 		RetryAttempts: 0,
 		Order:         order,
 		SubmitTime:    ts,
+		State:         openingState(order),
+		Config:        cfg,
 	}
 }
 
-func newStratOrders(ts time.Time, pfid string, order tasty.Order) stratOrders {
+func newStratOrders(ts time.Time, wo WrappedOrder) stratOrders {
 	return stratOrders{
 		LastSubmitted: ts,
 		WrappedOrders: map[string]WrappedOrder{
-			pfid: newWrappedOrder(ts, pfid, order),
+			wo.PreflightID: wo,
 		},
 	}
 }
@@ -114,19 +292,29 @@ func (ss *Status) PPrint() {
 	fmt.Println(string(bytes))
 }
 
-func (ss *Status) SubmitOrder(stratname string, ts time.Time, pfid string, order tasty.Order) {
+func (ss *Status) SubmitOrder(stratname string, ts time.Time, pfid string, order tasty.Order, cfg *Strategy) error {
 	ss.mu.Lock()
 	defer ss.mu.Unlock()
 
+	wo := newWrappedOrder(ts, pfid, order, cfg)
+	err := ss.db.Update(func(tx *store.Tx) error {
+		if err := tx.Put([]string{strategiesBucket, stratname}, summaryKey, stratSummary{LastSubmitted: ts}); err != nil {
+			return err
+		}
+		return putOrder(tx, stratname, pfid, wo)
+	})
+	if err != nil {
+		return fmt.Errorf("unable to record order %s for %s: %w", pfid, stratname, err)
+	}
+
 	if orders, ok := ss.states.Strategies[stratname]; !ok {
-		ss.states.Strategies[stratname] = newStratOrders(ts, pfid, order)
+		ss.states.Strategies[stratname] = newStratOrders(ts, wo)
 	} else {
 		orders.LastSubmitted = ts
-		orders.WrappedOrders[pfid] = newWrappedOrder(ts, pfid, order)
+		orders.WrappedOrders[pfid] = wo
 		ss.states.Strategies[stratname] = orders
 	}
-
-	ss.writefile()
+	return nil
 }
 
 //func (ss *Status) Submit(stratname string, ts time.Time, pfid string, order tasty.NewOrder) {
@@ -134,7 +322,7 @@ func (ss *Status) SubmitOrder(stratname string, ts time.Time, pfid string, order
 //	defer ss.mu.Unlock()
 //
 //	if orders, ok := ss.states.Strategies[stratname]; !ok {
-//		ss.states.Strategies[stratname] = newStratOrders(ts, pfid, order)
+//		ss.states.Strategies[stratname] = newStratOrders(ts, wo)
 //	} else {
 //		orders.LastSubmitted = ts
 //		//orders.OrderDetails[pfid] = orderDetail{}
@@ -155,6 +343,32 @@ func (ss *Status) StatusByName(stratname string) (stratOrders, error) {
 	return stratOrders{}, fmt.Errorf("No status for strategy name")
 }
 
+func (ss *Status) WrappedOrder(stratname string, pfid string) (WrappedOrder, error) {
+	ss.mu.RLock()
+	defer ss.mu.RUnlock()
+
+	if orders, ok := ss.states.Strategies[stratname]; ok {
+		if wo, ok := orders.WrappedOrders[pfid]; ok {
+			return wo, nil
+		}
+	}
+	return WrappedOrder{}, fmt.Errorf("no order found for stratname %s and pfid %s", stratname, pfid)
+}
+
+// AllOrders returns a copy of the wrapped orders of every strategy
+func (ss *Status) AllOrders() map[string][]WrappedOrder {
+	ss.mu.RLock()
+	defer ss.mu.RUnlock()
+
+	all := make(map[string][]WrappedOrder)
+	for name, orders := range ss.states.Strategies {
+		for _, wo := range orders.WrappedOrders {
+			all[name] = append(all[name], wo)
+		}
+	}
+	return all
+}
+
 func (ss *Status) LastSubmitted(stratname string) (time.Time, error) {
 	ss.mu.RLock()
 	defer ss.mu.RUnlock()
@@ -169,20 +383,48 @@ func (ss *Status) OpenTrades(stratname string) int {
 	ss.mu.RLock()
 	defer ss.mu.RUnlock()
 
+	var count int
 	if orders, ok := ss.states.Strategies[stratname]; ok {
-		return len(orders.WrappedOrders)
-	} else {
-		return 0
+		for _, wo := range orders.WrappedOrders {
+			if wo.State.Active() {
+				count++
+			}
+		}
+	}
+	return count
+}
+
+// WorkingOrders are the wrapped orders of the strategy whose opening order is working at the broker
+func (ss *Status) WorkingOrders(stratname string) []WrappedOrder {
+	ss.mu.RLock()
+	defer ss.mu.RUnlock()
+
+	var working []WrappedOrder
+	if orders, ok := ss.states.Strategies[stratname]; ok {
+		for _, wo := range orders.WrappedOrders {
+			if wo.State == StateWorking {
+				working = append(working, wo)
+			}
+		}
 	}
+	return working
 }
 
-func (ss *Status) NextPFID() int {
+func (ss *Status) NextPFID() (int, error) {
 	ss.mu.Lock()
 	defer ss.mu.Unlock()
 
-	ss.states.SeqPfid += 1
-	ss.writefile()
-	return ss.states.SeqPfid
+	var seq int
+	err := ss.db.Update(func(tx *store.Tx) error {
+		var err error
+		seq, err = tx.NextSequence(pfidBucket)
+		return err
+	})
+	if err != nil {
+		return 0, fmt.Errorf("unable to get next pfid: %w", err)
+	}
+	ss.states.SeqPfid = seq
+	return seq, nil
 }
 
 func (ss *Status) UpdateOrder(stratname string, ts time.Time, pfid string, order tasty.Order) error {
@@ -193,27 +435,101 @@ func (ss *Status) UpdateOrder(stratname string, ts time.Time, pfid string, order
 	if !ok {
 		return fmt.Errorf("no states found for stratname %s", stratname)
 	}
-	wo, ok := orders.WrappedOrders[pfid]
+	openPfid, closing := parsePFID(pfid)
+	wo, ok := orders.WrappedOrders[openPfid]
 	if !ok {
 		return fmt.Errorf("no order found for stratname %s and pfid %s", stratname, pfid)
 	}
 	wo.UpdateTime = ts
-	wo.Order = order
+	if closing {
+		wo.setClosingOrder(order)
+	} else {
+		wo.Order = order
+	}
+	wo.State = wo.deriveState()
+	wo.StateReason = ""
 
-	orders.WrappedOrders[pfid] = wo
-	ss.states.Strategies[stratname] = orders
+	if err := ss.db.Update(func(tx *store.Tx) error { return putOrder(tx, stratname, openPfid, wo) }); err != nil {
+		return fmt.Errorf("unable to update order %s for %s: %w", pfid, stratname, err)
+	}
+	orders.WrappedOrders[openPfid] = wo
+	return nil
+}
+
+// SetFees records the fee calculation for the opening or closing order of a wrapped order
+func (ss *Status) SetFees(stratname string, pfid string, fees tasty.FeeCalculation) error {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	orders, ok := ss.states.Strategies[stratname]
+	if !ok {
+		return fmt.Errorf("no states found for stratname %s", stratname)
+	}
+	openPfid, closing := parsePFID(pfid)
+	wo, ok := orders.WrappedOrders[openPfid]
+	if !ok {
+		return fmt.Errorf("no order found for stratname %s and pfid %s", stratname, pfid)
+	}
+	if closing {
+		wo.ClosingFees = &fees
+	} else {
+		wo.Fees = &fees
+	}
 
-	ss.writefile()
+	if err := ss.db.Update(func(tx *store.Tx) error { return putOrder(tx, stratname, openPfid, wo) }); err != nil {
+		return fmt.Errorf("unable to set fees on %s for %s: %w", pfid, stratname, err)
+	}
+	orders.WrappedOrders[openPfid] = wo
 	return nil
 }
 
-func (ss *Status) writefile() {
-	byt, err := json.MarshalIndent(ss.states, "", "  ")
-	if err != nil {
-		log.Fatal(err)
+// SetPricing records the quote snapshot the opening or closing order was priced from
+func (ss *Status) SetPricing(stratname string, pfid string, snap *dxlink.QuoteSnapshot) error {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	orders, ok := ss.states.Strategies[stratname]
+	if !ok {
+		return fmt.Errorf("no states found for stratname %s", stratname)
 	}
-	err = os.WriteFile(ss.fname, byt, 0666)
-	if err != nil {
-		log.Fatal(err)
+	openPfid, closing := parsePFID(pfid)
+	wo, ok := orders.WrappedOrders[openPfid]
+	if !ok {
+		return fmt.Errorf("no order found for stratname %s and pfid %s", stratname, pfid)
+	}
+	if closing {
+		wo.ClosingPricing = snap
+	} else {
+		wo.Pricing = snap
+	}
+
+	if err := ss.db.Update(func(tx *store.Tx) error { return putOrder(tx, stratname, openPfid, wo) }); err != nil {
+		return fmt.Errorf("unable to set pricing on %s for %s: %w", pfid, stratname, err)
 	}
+	orders.WrappedOrders[openPfid] = wo
+	return nil
+}
+
+// SetState overrides the lifecycle state of a wrapped order, used when the executor
+// aborts an order after it was recorded
+func (ss *Status) SetState(stratname string, pfid string, state OrderState, reason string) error {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	orders, ok := ss.states.Strategies[stratname]
+	if !ok {
+		return fmt.Errorf("no states found for stratname %s", stratname)
+	}
+	wo, ok := orders.WrappedOrders[pfid]
+	if !ok {
+		return fmt.Errorf("no order found for stratname %s and pfid %s", stratname, pfid)
+	}
+	wo.State = state
+	wo.StateReason = reason
+
+	if err := ss.db.Update(func(tx *store.Tx) error { return putOrder(tx, stratname, pfid, wo) }); err != nil {
+		return fmt.Errorf("unable to set state on %s for %s: %w", pfid, stratname, err)
+	}
+	orders.WrappedOrders[pfid] = wo
+	return nil
 }
@@ -1,6 +1,8 @@
 package strategy
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -9,13 +11,107 @@ import (
 	"github.com/jamesonhm/gochain/internal/tasty"
 )
 
+func newTestStatus(t *testing.T, fpath string) *Status {
+	t.Helper()
+	ss, err := NewStatus(fpath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ss.Close() })
+	return ss
+}
+
 func TestStatusSubmit(t *testing.T) {
-	stratstates := NewStatus("test_states.json")
+	stratstates := newTestStatus(t, filepath.Join(t.TempDir(), "states.db"))
 	submit_time := time.Date(2025, 8, 1, 13, 0, 0, 0, dt.TZNY())
 	order := tasty.Order{}
-	stratstates.SubmitOrder("test_strat", submit_time, "1", order)
+	err := stratstates.SubmitOrder("test_strat", submit_time, "1", order, nil)
+	assert.Equal(t, err, nil)
 
 	status, err := stratstates.StatusByName("test_strat")
 	assert.Equal(t, err, nil)
 	assert.Equal(t, status.LastSubmitted, submit_time)
 }
+
+func TestStatusWorkingOrders(t *testing.T) {
+	stratstates := newTestStatus(t, filepath.Join(t.TempDir(), "states.db"))
+	now := time.Date(2025, 8, 1, 13, 0, 0, 0, dt.TZNY())
+	for _, pfid := range []string{"1", "2"} {
+		stratstates.SubmitOrder("test_strat", now, pfid, openingOrder(0, pfid, tasty.Received, "XSP   250808P00600000"), nil)
+	}
+	assert.Equal(t, len(stratstates.WorkingOrders("test_strat")), 0)
+
+	stratstates.UpdateOrder("test_strat", now, "1", openingOrder(10, "1", tasty.Live, "XSP   250808P00600000"))
+	stratstates.UpdateOrder("test_strat", now, "2", openingOrder(11, "2", tasty.Filled, "XSP   250808P00600000"))
+	working := stratstates.WorkingOrders("test_strat")
+	assert.Equal(t, len(working), 1)
+	assert.Equal(t, working[0].Order.ID, 10)
+}
+
+func TestStatusReopen(t *testing.T) {
+	fpath := filepath.Join(t.TempDir(), "states.db")
+	submit_time := time.Date(2025, 8, 1, 13, 0, 0, 0, dt.TZNY())
+
+	stratstates, err := NewStatus(fpath)
+	assert.Equal(t, err, nil)
+	pfid, err := stratstates.NextPFID()
+	assert.Equal(t, err, nil)
+	assert.Equal(t, pfid, 1)
+	stratstates.SubmitOrder("test_strat", submit_time, "1", openingOrder(0, "1", tasty.Received, "XSP   250808P00600000"), nil)
+	stratstates.UpdateOrder("test_strat", submit_time, "1", openingOrder(10, "1", tasty.Filled, "XSP   250808P00600000"))
+	assert.Equal(t, stratstates.Close(), nil)
+
+	stratstates = newTestStatus(t, fpath)
+	wo, err := stratstates.WrappedOrder("test_strat", "1")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, wo.State, StateOpen)
+	assert.Equal(t, wo.Order.ID, 10)
+	last, err := stratstates.LastSubmitted("test_strat")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, last.Equal(submit_time), true)
+	pfid, _ = stratstates.NextPFID()
+	assert.Equal(t, pfid, 2)
+}
+
+func TestStatusImportJSON(t *testing.T) {
+	dir := t.TempDir()
+	legacy := filepath.Join(dir, "teststates.json")
+	data := `{
+  "seq-pfid": 7,
+  "strategies": {
+    "test_strat": {
+      "last-submitted": "2025-08-01T09:00:00-04:00",
+      "wrapped-orders": {
+        "7": {"pfid": "7", "order": {"id": 70, "status": "Filled"}}
+      }
+    }
+  }
+}`
+	if err := os.WriteFile(legacy, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	stratstates := newTestStatus(t, filepath.Join(dir, "states.db"))
+	count, err := stratstates.ImportJSON(legacy)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, count, 1)
+
+	wo, err := stratstates.WrappedOrder("test_strat", "7")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, wo.State, StateOpen)
+	pfid, _ := stratstates.NextPFID()
+	assert.Equal(t, pfid, 8)
+
+	// the legacy file is renamed, a second import is a no-op
+	_, err = os.Stat(legacy + ".imported")
+	assert.Equal(t, err, nil)
+	count, err = stratstates.ImportJSON(legacy)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, count, 0)
+
+	// a file put back after its import is skipped, not an error
+	os.WriteFile(legacy, []byte(data), 0644)
+	count, err = stratstates.ImportJSON(legacy)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, count, 0)
+}
@@ -7,24 +7,39 @@ import (
 	"os"
 	"time"
 
+	"github.com/jamesonhm/gochain/internal/calendar"
 	"github.com/jamesonhm/gochain/internal/dt"
+	"github.com/jamesonhm/gochain/internal/dxlink"
+	"github.com/jamesonhm/gochain/internal/options"
+	"github.com/shopspring/decimal"
 )
 
-// TODO: Set min time back to 9:32am
-const (
-	MIN_TIME = "6:32AM"
-	MAX_TIME = "3:58PM"
+// entry windows stay this far inside the regular session
+const EntryBuffer = 2 * time.Minute
+
+var (
+	MIN_TIME = kitchen(calendar.RegularOpen + EntryBuffer)
+	MAX_TIME = kitchen(calendar.RegularClose - EntryBuffer)
 )
 
+// kitchen formats an offset from midnight as "3:04PM"
+func kitchen(d time.Duration) string {
+	return time.Date(0, 1, 1, 0, 0, 0, 0, time.UTC).Add(d).Format(time.Kitchen)
+}
+
 // TODO: where does `Use Exact DTE`, ... go?
 type Strategy struct {
-	Name            string                            `json:"name"`
-	Underlying      string                            `json:"underlying"`
+	Name       string `json:"name"`
+	Underlying string `json:"underlying"`
+	// account number or alias the strategy trades in, empty for the default account
+	Account         string                            `json:"account"`
 	Legs            []Leg                             `json:"legs"`
 	EntryTime       EntryTime                         `json:"entry-time"`
 	EntryConditions map[string]map[string]interface{} `json:"entry-conditions"`
 	EntrySlippage   int                               `json:"entry-slippage"`
 	RetryConfig     RetryConfig                       `json:"retry-config"`
+	QuoteGuards     QuoteGuards                       `json:"quote-guards"`
+	DryRunChecks    DryRunChecks                      `json:"dry-run-checks"`
 	Allocation      string                            `json:"allocation"`
 	entryConditions map[string]Condition
 	exitConditions  map[string]Condition
@@ -57,6 +72,37 @@ type RetryConfig struct {
 	MaxPriceMove int  `json:"max-price-move"`
 }
 
+// Checks applied to the quote of each leg before an order is built.
+// Zero values disable the age and width checks
+type QuoteGuards struct {
+	MaxQuoteAgeSecs float64 `json:"max-quote-age-secs"`
+	// max bid/ask width in cents
+	MaxSpreadCents int `json:"max-spread-cents"`
+	// max bid/ask width as a percent of the mid
+	MaxSpreadPct float64 `json:"max-spread-pct"`
+	AllowZeroBid bool    `json:"allow-zero-bid"`
+	// require streamed greeks for every leg, always required for delta strike selection
+	RequireDelta bool `json:"require-delta"`
+	// max time between the oldest and newest leg quote of the order
+	MaxLegSkewSecs float64 `json:"max-leg-skew-secs"`
+}
+
+// Thresholds evaluated against the dry run response before an entry goes live.
+// Amounts are in dollars, zero values disable the check
+type DryRunChecks struct {
+	// min credit per contract after fees
+	MinNetCredit decimal.Decimal `json:"min-net-credit"`
+	// min credit as a ratio of the widest strike distance
+	MinCreditWidthRatio       decimal.Decimal `json:"min-credit-width-ratio"`
+	MaxBuyingPowerPerContract decimal.Decimal `json:"max-bp-per-contract"`
+	// max buying power for the whole order, the quantity is reduced to fit
+	MaxBuyingPower decimal.Decimal `json:"max-buying-power"`
+	// max expiration loss per contract after fees, from the order legs and price
+	MaxLossPerContract decimal.Decimal `json:"max-loss-per-contract"`
+	// reject orders with an uncapped expiration loss
+	RequireDefinedRisk bool `json:"require-defined-risk"`
+}
+
 type RiskParams struct {
 	PctPortfolio float64
 	NumContracts int
@@ -110,6 +156,8 @@ func NewLeg(
 }
 
 type OptionsProvider interface {
+	Surface(string) (*options.Surface, error)
+	ExpectedMove(string, time.Time) (dxlink.ExpectedMove, error)
 }
 
 type CandlesProvider interface {
@@ -149,15 +197,26 @@ func (s *Strategy) ListDTEs() []int {
 	return dtes
 }
 
-// check if a time is within the current entry time window
-func (s *Strategy) TimeInEntry(t time.Time) bool {
-	if t.After(dt.ParseTimeAsToday(s.EntryTime.MinTime)) &&
-		t.Before(dt.ParseTimeAsToday(s.EntryTime.MaxTime)) {
+// check if a time is within the entry time window of the day of now
+func (s *Strategy) TimeInEntry(now, t time.Time) bool {
+	if t.After(dt.ParseTimeAsToday(now, s.EntryTime.MinTime)) &&
+		t.Before(dt.ParseTimeAsToday(now, s.EntryTime.MaxTime)) {
 		return true
 	}
 	return false
 }
 
+// InEntryWindow checks now against the entry window cut short by the session close,
+// so a window past an early close is skipped for the day
+func (s *Strategy) InEntryWindow(now time.Time, session calendar.Session) bool {
+	return s.TimeInEntry(now, now) && session.Contains(now) && now.Before(session.Close.Add(-EntryBuffer))
+}
+
+// identifies the entry window containing t, used to dedupe order submissions
+func (s *Strategy) EntryWindowKey(t time.Time) string {
+	return fmt.Sprintf("%s %s-%s", t.In(dt.TZNY()).Format(time.DateOnly), s.EntryTime.MinTime, s.EntryTime.MaxTime)
+}
+
 func (s *Strategy) validateEntryTimes() error {
 	var t time.Time
 	var err error
@@ -176,7 +235,7 @@ func (s *Strategy) validateEntryTimes() error {
 	}
 
 	if s.EntryTime.MaxTime == "" {
-		s.EntryTime.MaxTime = dt.ParseTimeAsToday(s.EntryTime.MinTime).Add(1 * time.Minute).Format(time.Kitchen)
+		s.EntryTime.MaxTime = t.Add(1 * time.Minute).Format(time.Kitchen)
 	}
 	if t, err = time.Parse(time.Kitchen, s.EntryTime.MaxTime); err != nil {
 		return fmt.Errorf("(strategy: `%s`) Invalid format for EntryTime.MaxTime: %s, should be `3:40PM`", s.Name, s.EntryTime.MaxTime)
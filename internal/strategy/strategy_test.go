@@ -0,0 +1,46 @@
+package strategy
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-playground/assert/v2"
+	"github.com/jamesonhm/gochain/internal/calendar"
+	"github.com/jamesonhm/gochain/internal/dt"
+)
+
+func TestValidateEntryTimes(t *testing.T) {
+	assert.Equal(t, MIN_TIME, "9:32AM")
+	assert.Equal(t, MAX_TIME, "3:58PM")
+
+	s := Strategy{Name: "pre-market", EntryTime: EntryTime{MinTime: "7:20AM"}}
+	assert.NotEqual(t, s.validateEntryTimes(), nil)
+	s = Strategy{Name: "late", EntryTime: EntryTime{MinTime: "10:00AM", MaxTime: "3:59PM"}}
+	assert.NotEqual(t, s.validateEntryTimes(), nil)
+	s = Strategy{Name: "ok", EntryTime: EntryTime{MinTime: "9:40AM"}}
+	assert.Equal(t, s.validateEntryTimes(), nil)
+	assert.Equal(t, s.EntryTime.MaxTime, "9:41AM")
+}
+
+func TestInEntryWindowEarlyClose(t *testing.T) {
+	today := dt.Midnight(time.Date(2025, 7, 3, 0, 0, 0, 0, dt.TZNY()))
+	regular := calendar.Session{Open: today.Add(calendar.RegularOpen), Close: today.Add(calendar.RegularClose)}
+	early := calendar.Session{Open: regular.Open, Close: today.Add(calendar.EarlyClose), Early: true}
+
+	s := Strategy{EntryTime: EntryTime{MinTime: "12:30PM", MaxTime: "2:00PM"}}
+	at := func(h, m int) time.Time { return today.Add(time.Duration(h)*time.Hour + time.Duration(m)*time.Minute) }
+	assert.Equal(t, s.InEntryWindow(at(13, 30), regular), true)
+	assert.Equal(t, s.InEntryWindow(at(12, 45), early), true)
+	// inside the configured window, but within the buffer of or past the early close
+	assert.Equal(t, s.InEntryWindow(at(12, 59), early), false)
+	assert.Equal(t, s.InEntryWindow(at(13, 30), early), false)
+}
+
+func TestTimeInEntry(t *testing.T) {
+	s := Strategy{EntryTime: EntryTime{MinTime: "9:40AM", MaxTime: "10:10AM"}}
+	now := time.Date(2025, 7, 2, 10, 0, 0, 0, dt.TZNY())
+	assert.Equal(t, s.TimeInEntry(now, now), true)
+	// the same time of day on the previous day is outside today's window
+	assert.Equal(t, s.TimeInEntry(now, now.AddDate(0, 0, -1)), false)
+	assert.Equal(t, s.TimeInEntry(now, now.Add(-30*time.Minute)), false)
+}
@@ -0,0 +1,111 @@
+package symbology
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FuturesOption is an option on a future, tastytrade symbols name the future and the
+// expiration date while the streamer symbol names the exchange
+type FuturesOption struct {
+	// underlying future, /ESZ5
+	Future string
+	// option product, EW4 for the week 4 E-mini options
+	Product string
+	// contract month code and four digit year
+	Month byte
+	Year  int
+	// not part of the streamer symbol
+	Expiration time.Time
+	Type       string
+	Strike     float64
+	// not part of the tastytrade symbol
+	Exchange string
+}
+
+func (f FuturesOption) validate() error {
+	if f.Product == "" || f.Month == 0 {
+		return fmt.Errorf("futures option has no product or month")
+	}
+	if f.Type != Call && f.Type != Put {
+		return fmt.Errorf("option type must be %s or %s, got %q", Call, Put, f.Type)
+	}
+	if f.Strike <= 0 {
+		return fmt.Errorf("option strike must be positive, got %g", f.Strike)
+	}
+	return nil
+}
+
+// Tasty is the tastytrade symbol, ./ESZ5 EW4Z5 251219P5600
+func (f FuturesOption) Tasty() string {
+	return fmt.Sprintf("./%s %s%c%d %s%s%s", strings.TrimPrefix(f.Future, "/"), f.Product, f.Month, f.Year%10,
+		f.Expiration.Format("060102"), f.Type, formatStrike(f.Strike))
+}
+
+// Streamer is the DxLink symbol, ./EW4Z25P5600:XCME
+func (f FuturesOption) Streamer() string {
+	return fmt.Sprintf("./%s%c%02d%s%s:%s", f.Product, f.Month, f.Year%100, f.Type, formatStrike(f.Strike), f.Exchange)
+}
+
+var (
+	tastyFuturesPattern    = regexp.MustCompile(`^\./([A-Z0-9]+) ([A-Z0-9]+)([FGHJKMNQUVXZ])(\d) (\d{6})([CP])(\d+(?:\.\d+)?)$`)
+	streamerFuturesPattern = regexp.MustCompile(`^\./([A-Z0-9]+)([FGHJKMNQUVXZ])(\d{2})([CP])(\d+(?:\.\d+)?):([A-Z]+)$`)
+)
+
+// ParseTastyFuturesOption reads a tastytrade futures option symbol, the one digit
+// contract year is resolved to the first year on or after the expiration
+func ParseTastyFuturesOption(symbol string) (FuturesOption, error) {
+	m := tastyFuturesPattern.FindStringSubmatch(symbol)
+	if m == nil {
+		return FuturesOption{}, fmt.Errorf("unrecognized tasty futures option symbol %q", symbol)
+	}
+	exp, err := time.Parse("060102", m[5])
+	if err != nil {
+		return FuturesOption{}, fmt.Errorf("tasty symbol %q: unable to parse date: %w", symbol, err)
+	}
+	strike, err := strconv.ParseFloat(m[7], 64)
+	if err != nil {
+		return FuturesOption{}, fmt.Errorf("tasty symbol %q: unable to parse strike: %w", symbol, err)
+	}
+	digit := int(m[4][0] - '0')
+	year := exp.Year() - exp.Year()%10 + digit
+	if year < exp.Year() {
+		year += 10
+	}
+	f := FuturesOption{
+		Future:     "/" + m[1],
+		Product:    m[2],
+		Month:      m[3][0],
+		Year:       year,
+		Expiration: exp,
+		Type:       m[6],
+		Strike:     strike,
+	}
+	return f, f.validate()
+}
+
+// ParseStreamerFuturesOption reads a DxLink futures option symbol, the result has
+// no Future or Expiration
+func ParseStreamerFuturesOption(symbol string) (FuturesOption, error) {
+	m := streamerFuturesPattern.FindStringSubmatch(symbol)
+	if m == nil {
+		return FuturesOption{}, fmt.Errorf("unrecognized streamer futures option symbol %q", symbol)
+	}
+	yy, _ := strconv.Atoi(m[3])
+	strike, err := strconv.ParseFloat(m[5], 64)
+	if err != nil {
+		return FuturesOption{}, fmt.Errorf("streamer symbol %q: unable to parse strike: %w", symbol, err)
+	}
+	f := FuturesOption{
+		Product:  m[1],
+		Month:    m[2][0],
+		Year:     2000 + yy,
+		Type:     m[4],
+		Strike:   strike,
+		Exchange: m[6],
+	}
+	return f, f.validate()
+}
@@ -0,0 +1,154 @@
+// Package symbology converts option symbols between the OCC, DxLink streamer and
+// tastytrade formats
+package symbology
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+const (
+	Call = "C"
+	Put  = "P"
+)
+
+// weekly, PM settled and other roots that trade under a different underlying symbol
+var rootUnderlying = map[string]string{
+	"SPXW":  "SPX",
+	"SPXPM": "SPX",
+	"NDXP":  "NDX",
+	"RUTW":  "RUT",
+	"VIXW":  "VIX",
+	"BRKB":  "BRK/B",
+}
+
+// Option is a listed equity or index option independent of the symbol format
+type Option struct {
+	// option root, SPXW for the SPX weeklies or AAPL1 for an adjusted deliverable
+	Root       string
+	Expiration time.Time
+	Type       string
+	Strike     float64
+}
+
+// Underlying is the symbol the root's options deliver or settle against
+func Underlying(root string) string {
+	if u, ok := rootUnderlying[root]; ok {
+		return u
+	}
+	// adjusted deliverables add a digit to the root after a corporate action
+	return strings.TrimRightFunc(root, unicode.IsDigit)
+}
+
+func (o Option) Underlying() string {
+	return Underlying(o.Root)
+}
+
+// Adjusted is true for roots with a non-standard deliverable
+func (o Option) Adjusted() bool {
+	return o.Root != "" && unicode.IsDigit(rune(o.Root[len(o.Root)-1]))
+}
+
+func (o Option) validate() error {
+	if o.Root == "" {
+		return fmt.Errorf("option has no root")
+	}
+	if o.Type != Call && o.Type != Put {
+		return fmt.Errorf("option type must be %s or %s, got %q", Call, Put, o.Type)
+	}
+	if o.Strike <= 0 {
+		return fmt.Errorf("option strike must be positive, got %g", o.Strike)
+	}
+	return nil
+}
+
+// OCC is the 21 character OCC symbol, the root padded to 6 characters, yymmdd,
+// the type and the strike in thousandths padded to 8 digits. Tastytrade equity
+// and index option symbols use the same format.
+func (o Option) OCC() string {
+	strike := int64(math.Round(o.Strike * 1000))
+	return fmt.Sprintf("%-6s%s%s%08d", o.Root, o.Expiration.Format("060102"), o.Type, strike)
+}
+
+// Streamer is the DxLink symbol, fractional strikes keep their decimals
+func (o Option) Streamer() string {
+	return fmt.Sprintf(".%s%s%s%s", o.Root, o.Expiration.Format("060102"), o.Type, formatStrike(o.Strike))
+}
+
+func formatStrike(strike float64) string {
+	// rounded to the OCC precision so float noise doesn't leak into the symbol
+	return strconv.FormatFloat(math.Round(strike*1000)/1000, 'f', -1, 64)
+}
+
+// ParseOCC reads the fixed width OCC symbol, roots of 6 characters have no padding
+func ParseOCC(symbol string) (Option, error) {
+	if len(symbol) < 16 || len(symbol) > 21 {
+		return Option{}, fmt.Errorf("OCC symbol %q must be 16 to 21 characters", symbol)
+	}
+	tail := symbol[len(symbol)-15:]
+	o := Option{
+		Root: strings.TrimRight(symbol[:len(symbol)-15], " "),
+		Type: tail[6:7],
+	}
+	exp, err := time.Parse("060102", tail[:6])
+	if err != nil {
+		return Option{}, fmt.Errorf("OCC symbol %q: unable to parse date: %w", symbol, err)
+	}
+	o.Expiration = exp
+	strike, err := strconv.ParseInt(tail[7:], 10, 64)
+	if err != nil {
+		return Option{}, fmt.Errorf("OCC symbol %q: unable to parse strike: %w", symbol, err)
+	}
+	o.Strike = float64(strike) / 1000
+	if err := o.validate(); err != nil {
+		return Option{}, fmt.Errorf("OCC symbol %q: %w", symbol, err)
+	}
+	return o, nil
+}
+
+// the root is greedy, the date is always the last 6 digits before the type
+var streamerPattern = regexp.MustCompile(`^\.([A-Z0-9]+)(\d{6})([CP])(\d+(?:\.\d+)?)$`)
+
+// ParseStreamer reads a DxLink equity or index option symbol such as .SPXW250808P5600
+func ParseStreamer(symbol string) (Option, error) {
+	m := streamerPattern.FindStringSubmatch(symbol)
+	if m == nil {
+		return Option{}, fmt.Errorf("unrecognized streamer option symbol %q", symbol)
+	}
+	exp, err := time.Parse("060102", m[2])
+	if err != nil {
+		return Option{}, fmt.Errorf("streamer symbol %q: unable to parse date: %w", symbol, err)
+	}
+	strike, err := strconv.ParseFloat(m[4], 64)
+	if err != nil {
+		return Option{}, fmt.Errorf("streamer symbol %q: unable to parse strike: %w", symbol, err)
+	}
+	o := Option{Root: m[1], Expiration: exp, Type: m[3], Strike: strike}
+	if err := o.validate(); err != nil {
+		return Option{}, fmt.Errorf("streamer symbol %q: %w", symbol, err)
+	}
+	return o, nil
+}
+
+// StreamerToOCC converts a DxLink option symbol to its OCC symbol
+func StreamerToOCC(symbol string) (string, error) {
+	o, err := ParseStreamer(symbol)
+	if err != nil {
+		return "", err
+	}
+	return o.OCC(), nil
+}
+
+// OCCToStreamer converts an OCC or tastytrade equity option symbol to its DxLink symbol
+func OCCToStreamer(symbol string) (string, error) {
+	o, err := ParseOCC(symbol)
+	if err != nil {
+		return "", err
+	}
+	return o.Streamer(), nil
+}
@@ -0,0 +1,136 @@
+package symbology
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-playground/assert/v2"
+)
+
+func TestOptionRoundTrip(t *testing.T) {
+	tests := []struct {
+		name       string
+		occ        string
+		streamer   string
+		option     Option
+		underlying string
+	}{
+		{
+			name:       "equity",
+			occ:        "AAPL  230818C00197500",
+			streamer:   ".AAPL230818C197.5",
+			option:     Option{Root: "AAPL", Expiration: time.Date(2023, 8, 18, 0, 0, 0, 0, time.UTC), Type: Call, Strike: 197.5},
+			underlying: "AAPL",
+		},
+		{
+			name:       "fractional index strike",
+			occ:        "XSP   250808P00662500",
+			streamer:   ".XSP250808P662.5",
+			option:     Option{Root: "XSP", Expiration: time.Date(2025, 8, 8, 0, 0, 0, 0, time.UTC), Type: Put, Strike: 662.5},
+			underlying: "XSP",
+		},
+		{
+			name:       "weekly root",
+			occ:        "SPXW  250808P05600000",
+			streamer:   ".SPXW250808P5600",
+			option:     Option{Root: "SPXW", Expiration: time.Date(2025, 8, 8, 0, 0, 0, 0, time.UTC), Type: Put, Strike: 5600},
+			underlying: "SPX",
+		},
+		{
+			name:       "pm settled root",
+			occ:        "NDXP  251219C21000000",
+			streamer:   ".NDXP251219C21000",
+			option:     Option{Root: "NDXP", Expiration: time.Date(2025, 12, 19, 0, 0, 0, 0, time.UTC), Type: Call, Strike: 21000},
+			underlying: "NDX",
+		},
+		{
+			name:       "adjusted deliverable",
+			occ:        "AAPL1 250808C00200000",
+			streamer:   ".AAPL1250808C200",
+			option:     Option{Root: "AAPL1", Expiration: time.Date(2025, 8, 8, 0, 0, 0, 0, time.UTC), Type: Call, Strike: 200},
+			underlying: "AAPL",
+		},
+		{
+			name:       "six character root",
+			occ:        "GOOGL1250808P00012345",
+			streamer:   ".GOOGL1250808P12.345",
+			option:     Option{Root: "GOOGL1", Expiration: time.Date(2025, 8, 8, 0, 0, 0, 0, time.UTC), Type: Put, Strike: 12.345},
+			underlying: "GOOGL",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fromOCC, err := ParseOCC(tt.occ)
+			assert.Equal(t, err, nil)
+			assert.Equal(t, fromOCC, tt.option)
+			fromStreamer, err := ParseStreamer(tt.streamer)
+			assert.Equal(t, err, nil)
+			assert.Equal(t, fromStreamer, tt.option)
+
+			assert.Equal(t, tt.option.OCC(), tt.occ)
+			assert.Equal(t, tt.option.Streamer(), tt.streamer)
+			assert.Equal(t, tt.option.Underlying(), tt.underlying)
+
+			streamer, err := OCCToStreamer(tt.occ)
+			assert.Equal(t, err, nil)
+			assert.Equal(t, streamer, tt.streamer)
+			occ, err := StreamerToOCC(tt.streamer)
+			assert.Equal(t, err, nil)
+			assert.Equal(t, occ, tt.occ)
+		})
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	for _, sym := range []string{"", ".", ".XSP", ".XSP250808X600", ".XSP2508P600", "SPXW250808P5600", ".SPXW250808P"} {
+		_, err := ParseStreamer(sym)
+		assert.NotEqual(t, err, nil)
+	}
+	for _, sym := range []string{"", "AAPL", "AAPL  230818X00197500", "AAPL  231318C00197500", "AAPL  230818C0019750a", "       230818C00197500"} {
+		_, err := ParseOCC(sym)
+		assert.NotEqual(t, err, nil)
+	}
+}
+
+func TestFuturesOptionRoundTrip(t *testing.T) {
+	tests := []struct {
+		tasty    string
+		streamer string
+		option   FuturesOption
+	}{
+		{
+			tasty:    "./ESZ5 EW4Z5 251219P5600",
+			streamer: "./EW4Z25P5600:XCME",
+			option: FuturesOption{Future: "/ESZ5", Product: "EW4", Month: 'Z', Year: 2025,
+				Expiration: time.Date(2025, 12, 19, 0, 0, 0, 0, time.UTC), Type: Put, Strike: 5600, Exchange: "XCME"},
+		},
+		{
+			// the option expires the year before its January contract
+			tasty:    "./CLF6 LOF6 251216C62.5",
+			streamer: "./LOF26C62.5:XNYM",
+			option: FuturesOption{Future: "/CLF6", Product: "LO", Month: 'F', Year: 2026,
+				Expiration: time.Date(2025, 12, 16, 0, 0, 0, 0, time.UTC), Type: Call, Strike: 62.5, Exchange: "XNYM"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.tasty, func(t *testing.T) {
+			f, err := ParseTastyFuturesOption(tt.tasty)
+			assert.Equal(t, err, nil)
+			f.Exchange = tt.option.Exchange
+			assert.Equal(t, f, tt.option)
+			assert.Equal(t, f.Tasty(), tt.tasty)
+			assert.Equal(t, f.Streamer(), tt.streamer)
+
+			s, err := ParseStreamerFuturesOption(tt.streamer)
+			assert.Equal(t, err, nil)
+			assert.Equal(t, s.Streamer(), tt.streamer)
+			assert.Equal(t, s.Product, tt.option.Product)
+			assert.Equal(t, s.Year, tt.option.Year)
+		})
+	}
+
+	_, err := ParseTastyFuturesOption("./ESZ5 EW4Z5 251219X5600")
+	assert.NotEqual(t, err, nil)
+	_, err = ParseStreamerFuturesOption("./EW4Z25P5600")
+	assert.NotEqual(t, err, nil)
+}
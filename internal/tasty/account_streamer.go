@@ -5,12 +5,12 @@ import (
 	"encoding/json"
 	"fmt"
 	"log/slog"
-	"net/url"
 	"sync"
 	"time"
 
-	"github.com/gorilla/websocket"
+	"github.com/jamesonhm/gochain/internal/clock"
 	"github.com/jamesonhm/gochain/internal/dt"
+	"github.com/jamesonhm/gochain/internal/wsconn"
 )
 
 const (
@@ -20,19 +20,20 @@ const (
 
 type AccountStreamer struct {
 	acct           string
-	conn           *websocket.Conn
+	ws             *wsconn.Conn
 	url            string
 	token          string
-	connected      bool
 	messageCounter int
 	ctx            context.Context
 	cancel         context.CancelFunc
-	retries        int
-	delay          time.Duration
-	expBackoff     bool
 	mu             sync.RWMutex
-	orderQueue     chan Order
-	stratStatus    StatusUpdater
+	once           sync.Once
+	// order updates waiting for updateOrderState, queued without blocking the read loop
+	ordersMu    sync.Mutex
+	orders      []Order
+	ordersReady chan struct{}
+	stratStatus StatusUpdater
+	clock       clock.Clock
 }
 
 type ActionMsg struct {
@@ -59,6 +60,7 @@ func NewAccountStreamer(
 	acct string,
 	token string,
 	stratStatus StatusUpdater,
+	clk clock.Clock,
 	prod bool,
 ) *AccountStreamer {
 	ctx, cancel := context.WithCancel(ctx)
@@ -68,144 +70,125 @@ func NewAccountStreamer(
 	} else {
 		url = SandboxURL
 	}
-	return &AccountStreamer{
+	as := &AccountStreamer{
 		acct:           acct,
 		ctx:            ctx,
 		cancel:         cancel,
-		delay:          1 * time.Second,
-		expBackoff:     false,
-		retries:        3,
 		token:          token,
 		url:            url,
 		messageCounter: 1,
-		orderQueue:     make(chan Order, 10),
+		ordersReady:    make(chan struct{}, 1),
 		stratStatus:    stratStatus,
+		clock:          clk,
 	}
+	as.ws = as.newConn()
+	return as
 }
 
-func (as *AccountStreamer) Connect() error {
-	if as.connected {
-		return fmt.Errorf("client already connected")
-	}
-
-	u, err := url.Parse(as.url)
-	if err != nil {
-		return fmt.Errorf("invalid URL: %w", err)
-	}
-
-	conn, _, err := websocket.DefaultDialer.Dial(u.String(), nil)
-	if err != nil {
-		return fmt.Errorf("dial error for url: %s : %w", u.String(), err)
-	}
-	as.conn = conn
-	as.connected = true
-
-	// Start message handler
-	go as.handleMessages()
-	go as.keepAlive()
-
-	setupMsg := ActionMsg{
-		Action:    "connect",
-		Value:     as.acct,
-		AuthToken: as.token,
-		RequestID: as.messageCounter,
-	}
-
-	err = as.sendMessage(setupMsg)
-	if err != nil {
-		as.connected = false
-		as.conn.Close()
-		return fmt.Errorf("failed to send setup message: %w", err)
-	}
+func (as *AccountStreamer) newConn() *wsconn.Conn {
+	return wsconn.New(wsconn.Config{
+		Name:              "account-streamer " + as.acct,
+		URL:               as.url,
+		HeartbeatInterval: 30 * time.Second,
+		// every heartbeat is answered
+		ReadTimeout: 90 * time.Second,
+		MinBackoff:  time.Second,
+		MaxBackoff:  60 * time.Second,
+	}, wsconn.Handlers{
+		OnConnect: as.onConnect,
+		OnMessage: as.processMessage,
+		Heartbeat: func() any {
+			return as.actionMsg("heartbeat", "")
+		},
+	})
+}
 
-	return nil
+// conn is the current connection, replaced when Connect starts over
+func (as *AccountStreamer) conn() *wsconn.Conn {
+	as.mu.RLock()
+	defer as.mu.RUnlock()
+	return as.ws
 }
 
-func (as *AccountStreamer) Close() error {
+// SetToken replaces the session token used to authorize new connections
+func (as *AccountStreamer) SetToken(token string) {
 	as.mu.Lock()
 	defer as.mu.Unlock()
+	as.token = token
+}
 
-	if !as.connected {
-		return fmt.Errorf("client not connected")
-	}
-
-	as.cancel()
+func (as *AccountStreamer) State() wsconn.State {
+	return as.conn().State()
+}
 
-	err := as.conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
-	if err != nil {
-		slog.Error("Error sending close message", "err", err)
-	}
+// Events reports connection state changes
+func (as *AccountStreamer) Events() <-chan wsconn.Event {
+	return as.conn().Events()
+}
 
-	as.connected = false
-	err = as.conn.Close()
-	if err != nil {
-		return fmt.Errorf("error closing connection: %w", err)
-	}
+// Done is closed when the streamer is closed or stops reconnecting, see Err
+func (as *AccountStreamer) Done() <-chan struct{} {
+	return as.conn().Done()
+}
 
-	return nil
+func (as *AccountStreamer) Err() error {
+	return as.conn().Err()
 }
 
-func (as *AccountStreamer) sendMessage(msg ActionMsg) error {
+func (as *AccountStreamer) actionMsg(action string, value string) ActionMsg {
 	as.mu.Lock()
 	defer as.mu.Unlock()
-
-	if as.conn == nil {
-		return fmt.Errorf("unable to send message, no connection")
-	}
-
 	as.messageCounter++
-	data, err := json.Marshal(msg)
-	if err != nil {
-		return fmt.Errorf("error marshaling message: %w", err)
+	return ActionMsg{
+		Action:    action,
+		Value:     value,
+		AuthToken: as.token,
+		RequestID: as.messageCounter,
 	}
+}
 
-	slog.Info("ACCT STREAMER ->", "", msg)
-
-	err = as.conn.WriteMessage(websocket.TextMessage, data)
-	if err != nil {
-		return fmt.Errorf("error sending message: %w", err)
+// Connect opens the connection, it is reopened and the account resubscribed after
+// any failure until the streamer is closed. A streamer that gave up reconnecting
+// starts over on a new connection
+func (as *AccountStreamer) Connect() error {
+	as.mu.Lock()
+	select {
+	case <-as.ws.Done():
+		if as.ctx.Err() == nil {
+			as.ws = as.newConn()
+		}
+	default:
 	}
+	ws := as.ws
+	as.mu.Unlock()
 
+	if err := ws.Start(as.ctx); err != nil {
+		return err
+	}
+	as.once.Do(func() { go as.updateOrderState() })
 	return nil
 }
 
-func (as *AccountStreamer) keepAlive() {
-	for {
-		select {
-		case <-as.ctx.Done():
-			return
-		default:
-			time.Sleep(30 * time.Second)
-			as.sendMessage(ActionMsg{
-				Action:    "heartbeat",
-				AuthToken: as.token,
-				RequestID: as.messageCounter,
-			})
-		}
+// onConnect subscribes to the account on each new connection with the current token
+func (as *AccountStreamer) onConnect(_ *wsconn.Conn) error {
+	if err := as.sendMessage(as.actionMsg("connect", as.acct)); err != nil {
+		return fmt.Errorf("failed to send setup message: %w", err)
 	}
+	return nil
 }
 
-// handleMessages reads and processes incoming
-func (as *AccountStreamer) handleMessages() {
-	for {
-		select {
-		case <-as.ctx.Done():
-			return
-		default:
-			if as.conn == nil {
-				time.Sleep(100 * time.Millisecond)
-				continue
-			}
-
-			_, message, err := as.conn.ReadMessage()
-			if err != nil {
-				slog.Error("Error reading AcctStreamer message", "err", err)
-				continue
-			}
-
-			go as.processMessage(message)
-		}
+func (as *AccountStreamer) Close() error {
+	err := as.conn().Close()
+	as.cancel()
+	if err != nil {
+		return fmt.Errorf("error closing connection: %w", err)
 	}
+	return nil
+}
+
+func (as *AccountStreamer) sendMessage(msg ActionMsg) error {
+	slog.Info("ACCT STREAMER ->", "action", msg.Action, "request id", msg.RequestID)
+	return as.conn().Send(msg)
 }
 
 func (as *AccountStreamer) processMessage(message []byte) {
@@ -235,6 +218,11 @@ func (as *AccountStreamer) processMessage(message []byte) {
 			return
 		}
 		slog.Info("ACCT STREAMER <-", "", resp)
+		if resp.Status == "ok" {
+			as.conn().MarkReady()
+		} else {
+			slog.Error("account streamer connect rejected", "account", as.acct, "status", resp.Status)
+		}
 	case "heartbeat":
 		resp := ConnectRespMsg{}
 		err := json.Unmarshal(message, &resp)
@@ -259,25 +247,53 @@ func (as *AccountStreamer) processMessage(message []byte) {
 		if err == nil {
 			fmt.Println(string(b))
 		}
-		as.orderQueue <- resp.Order
+		as.queueOrder(resp.Order)
 	}
 }
 
-func (as *AccountStreamer) updateOrderState(order Order) {
+// queueOrder hands an order update to updateOrderState, the queue grows rather than
+// dropping an update or holding up the read loop
+func (as *AccountStreamer) queueOrder(order Order) {
+	as.ordersMu.Lock()
+	as.orders = append(as.orders, order)
+	as.ordersMu.Unlock()
+	select {
+	case as.ordersReady <- struct{}{}:
+	default:
+	}
+}
+
+// takeOrders empties the queue, in the order the updates were received
+func (as *AccountStreamer) takeOrders() []Order {
+	as.ordersMu.Lock()
+	defer as.ordersMu.Unlock()
+	orders := as.orders
+	as.orders = nil
+	return orders
+}
+
+func (as *AccountStreamer) updateOrderState() {
 	// get source = strat name or desktop app version
 	// get preflightID
-	for order := range as.orderQueue {
-		if order.Source == "" {
-			slog.Info("order update with no source. order id: %s", order.ID)
-			continue
-		}
-		if order.PreflightID == "" {
-			slog.Info("order update with no preflightID. order id: %s", order.ID)
-			continue
+	for {
+		select {
+		case <-as.ctx.Done():
+			return
+		case <-as.ordersReady:
 		}
-		err := as.stratStatus.UpdateOrder(order.Source, time.Now().In(dt.TZNY()), order.PreflightID, order)
-		if err != nil {
-			slog.Error("unable to update order from streamer: %w", err)
+		for _, order := range as.takeOrders() {
+			if order.Source == "" {
+				slog.Info("order update with no source", "order id", order.ID)
+				continue
+			}
+			if order.PreflightID == "" {
+				slog.Info("order update with no preflightID", "order id", order.ID)
+				continue
+			}
+			err := as.stratStatus.UpdateOrder(order.Source, as.clock.Now().In(dt.TZNY()), order.PreflightID, order)
+			if err != nil {
+				slog.Error("unable to update order from streamer", "error", err)
+			}
 		}
 	}
 }
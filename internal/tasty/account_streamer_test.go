@@ -0,0 +1,69 @@
+package tasty
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-playground/assert/v2"
+	"github.com/jamesonhm/gochain/internal/clock"
+)
+
+// blockingStatus holds every update until release is closed
+type blockingStatus struct {
+	release chan struct{}
+	mu      sync.Mutex
+	ids     []int
+	times   []time.Time
+}
+
+func (b *blockingStatus) UpdateOrder(_ string, ts time.Time, _ string, order Order) error {
+	<-b.release
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.ids = append(b.ids, order.ID)
+	b.times = append(b.times, ts)
+	return nil
+}
+
+func (b *blockingStatus) count() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.ids)
+}
+
+func TestOrderUpdatesDontBlockReads(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	status := &blockingStatus{release: make(chan struct{})}
+	now := time.Date(2025, 8, 1, 10, 0, 0, 0, time.UTC)
+	as := NewAccountStreamer(ctx, "ACCT", "", status, clock.NewFixed(now), false)
+	go as.updateOrderState()
+
+	// a slow state store doesn't hold up the read loop
+	read := make(chan struct{})
+	go func() {
+		for id := 1; id <= 50; id++ {
+			as.processMessage([]byte(fmt.Sprintf(`{"type":"Order","data":{"id":%d,"source":"s","preflight-id":"%d"}}`, id, id)))
+		}
+		close(read)
+	}()
+	select {
+	case <-read:
+	case <-time.After(5 * time.Second):
+		t.Fatal("order updates blocked the read loop")
+	}
+
+	close(status.release)
+	deadline := time.Now().Add(5 * time.Second)
+	for status.count() < 50 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	assert.Equal(t, status.count(), 50)
+	assert.Equal(t, status.ids[0], 1)
+	assert.Equal(t, status.ids[49], 50)
+	// updates are stamped with the engine clock
+	assert.Equal(t, status.times[0].Equal(now), true)
+}
@@ -22,7 +22,7 @@ type BacktestSessionResponse struct {
 func (c *TastyAPI) BacktestSession(ctx context.Context) (*BacktestSessionResponse, error) {
 	backtestURL := fmt.Sprintf("%s%s", backtestURL, backtestSessionPath)
 	authData := BacktestRequest{
-		TastyToken: *c.session.Data.SessionToken,
+		TastyToken: c.GetToken(),
 	}
 
 	btSession := &BacktestSessionResponse{}
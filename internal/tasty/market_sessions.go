@@ -11,17 +11,32 @@ const (
 	MarketSessionHolidaysPath = "/market-time/equities/holidays"
 )
 
-func (c *TastyAPI) GetMarketHolidays(ctx context.Context) ([]HolidayDate, error) {
+func (c *TastyAPI) getMarketSessions(ctx context.Context) (*MarketHolidayResponse, error) {
 	res := &MarketHolidayResponse{}
 	path := c.baseurl + MarketSessionHolidaysPath
 	err := c.request(ctx, http.MethodGet, auth, path, nil, nil, res)
 	if err != nil {
 		return nil, err
 	}
-	holidays := res.Data.MarketHolidays
-	half_days := res.Data.MarketHalfDays
-	holidays = append(holidays, half_days...)
-	return holidays, nil
+	return res, nil
+}
+
+// GetMarketHolidays returns the full day closures, half days trade and are left out
+func (c *TastyAPI) GetMarketHolidays(ctx context.Context) ([]HolidayDate, error) {
+	res, err := c.getMarketSessions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return res.Data.MarketHolidays, nil
+}
+
+// GetMarketCalendar returns the holidays and the early close half days
+func (c *TastyAPI) GetMarketCalendar(ctx context.Context) ([]time.Time, []time.Time, error) {
+	res, err := c.getMarketSessions(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	return holidayTimes(res.Data.MarketHolidays), holidayTimes(res.Data.MarketHalfDays), nil
 }
 
 func (c *TastyAPI) GetMarketHolidaysDT(ctx context.Context) ([]time.Time, error) {
@@ -29,11 +44,15 @@ func (c *TastyAPI) GetMarketHolidaysDT(ctx context.Context) ([]time.Time, error)
 	if err != nil {
 		return nil, err
 	}
+	return holidayTimes(holidays), nil
+}
+
+func holidayTimes(hds []HolidayDate) []time.Time {
 	var ts []time.Time
-	for _, h := range holidays {
+	for _, h := range hds {
 		ts = append(ts, time.Time(h))
 	}
-	return ts, nil
+	return ts
 }
 
 type MarketHolidayResponse struct {
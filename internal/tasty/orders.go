@@ -3,12 +3,15 @@ package tasty
 import (
 	"context"
 	"net/http"
+	"strconv"
 	"strings"
 )
 
 const (
 	DryRunOrderPath = "/accounts/{account_number}/orders/dry-run"
 	OrderPath       = "/accounts/{account_number}/orders"
+	LiveOrdersPath  = "/accounts/{account_number}/orders/live"
+	OrderIDPath     = "/accounts/{account_number}/orders/{id}"
 )
 
 func (c *TastyAPI) SubmitOrderDryRun(ctx context.Context, acctNum string, order *NewOrder) (*SubmitOrderResponse, error) {
@@ -26,3 +29,31 @@ func (c *TastyAPI) SubmitOrder(ctx context.Context, acctNum string, order *NewOr
 	err := c.request(ctx, http.MethodPost, auth, path, nil, order, res)
 	return res, err
 }
+
+// GetLiveOrders returns all orders for the account that are working or were updated today
+func (c *TastyAPI) GetLiveOrders(ctx context.Context, acctNum string) ([]Order, error) {
+	res := &OrdersResponse{}
+	path := c.baseurl + LiveOrdersPath
+	path = strings.ReplaceAll(path, "{account_number}", acctNum)
+	err := c.request(ctx, http.MethodGet, auth, path, nil, nil, res)
+	return res.Data.Orders, err
+}
+
+// GetOrders returns a page of the account order history filtered by params
+func (c *TastyAPI) GetOrders(ctx context.Context, acctNum string, params *OrdersParams) ([]Order, error) {
+	res := &OrdersResponse{}
+	path := c.baseurl + OrderPath
+	path = strings.ReplaceAll(path, "{account_number}", acctNum)
+	err := c.request(ctx, http.MethodGet, auth, path, params, nil, res)
+	return res.Data.Orders, err
+}
+
+// CancelOrder requests cancellation of a working order, the returned order reflects the cancel request
+func (c *TastyAPI) CancelOrder(ctx context.Context, acctNum string, id int) (*Order, error) {
+	res := &OrderDataResponse{}
+	path := c.baseurl + OrderIDPath
+	path = strings.ReplaceAll(path, "{account_number}", acctNum)
+	path = strings.ReplaceAll(path, "{id}", strconv.Itoa(id))
+	err := c.request(ctx, http.MethodDelete, auth, path, nil, nil, res)
+	return &res.Order, err
+}
@@ -98,6 +98,28 @@ type OrderPriceComponent struct {
 	QuantityDirection Direction      `json:"quantity-direction"`
 }
 
+type OrdersParams struct {
+	// Dates in the format "2006-01-02"
+	StartDate        string        `url:"start-date,omitempty"`
+	EndDate          string        `url:"end-date,omitempty"`
+	UnderlyingSymbol string        `url:"underlying-symbol,omitempty"`
+	Status           []OrderStatus `url:"status[],omitempty"`
+	PerPage          int           `url:"per-page,omitempty"`
+	PageOffset       int           `url:"page-offset,omitempty"`
+	Sort             SortOrder     `url:"sort,omitempty"`
+}
+
+type OrdersResponse struct {
+	Data struct {
+		Orders []Order `json:"items"`
+	} `json:"data"`
+	Pagination interface{} `json:"pagination"`
+}
+
+type OrderDataResponse struct {
+	Order Order `json:"data"`
+}
+
 type NewOrder struct {
 	TimeInForce        TimeInForce   `json:"time-in-force"`
 	GtcDate            string        `json:"gtc-date"`
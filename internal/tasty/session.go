@@ -2,13 +2,19 @@ package tasty
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log/slog"
 	"net/http"
+	"os"
+	"strings"
 	"time"
 )
 
 const (
 	SessionURL = "/sessions"
+	// quote streamer tokens are valid for 24 hours
+	QuoteTokenTTL = 24 * time.Hour
 )
 
 type User struct {
@@ -21,6 +27,8 @@ type Data struct {
 	User              *User     `json:"user"`
 	SessionToken      *string   `json:"session-token"`
 	SessionExpiration time.Time `json:"session-expiration"`
+	// single use, returned when logging in with remember-me
+	RememberToken *string `json:"remember-token"`
 }
 
 type Session struct {
@@ -35,6 +43,50 @@ type LoginInfo struct {
 	RememberToken string `json:"remember-token,omitempty"`
 }
 
+// TokenStore persists the remember token between runs
+type TokenStore interface {
+	Load() (string, error)
+	Save(string) error
+}
+
+// FileTokenStore keeps the remember token in a file only the user can read
+type FileTokenStore string
+
+func (f FileTokenStore) Load() (string, error) {
+	b, err := os.ReadFile(string(f))
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(b)), nil
+}
+
+func (f FileTokenStore) Save(token string) error {
+	return os.WriteFile(string(f), []byte(token), 0600)
+}
+
+// SetTokenStore persists the remember token of each login, later logins use it
+// before the password
+func (c *TastyAPI) SetTokenStore(store TokenStore) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.tokens = store
+}
+
+// OnSessionToken registers fn to be called with each new session token
+func (c *TastyAPI) OnSessionToken(fn func(string)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.sessionListeners = append(c.sessionListeners, fn)
+}
+
+// OnQuoteToken registers fn to be called with each new quote streamer token
+func (c *TastyAPI) OnQuoteToken(fn func(QuoteStreamerToken)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.quoteListeners = append(c.quoteListeners, fn)
+}
+
+// CreateSession logs in once with the login given, see Login for a session that is renewed
 func (c *TastyAPI) CreateSession(ctx context.Context, login LoginInfo) error {
 	authURL := fmt.Sprintf("%s%s", c.baseurl, SessionURL)
 
@@ -43,6 +95,144 @@ func (c *TastyAPI) CreateSession(ctx context.Context, login LoginInfo) error {
 	if err != nil {
 		return err
 	}
+	if session.Data == nil || session.Data.SessionToken == nil {
+		return fmt.Errorf("login response has no session token")
+	}
+	c.mu.Lock()
 	c.session = session
+	tokens := c.tokens
+	listeners := c.sessionListeners
+	c.mu.Unlock()
+
+	if tokens != nil && session.Data.RememberToken != nil {
+		if err := tokens.Save(*session.Data.RememberToken); err != nil {
+			slog.Error("unable to save remember token", "error", err)
+		}
+	}
+	for _, fn := range listeners {
+		fn(*session.Data.SessionToken)
+	}
 	return nil
 }
+
+// Login keeps the login to renew the session with, then logs in with the saved
+// remember token, falling back to the password
+func (c *TastyAPI) Login(ctx context.Context, login LoginInfo) error {
+	c.mu.Lock()
+	login.RememberMe = login.RememberMe || c.tokens != nil
+	c.login = login
+	c.mu.Unlock()
+
+	c.authMu.Lock()
+	defer c.authMu.Unlock()
+	return c.renew(ctx)
+}
+
+// renew logs in again, callers hold authMu
+func (c *TastyAPI) renew(ctx context.Context) error {
+	c.mu.RLock()
+	login, tokens := c.login, c.tokens
+	c.mu.RUnlock()
+	if login.Login == "" {
+		return fmt.Errorf("no login to renew the session with")
+	}
+	if tokens != nil {
+		if token, err := tokens.Load(); err == nil && token != "" {
+			err := c.CreateSession(ctx, LoginInfo{Login: login.Login, RememberMe: true, RememberToken: token})
+			if err == nil {
+				return nil
+			}
+			slog.Warn("remember token login failed, using password", "error", err)
+		}
+	}
+	if login.Password == "" {
+		return fmt.Errorf("no password to renew the session with")
+	}
+	return c.CreateSession(ctx, login)
+}
+
+// reauthenticate renews a session rejected with the stale token, unless another
+// request already renewed it
+func (c *TastyAPI) reauthenticate(ctx context.Context, stale string) error {
+	c.authMu.Lock()
+	defer c.authMu.Unlock()
+	if token, ok := c.sessionToken(); ok && token != stale {
+		return nil
+	}
+	return c.renew(ctx)
+}
+
+func (c *TastyAPI) sessionToken() (string, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.session == nil || c.session.Data == nil || c.session.Data.SessionToken == nil {
+		return "", false
+	}
+	return *c.session.Data.SessionToken, true
+}
+
+// renewals are the times the session and the quote token should be renewed, zero
+// when there is nothing to renew
+func (c *TastyAPI) renewals(lead time.Duration) (time.Time, time.Time) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	var session, quote time.Time
+	if c.session != nil && c.session.Data != nil && !c.session.Data.SessionExpiration.IsZero() {
+		session = c.session.Data.SessionExpiration.Add(-lead)
+	}
+	if c.quote != nil {
+		quote = c.quoteAt.Add(QuoteTokenTTL - lead)
+	}
+	return session, quote
+}
+
+// KeepAlive renews the session and the quote token lead before they expire, new
+// tokens are passed to the listeners. Runs until ctx is done
+func (c *TastyAPI) KeepAlive(ctx context.Context, lead time.Duration) {
+	for {
+		session, quote := c.renewals(lead)
+		// checked at least hourly, timers don't run while the machine sleeps
+		next := time.Now().Add(time.Hour)
+		for _, t := range []time.Time{session, quote} {
+			if !t.IsZero() && t.Before(next) {
+				next = t
+			}
+		}
+		timer := time.NewTimer(max(time.Until(next), 0))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		var errs []error
+		now := time.Now()
+		if !session.IsZero() && !now.Before(session) {
+			c.authMu.Lock()
+			if err := c.renew(ctx); err != nil {
+				errs = append(errs, fmt.Errorf("session: %w", err))
+			} else {
+				slog.Info("tasty session renewed")
+			}
+			c.authMu.Unlock()
+		}
+		if !quote.IsZero() && !now.Before(quote) {
+			if _, err := c.GetQuoteStreamerToken(ctx); err != nil {
+				errs = append(errs, fmt.Errorf("quote token: %w", err))
+			} else {
+				slog.Info("quote streamer token renewed")
+			}
+		}
+		if err := errors.Join(errs...); err != nil {
+			slog.Error("tasty token renewal failed, retrying", "error", err)
+			retry := time.NewTimer(time.Minute)
+			select {
+			case <-ctx.Done():
+				retry.Stop()
+				return
+			case <-retry.C:
+			}
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package tasty
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-playground/assert/v2"
+)
+
+// fakeAuth hands out numbered session and remember tokens and accepts only the
+// latest session token
+type fakeAuth struct {
+	mu       sync.Mutex
+	valid    string
+	remember string
+	logins   []string
+}
+
+func (f *fakeAuth) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	switch r.URL.Path {
+	case SessionURL:
+		var login LoginInfo
+		json.NewDecoder(r.Body).Decode(&login)
+		switch {
+		case login.RememberToken != "" && login.RememberToken == f.remember:
+			f.logins = append(f.logins, "remember")
+		case login.Password == "pw":
+			f.logins = append(f.logins, "password")
+		default:
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		n := strconv.Itoa(len(f.logins))
+		f.valid, f.remember = "s"+n, "r"+n
+		json.NewEncoder(w).Encode(map[string]any{"data": map[string]any{
+			"session-token":      f.valid,
+			"remember-token":     f.remember,
+			"session-expiration": time.Now().Add(24 * time.Hour),
+		}})
+	case StreamingPath:
+		if r.Header.Get("Authorization") != f.valid {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]any{"data": map[string]any{"token": "q-" + f.valid}})
+	}
+}
+
+func (f *fakeAuth) expire() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.valid = ""
+}
+
+func TestSessionReauthenticates(t *testing.T) {
+	auth := &fakeAuth{}
+	srv := httptest.NewServer(auth)
+	defer srv.Close()
+
+	c := New(5*time.Second, time.Second, 100, TastySandbox)
+	c.baseurl = srv.URL
+	store := FileTokenStore(filepath.Join(t.TempDir(), "remember_token"))
+	c.SetTokenStore(store)
+	var sessions, quotes []string
+	c.OnSessionToken(func(token string) { sessions = append(sessions, token) })
+	c.OnQuoteToken(func(token QuoteStreamerToken) { quotes = append(quotes, token.Token) })
+
+	ctx := context.Background()
+	assert.Equal(t, c.Login(ctx, LoginInfo{Login: "user", Password: "pw"}), nil)
+	saved, _ := store.Load()
+	assert.Equal(t, saved, "r1")
+
+	// the expired session is renewed with the remember token and the call retried
+	auth.expire()
+	token, err := c.GetQuoteStreamerToken(ctx)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, token.Token, "q-s2")
+	assert.Equal(t, auth.logins, []string{"password", "remember"})
+	assert.Equal(t, sessions, []string{"s1", "s2"})
+	assert.Equal(t, quotes, []string{"q-s2"})
+	saved, _ = store.Load()
+	assert.Equal(t, saved, "r2")
+
+	// a rejected remember token falls back to the password
+	store.Save("stale")
+	auth.expire()
+	_, err = c.GetQuoteStreamerToken(ctx)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, auth.logins[len(auth.logins)-1], "password")
+
+	// retried once, the auth failure is returned when the login fails too
+	c.login.Password = "wrong"
+	store.Save("stale")
+	auth.expire()
+	_, err = c.GetQuoteStreamerToken(ctx)
+	var apiErr *APIError
+	assert.Equal(t, errors.As(err, &apiErr), true)
+	assert.Equal(t, apiErr.StatusCode, http.StatusUnauthorized)
+}
@@ -3,15 +3,28 @@ package tasty
 import (
 	"context"
 	"net/http"
+	"time"
 )
 
 const (
 	StreamingPath = "/api-quote-tokens"
 )
 
+// GetQuoteStreamerToken fetches a new quote token and passes it to the listeners, see OnQuoteToken
 func (c *TastyAPI) GetQuoteStreamerToken(ctx context.Context) (*QuoteStreamerToken, error) {
 	res := &QuoteStreamerTokenResult{}
 	path := c.baseurl + StreamingPath
 	err := c.request(ctx, http.MethodGet, auth, path, nil, nil, res)
-	return &res.QuoteStreamerToken, err
+	if err != nil {
+		return &res.QuoteStreamerToken, err
+	}
+	token := res.QuoteStreamerToken
+	c.mu.Lock()
+	c.quote, c.quoteAt = &token, time.Now()
+	listeners := c.quoteListeners
+	c.mu.Unlock()
+	for _, fn := range listeners {
+		fn(token)
+	}
+	return &token, nil
 }
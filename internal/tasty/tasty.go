@@ -4,9 +4,11 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
+	"sync"
 	"time"
 
 	"github.com/google/go-querystring/query"
@@ -34,9 +36,20 @@ const (
 )
 
 type TastyAPI struct {
-	baseurl    string
-	session    *Session
-	httpClient *http.Client
+	baseurl string
+	// guards the session, tokens and listeners
+	mu      sync.RWMutex
+	session *Session
+	// kept to renew the session, see Login
+	login  LoginInfo
+	tokens TokenStore
+	// serializes logins so concurrent auth failures renew the session once
+	authMu           sync.Mutex
+	quote            *QuoteStreamerToken
+	quoteAt          time.Time
+	sessionListeners []func(string)
+	quoteListeners   []func(QuoteStreamerToken)
+	httpClient       *http.Client
 	//uriBuilder *uri.URIBuilder
 	limiter *rate.Limiter
 	Env     TastyEnv
@@ -67,6 +80,20 @@ func New(timeout time.Duration, rate_period time.Duration, rate_count int, env T
 //	return encPath
 //}
 
+// APIError is a 4xx or 5xx response
+type APIError struct {
+	StatusCode int
+}
+
+func (e *APIError) Error() string {
+	if e.StatusCode >= 500 {
+		return fmt.Sprintf("server error occurred, status code: %d", e.StatusCode)
+	}
+	return fmt.Sprintf("client error occurred, status code: %d", e.StatusCode)
+}
+
+// request calls the api, a request rejected as unauthorized renews the session and
+// is retried once
 func (c *TastyAPI) request(
 	ctx context.Context,
 	method string,
@@ -75,13 +102,37 @@ func (c *TastyAPI) request(
 	params,
 	payload,
 	response any,
+) error {
+	token, _ := c.sessionToken()
+	err := c.do(ctx, method, auth, token, path, params, payload, response)
+	var apiErr *APIError
+	if auth == noAuth || !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusUnauthorized {
+		return err
+	}
+	slog.Warn("tasty session rejected, re-authenticating", "URL", path)
+	if rerr := c.reauthenticate(ctx, token); rerr != nil {
+		return fmt.Errorf("%w, re-authentication failed: %w", err, rerr)
+	}
+	token, _ = c.sessionToken()
+	return c.do(ctx, method, auth, token, path, params, payload, response)
+}
+
+func (c *TastyAPI) do(
+	ctx context.Context,
+	method string,
+	auth AuthReq,
+	token string,
+	path string,
+	params,
+	payload,
+	response any,
 ) error {
 	err := c.limiter.Wait(ctx)
 	if err != nil {
 		return err
 	}
 
-	if auth && c.session.Data.SessionToken == nil {
+	if auth && token == "" {
 		return fmt.Errorf("invalid session")
 	}
 
@@ -96,7 +147,7 @@ func (c *TastyAPI) request(
 	}
 
 	if auth {
-		req.Header.Add("Authorization", *c.session.Data.SessionToken)
+		req.Header.Add("Authorization", token)
 	}
 	req.Header.Add("Content-Type", "application/json")
 	req.Header.Add("User-Agent", "gochain-client/0.1")
@@ -123,11 +174,8 @@ func (c *TastyAPI) request(
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode >= 400 && resp.StatusCode < 500 {
-		return fmt.Errorf("client error occurred, status code: %d", resp.StatusCode)
-	}
-	if resp.StatusCode >= 500 {
-		return fmt.Errorf("server error occurred, status code: %d", resp.StatusCode)
+	if resp.StatusCode >= 400 {
+		return &APIError{StatusCode: resp.StatusCode}
 	}
 
 	if err := json.NewDecoder(resp.Body).Decode(response); err != nil {
@@ -138,15 +186,18 @@ func (c *TastyAPI) request(
 }
 
 func (c *TastyAPI) GetUser() string {
-	if c.session == nil {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.session == nil || c.session.Data.User == nil || c.session.Data.User.Username == nil {
 		return "NOT LOGGED IN"
 	}
 	return *c.session.Data.User.Username
 }
 
 func (c *TastyAPI) GetToken() string {
-	if c.session == nil {
+	token, ok := c.sessionToken()
+	if !ok {
 		return "NOT LOGGED IN"
 	}
-	return *c.session.Data.SessionToken
+	return token
 }
@@ -0,0 +1,43 @@
+package tasty
+
+import (
+	"context"
+	"net/http"
+	"strings"
+)
+
+const (
+	TransactionsPath = "/accounts/{account_number}/transactions"
+)
+
+// GetTransactions returns a page of the account transactions filtered by params
+func (c *TastyAPI) GetTransactions(ctx context.Context, acctNum string, params *TransactionsParams) ([]Transaction, Pagination, error) {
+	res := &TransactionsResponse{}
+	path := c.baseurl + TransactionsPath
+	path = strings.ReplaceAll(path, "{account_number}", acctNum)
+	err := c.request(ctx, http.MethodGet, auth, path, params, nil, res)
+	return res.Data.Transactions, res.Pagination, err
+}
+
+// GetAllTransactions follows the pagination from params.PageOffset to the last page
+func (c *TastyAPI) GetAllTransactions(ctx context.Context, acctNum string, params *TransactionsParams) ([]Transaction, error) {
+	p := TransactionsParams{}
+	if params != nil {
+		p = *params
+	}
+	if p.PerPage == 0 {
+		p.PerPage = 250
+	}
+	var all []Transaction
+	for {
+		txns, page, err := c.GetTransactions(ctx, acctNum, &p)
+		if err != nil {
+			return all, err
+		}
+		all = append(all, txns...)
+		if len(txns) == 0 || p.PageOffset+1 >= page.TotalPages {
+			return all, nil
+		}
+		p.PageOffset++
+	}
+}
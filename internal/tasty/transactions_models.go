@@ -0,0 +1,102 @@
+package tasty
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+type TransactionType string
+
+const (
+	TradeTT          TransactionType = "Trade"
+	ReceiveDeliverTT TransactionType = "Receive Deliver"
+	MoneyMovementTT  TransactionType = "Money Movement"
+)
+
+type TransactionsParams struct {
+	// Dates in the format "2006-01-02"
+	StartDate        string            `url:"start-date,omitempty"`
+	EndDate          string            `url:"end-date,omitempty"`
+	Types            []TransactionType `url:"types[],omitempty"`
+	SubTypes         []string          `url:"sub-type[],omitempty"`
+	InstrumentType   InstrumentType    `url:"instrument-type,omitempty"`
+	Symbol           string            `url:"symbol,omitempty"`
+	UnderlyingSymbol string            `url:"underlying-symbol,omitempty"`
+	PerPage          int               `url:"per-page,omitempty"`
+	PageOffset       int               `url:"page-offset,omitempty"`
+	Sort             SortOrder         `url:"sort,omitempty"`
+}
+
+type Pagination struct {
+	PerPage          int `json:"per-page"`
+	PageOffset       int `json:"page-offset"`
+	ItemOffset       int `json:"item-offset"`
+	TotalItems       int `json:"total-items"`
+	TotalPages       int `json:"total-pages"`
+	CurrentItemCount int `json:"current-item-count"`
+}
+
+type TransactionsResponse struct {
+	Data struct {
+		Transactions []Transaction `json:"items"`
+	} `json:"data"`
+	Pagination Pagination `json:"pagination"`
+}
+
+type Transaction struct {
+	ID                               int             `json:"id"`
+	AccountNumber                    string          `json:"account-number"`
+	Symbol                           string          `json:"symbol"`
+	InstrumentType                   InstrumentType  `json:"instrument-type"`
+	UnderlyingSymbol                 string          `json:"underlying-symbol"`
+	TransactionType                  TransactionType `json:"transaction-type"`
+	TransactionSubType               string          `json:"transaction-sub-type"`
+	Description                      string          `json:"description"`
+	Action                           OrderAction     `json:"action"`
+	Quantity                         decimal.Decimal `json:"quantity"`
+	Price                            decimal.Decimal `json:"price"`
+	ExecutedAt                       time.Time       `json:"executed-at"`
+	TransactionDate                  string          `json:"transaction-date"`
+	Value                            decimal.Decimal `json:"value"`
+	ValueEffect                      PriceEffect     `json:"value-effect"`
+	RegulatoryFees                   decimal.Decimal `json:"regulatory-fees"`
+	RegulatoryFeesEffect             PriceEffect     `json:"regulatory-fees-effect"`
+	ClearingFees                     decimal.Decimal `json:"clearing-fees"`
+	ClearingFeesEffect               PriceEffect     `json:"clearing-fees-effect"`
+	Commission                       decimal.Decimal `json:"commission"`
+	CommissionEffect                 PriceEffect     `json:"commission-effect"`
+	ProprietaryIndexOptionFees       decimal.Decimal `json:"proprietary-index-option-fees"`
+	ProprietaryIndexOptionFeesEffect PriceEffect     `json:"proprietary-index-option-fees-effect"`
+	NetValue                         decimal.Decimal `json:"net-value"`
+	NetValueEffect                   PriceEffect     `json:"net-value-effect"`
+	IsEstimatedFee                   bool            `json:"is-estimated-fee"`
+	OrderID                          int             `json:"order-id"`
+	LegCount                         int             `json:"leg-count"`
+	DestinationVenue                 string          `json:"destination-venue"`
+	ExecID                           string          `json:"exec-id"`
+	ExtGroupFillID                   string          `json:"ext-group-fill-id"`
+}
+
+func signedAmount(amt decimal.Decimal, effect PriceEffect) decimal.Decimal {
+	switch effect {
+	case Credit:
+		return amt.Abs()
+	case Debit:
+		return amt.Abs().Neg()
+	}
+	return decimal.Zero
+}
+
+// Cash is the signed value of the transaction before fees, credits positive
+func (t Transaction) Cash() decimal.Decimal {
+	return signedAmount(t.Value, t.ValueEffect)
+}
+
+// Fees is the signed sum of all fees and commissions, debits negative
+func (t Transaction) Fees() decimal.Decimal {
+	return signedAmount(t.RegulatoryFees, t.RegulatoryFeesEffect).
+		Add(signedAmount(t.ClearingFees, t.ClearingFeesEffect)).
+		Add(signedAmount(t.Commission, t.CommissionEffect)).
+		Add(signedAmount(t.ProprietaryIndexOptionFees, t.ProprietaryIndexOptionFeesEffect))
+}
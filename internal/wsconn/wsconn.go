@@ -0,0 +1,388 @@
+package wsconn
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+type State int
+
+const (
+	Disconnected State = iota
+	Connecting
+	// socket open, protocol setup and auth in progress
+	Connected
+	// authorized and subscribed, set by the client with MarkReady
+	Ready
+	Reconnecting
+	Closed
+)
+
+func (s State) String() string {
+	switch s {
+	case Disconnected:
+		return "disconnected"
+	case Connecting:
+		return "connecting"
+	case Connected:
+		return "connected"
+	case Ready:
+		return "ready"
+	case Reconnecting:
+		return "reconnecting"
+	case Closed:
+		return "closed"
+	}
+	return "unknown"
+}
+
+type Event struct {
+	Name string
+	From State
+	To   State
+	// error that caused the transition, if any
+	Err     error
+	Attempt int
+	At      time.Time
+}
+
+var (
+	ErrGaveUp       = errors.New("reconnect attempts exhausted")
+	ErrNotConnected = errors.New("not connected")
+)
+
+// Socket is the subset of a websocket connection used by Conn
+type Socket interface {
+	ReadMessage() (int, []byte, error)
+	WriteMessage(int, []byte) error
+	SetReadDeadline(time.Time) error
+	Close() error
+}
+
+type Dialer func(ctx context.Context, url string) (Socket, error)
+
+func DefaultDialer(ctx context.Context, url string) (Socket, error) {
+	conn, _, err := websocket.DefaultDialer.DialContext(ctx, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	return conn, nil
+}
+
+// Handlers connect a streaming protocol to the connection manager
+type Handlers struct {
+	// OnConnect runs after every dial, before any message is read. It sends the
+	// setup and auth messages, so subscriptions are restored on every reconnect
+	OnConnect func(*Conn) error
+	// OnMessage is called in order for every message received
+	OnMessage func([]byte)
+	// Heartbeat returns the keepalive message sent every HeartbeatInterval
+	Heartbeat func() any
+}
+
+type Config struct {
+	Name string
+	URL  string
+	// 0 disables sending heartbeats
+	HeartbeatInterval time.Duration
+	// the connection is considered dead when nothing is received for this long, 0 disables
+	ReadTimeout time.Duration
+	MinBackoff  time.Duration
+	MaxBackoff  time.Duration
+	// reconnect attempts before giving up, 0 retries forever
+	MaxAttempts int
+	Dial        Dialer
+}
+
+// Conn keeps a websocket connected, reconnecting with jittered exponential backoff
+type Conn struct {
+	cfg      Config
+	handlers Handlers
+
+	mu     sync.Mutex
+	wmu    sync.Mutex
+	sock   Socket
+	state  State
+	err    error
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   chan struct{}
+	events chan Event
+}
+
+func New(cfg Config, handlers Handlers) *Conn {
+	if cfg.Dial == nil {
+		cfg.Dial = DefaultDialer
+	}
+	if cfg.MinBackoff <= 0 {
+		cfg.MinBackoff = time.Second
+	}
+	if cfg.MaxBackoff < cfg.MinBackoff {
+		cfg.MaxBackoff = 60 * time.Second
+	}
+	return &Conn{
+		cfg:      cfg,
+		handlers: handlers,
+		done:     make(chan struct{}),
+		events:   make(chan Event, 32),
+	}
+}
+
+// Start dials and sets up the first connection, returning its error. After that
+// the connection is maintained in the background until ctx is done or Close
+func (c *Conn) Start(ctx context.Context) error {
+	c.mu.Lock()
+	if c.ctx != nil {
+		c.mu.Unlock()
+		return fmt.Errorf("%s: already started", c.cfg.Name)
+	}
+	c.ctx, c.cancel = context.WithCancel(ctx)
+	c.mu.Unlock()
+
+	if err := c.connect(0); err != nil {
+		c.finish(err)
+		return err
+	}
+	go c.run()
+	return nil
+}
+
+func (c *Conn) State() State {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.state
+}
+
+// Events reports state changes, events are dropped if the channel is not drained
+func (c *Conn) Events() <-chan Event {
+	return c.events
+}
+
+// Done is closed when the connection is closed or gives up reconnecting
+func (c *Conn) Done() <-chan struct{} {
+	return c.done
+}
+
+// Err is the reason the connection stopped, nil after a Close
+func (c *Conn) Err() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.err
+}
+
+// MarkReady is called by the client once auth and subscriptions are complete
+func (c *Conn) MarkReady() {
+	c.setState(Ready, nil, 0)
+}
+
+// Send writes msg as JSON
+func (c *Conn) Send(msg any) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("error marshaling message: %w", err)
+	}
+	c.mu.Lock()
+	sock := c.sock
+	c.mu.Unlock()
+	if sock == nil {
+		return ErrNotConnected
+	}
+
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+	if err := sock.WriteMessage(websocket.TextMessage, data); err != nil {
+		return fmt.Errorf("error sending message: %w", err)
+	}
+	return nil
+}
+
+func (c *Conn) Close() error {
+	c.mu.Lock()
+	if c.ctx == nil {
+		c.mu.Unlock()
+		return ErrNotConnected
+	}
+	sock := c.sock
+	c.mu.Unlock()
+
+	if sock != nil {
+		c.wmu.Lock()
+		err := sock.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+		c.wmu.Unlock()
+		if err != nil {
+			slog.Debug("error sending close message", "conn", c.cfg.Name, "error", err)
+		}
+	}
+	c.cancel()
+	<-c.done
+	return nil
+}
+
+func (c *Conn) setState(to State, err error, attempt int) {
+	c.mu.Lock()
+	from := c.state
+	if from == to || from == Closed {
+		c.mu.Unlock()
+		return
+	}
+	c.state = to
+	c.mu.Unlock()
+
+	ev := Event{Name: c.cfg.Name, From: from, To: to, Err: err, Attempt: attempt, At: time.Now()}
+	if err != nil {
+		slog.Warn("websocket state change", "conn", c.cfg.Name, "from", from, "to", to, "attempt", attempt, "error", err)
+	} else {
+		slog.Info("websocket state change", "conn", c.cfg.Name, "from", from, "to", to, "attempt", attempt)
+	}
+	select {
+	case c.events <- ev:
+	default:
+	}
+}
+
+func (c *Conn) connect(attempt int) error {
+	c.setState(Connecting, nil, attempt)
+	sock, err := c.cfg.Dial(c.ctx, c.cfg.URL)
+	if err != nil {
+		return fmt.Errorf("%s dial error for url %s: %w", c.cfg.Name, c.cfg.URL, err)
+	}
+	c.mu.Lock()
+	c.sock = sock
+	c.mu.Unlock()
+	c.setState(Connected, nil, attempt)
+
+	if c.handlers.OnConnect != nil {
+		if err := c.handlers.OnConnect(c); err != nil {
+			c.dropSocket(sock)
+			return fmt.Errorf("%s setup failed: %w", c.cfg.Name, err)
+		}
+	}
+	return nil
+}
+
+func (c *Conn) dropSocket(sock Socket) {
+	c.mu.Lock()
+	if c.sock == sock {
+		c.sock = nil
+	}
+	c.mu.Unlock()
+	sock.Close()
+}
+
+func (c *Conn) run() {
+	for {
+		err := c.readLoop()
+		if c.ctx.Err() != nil {
+			c.finish(nil)
+			return
+		}
+		c.setState(Reconnecting, err, 0)
+		if err := c.reconnect(); err != nil {
+			c.finish(err)
+			return
+		}
+	}
+}
+
+func (c *Conn) reconnect() error {
+	var lastErr error
+	for attempt := 1; c.cfg.MaxAttempts == 0 || attempt <= c.cfg.MaxAttempts; attempt++ {
+		select {
+		case <-c.ctx.Done():
+			return nil
+		case <-time.After(c.backoff(attempt)):
+		}
+		lastErr = c.connect(attempt)
+		if lastErr == nil {
+			return nil
+		}
+		c.setState(Reconnecting, lastErr, attempt)
+	}
+	return fmt.Errorf("%s: %w: %w", c.cfg.Name, ErrGaveUp, lastErr)
+}
+
+// backoff doubles from MinBackoff up to MaxBackoff, randomized to between half
+// and all of the delay so clients don't reconnect in lockstep
+func (c *Conn) backoff(attempt int) time.Duration {
+	delay := c.cfg.MinBackoff
+	for i := 1; i < attempt && delay < c.cfg.MaxBackoff; i++ {
+		delay *= 2
+	}
+	delay = min(delay, c.cfg.MaxBackoff)
+	half := delay / 2
+	return half + rand.N(half+1)
+}
+
+// readLoop reads until the socket fails, the read deadline passes or ctx is done
+func (c *Conn) readLoop() error {
+	c.mu.Lock()
+	sock := c.sock
+	c.mu.Unlock()
+	if sock == nil {
+		return ErrNotConnected
+	}
+
+	connCtx, cancel := context.WithCancel(c.ctx)
+	defer cancel()
+	go func() {
+		// unblocks the read when the connection is closed
+		<-connCtx.Done()
+		c.dropSocket(sock)
+	}()
+	if c.cfg.HeartbeatInterval > 0 && c.handlers.Heartbeat != nil {
+		go c.heartbeat(connCtx)
+	}
+
+	for {
+		if c.cfg.ReadTimeout > 0 {
+			sock.SetReadDeadline(time.Now().Add(c.cfg.ReadTimeout))
+		}
+		_, msg, err := sock.ReadMessage()
+		if err != nil {
+			return fmt.Errorf("%s read: %w", c.cfg.Name, err)
+		}
+		if c.handlers.OnMessage != nil {
+			c.handlers.OnMessage(msg)
+		}
+	}
+}
+
+func (c *Conn) heartbeat(ctx context.Context) {
+	ticker := time.NewTicker(c.cfg.HeartbeatInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := c.Send(c.handlers.Heartbeat()); err != nil {
+				slog.Warn("unable to send heartbeat", "conn", c.cfg.Name, "error", err)
+			}
+		}
+	}
+}
+
+func (c *Conn) finish(err error) {
+	c.mu.Lock()
+	c.err = err
+	sock := c.sock
+	c.sock = nil
+	c.mu.Unlock()
+	if sock != nil {
+		sock.Close()
+	}
+	if err != nil {
+		c.setState(Disconnected, err, 0)
+	}
+	c.setState(Closed, err, 0)
+	c.cancel()
+	close(c.done)
+}
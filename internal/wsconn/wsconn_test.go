@@ -0,0 +1,142 @@
+package wsconn
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-playground/assert/v2"
+)
+
+// fakeSocket delivers queued messages and fails reads once they are drained and it is broken
+type fakeSocket struct {
+	mu     sync.Mutex
+	msgs   chan []byte
+	closed chan struct{}
+	once   sync.Once
+	sent   [][]byte
+}
+
+func newFakeSocket() *fakeSocket {
+	return &fakeSocket{msgs: make(chan []byte, 10), closed: make(chan struct{})}
+}
+
+func (s *fakeSocket) ReadMessage() (int, []byte, error) {
+	select {
+	case msg := <-s.msgs:
+		return 1, msg, nil
+	case <-s.closed:
+		return 0, nil, errors.New("socket closed")
+	}
+}
+
+func (s *fakeSocket) WriteMessage(_ int, data []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sent = append(s.sent, data)
+	return nil
+}
+
+func (s *fakeSocket) SetReadDeadline(time.Time) error { return nil }
+
+func (s *fakeSocket) Close() error {
+	s.once.Do(func() { close(s.closed) })
+	return nil
+}
+
+func TestReconnectResubscribes(t *testing.T) {
+	sockets := make(chan *fakeSocket, 4)
+	var connects int
+	var mu sync.Mutex
+	conn := New(Config{
+		Name:       "test",
+		MinBackoff: time.Millisecond,
+		MaxBackoff: 2 * time.Millisecond,
+		Dial: func(context.Context, string) (Socket, error) {
+			s := newFakeSocket()
+			sockets <- s
+			return s, nil
+		},
+	}, Handlers{
+		OnConnect: func(c *Conn) error {
+			mu.Lock()
+			connects++
+			mu.Unlock()
+			return c.Send(map[string]string{"type": "SETUP"})
+		},
+		OnMessage: func([]byte) {},
+	})
+
+	err := conn.Start(context.Background())
+	assert.Equal(t, err, nil)
+	first := <-sockets
+	conn.MarkReady()
+	assert.Equal(t, conn.State(), Ready)
+
+	// dropped connection is redialed and set up again
+	first.Close()
+	second := <-sockets
+	deadline := time.Now().Add(time.Second)
+	for conn.State() != Connected && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	assert.Equal(t, conn.State(), Connected)
+	mu.Lock()
+	assert.Equal(t, connects, 2)
+	mu.Unlock()
+	second.mu.Lock()
+	assert.Equal(t, string(second.sent[0]), `{"type":"SETUP"}`)
+	second.mu.Unlock()
+
+	assert.Equal(t, conn.Close(), nil)
+	assert.Equal(t, conn.State(), Closed)
+	assert.Equal(t, conn.Err(), nil)
+
+	var states []State
+	for len(conn.Events()) > 0 {
+		states = append(states, (<-conn.Events()).To)
+	}
+	assert.Equal(t, states, []State{Connecting, Connected, Ready, Reconnecting, Connecting, Connected, Closed})
+}
+
+func TestGiveUp(t *testing.T) {
+	dials := 0
+	conn := New(Config{
+		Name:        "test",
+		MinBackoff:  time.Millisecond,
+		MaxBackoff:  time.Millisecond,
+		MaxAttempts: 2,
+		Dial: func(context.Context, string) (Socket, error) {
+			dials++
+			if dials == 1 {
+				s := newFakeSocket()
+				s.Close()
+				return s, nil
+			}
+			return nil, errors.New("refused")
+		},
+	}, Handlers{})
+
+	assert.Equal(t, conn.Start(context.Background()), nil)
+	select {
+	case <-conn.Done():
+	case <-time.After(time.Second):
+		t.Fatal("connection did not give up")
+	}
+	assert.Equal(t, errors.Is(conn.Err(), ErrGaveUp), true)
+	assert.Equal(t, dials, 3)
+}
+
+func TestBackoff(t *testing.T) {
+	conn := New(Config{MinBackoff: time.Second, MaxBackoff: 8 * time.Second}, Handlers{})
+	for attempt, max := range map[int]time.Duration{1: time.Second, 3: 4 * time.Second, 10: 8 * time.Second} {
+		for range 20 {
+			d := conn.backoff(attempt)
+			if d < max/2 || d > max {
+				t.Fatalf("attempt %d backoff %s outside [%s, %s]", attempt, d, max/2, max)
+			}
+		}
+	}
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	//"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"maps"
@@ -15,13 +16,16 @@ import (
 	//"sync"
 	"time"
 
+	"github.com/jamesonhm/gochain/internal/accounts"
+	"github.com/jamesonhm/gochain/internal/calendar"
+	"github.com/jamesonhm/gochain/internal/clock"
 	"github.com/jamesonhm/gochain/internal/dt"
 	"github.com/jamesonhm/gochain/internal/dxlink"
-	"github.com/jamesonhm/gochain/internal/executor"
 	"github.com/jamesonhm/gochain/internal/monitor"
-	"github.com/jamesonhm/gochain/internal/strategy"
+	"github.com/jamesonhm/gochain/internal/scheduler"
 
 	//"github.com/jamesonhm/gochain/internal/options"
+	"github.com/jamesonhm/gochain/internal/strategy"
 	"github.com/jamesonhm/gochain/internal/tasty"
 	"github.com/jamesonhm/gochain/internal/yahoo"
 	"github.com/joho/godotenv"
@@ -33,6 +37,10 @@ func main() {
 	// determines wether an order is actually posted
 	var LIVE_ORDER bool = false
 	var PROD_ACCT bool = false
+	// days of transactions to rebuild journal trades from at startup, 0 to skip
+	var IMPORT_HISTORY_DAYS int = 0
+	// candle conditions read bars from the DxLink feed instead of the yahoo api
+	var DX_CANDLES bool = true
 
 	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
 	//slog.SetDefault(logger)
@@ -44,17 +52,20 @@ func main() {
 	// Env Variable Load
 	godotenv.Load()
 
-	yahooClient := yahoo.New(mustEnv("YAHOO_API_KEY"), 10*time.Second, 1*time.Second, 1, 10*time.Second)
-	move, err := yahooClient.ONMovePct("^VIX")
-	if err != nil {
-		logger.Error("unable to get overnight move for `^VIX`", "error", err)
-	}
-	fmt.Printf("VIX ON MOVE: %.2f\n", move)
-	intraday_move, err := yahooClient.IntradayMove("^VIX")
-	if err != nil {
-		logger.Error("unable to get intraday move for `^VIX`", "error", err)
+	var yahooClient *yahoo.YahooAPI
+	if !DX_CANDLES {
+		yahooClient = yahoo.New(mustEnv("YAHOO_API_KEY"), 10*time.Second, 1*time.Second, 1, 10*time.Second)
+		move, err := yahooClient.ONMovePct("^VIX")
+		if err != nil {
+			logger.Error("unable to get overnight move for `^VIX`", "error", err)
+		}
+		fmt.Printf("VIX ON MOVE: %.2f\n", move)
+		intraday_move, err := yahooClient.IntradayMove("^VIX")
+		if err != nil {
+			logger.Error("unable to get intraday move for `^VIX`", "error", err)
+		}
+		fmt.Printf("VIX Intraday MOVE: %.2f\n", intraday_move)
 	}
-	fmt.Printf("VIX Intraday MOVE: %.2f\n", intraday_move)
 
 	var tastyClient *tasty.TastyAPI
 	var login tasty.LoginInfo
@@ -75,12 +86,22 @@ func main() {
 			RememberMe: true,
 		}
 	}
-	err = tastyClient.CreateSession(ctx, login)
+	// remember tokens are single use, each login saves the next one
+	tastyClient.SetTokenStore(tasty.FileTokenStore("remember_token"))
+	err := tastyClient.Login(ctx, login)
 	if err != nil {
 		logger.LogAttrs(ctx, slog.LevelError, "Tasty Session", slog.String("error creating session", err.Error()))
 	}
 	logger.Info("Tasty Session", "tasty user", tastyClient.GetUser())
 
+	var clk clock.Clock = clock.Real{}
+
+	// the embedded holiday table is used when the broker's calendar can't be loaded
+	cal := calendar.New()
+	if err := cal.Refresh(ctx, tastyClient); err != nil {
+		logger.Warn("using embedded market calendar", "error", err)
+	}
+
 	accts, err := tastyClient.GetAccounts(ctx)
 	if err != nil {
 		logger.Error("unable to get tasty accounts", "error", err)
@@ -90,43 +111,9 @@ func main() {
 
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
-	acctErrChan := make(chan error, 1)
 	marketErrChan := make(chan error, 1)
 
-	acctNum := accts[0].Account.AccountNumber
-	//balance, err := tastyClient.GetAccountBalances(ctx, acctNum)
-	//if err != nil {
-	//	logger.Error("unable to get balance info", "error", err)
-	//} else {
-	//	bytes, _ := json.MarshalIndent(balance, "", "  ")
-	//	fmt.Println("Balance")
-	//	fmt.Println(string(bytes))
-	//}
-
-	stratStates := strategy.NewStatus("teststates.json")
-	//stratStates.PPrint()
-
-	// start account streamer
-	acctStreamer := tasty.NewAccountStreamer(
-		ctx,
-		acctNum,
-		tastyClient.GetToken(),
-		stratStates,
-		tastyClient.Env == tasty.TastyProd,
-	)
-
-	startAcctStream := func() {
-		err = acctStreamer.Connect()
-		if err != nil {
-			logger.Error("unable to connect to acccount streamer", "error", err)
-			acctErrChan <- err
-		}
-	}
-	if ACCT_STREAM {
-		go startAcctStream()
-	}
-
-	strats, err := loadStrategies()
+	strats, err := loadStrategies(clk, cal)
 	if err != nil {
 		logger.Error("unable to load strategies", "error", err)
 		return
@@ -138,21 +125,91 @@ func main() {
 		MKT_STREAM = false
 	}
 	streamClient := dxlink.New(ctx, streamer.DXLinkURL, streamer.Token)
+	streamClient.SetClock(clk)
+	tastyClient.OnQuoteToken(func(token tasty.QuoteStreamerToken) {
+		streamClient.SetToken(token.Token)
+	})
+	go tastyClient.KeepAlive(ctx, 15*time.Minute)
+
+	acctCfg, err := accounts.LoadConfig("accounts.json")
+	if err != nil {
+		logger.Warn("no accounts config, trading all accounts", "error", err)
+	}
+	registry := accounts.NewRegistry(acctCfg)
+	for _, a := range accts {
+		acctNum := a.Account.AccountNumber
+		if !acctCfg.Includes(acctNum) {
+			continue
+		}
+		// the pre-store state file belongs to the default account
+		var importFile string
+		if acctNum == acctCfg.DefaultNumber() || (acctCfg.DefaultNumber() == "" && len(registry.All()) == 0) {
+			importFile = "teststates.json"
+		}
+		// each account keeps its own state, streamer and executor
+		acct, err := accounts.New(ctx, acctNum, registry.Alias(acctNum), tastyClient, streamClient, cal, clk, accounts.Options{
+			StateFile:       fmt.Sprintf("states_%s.db", acctNum),
+			ImportFile:      importFile,
+			JournalFile:     fmt.Sprintf("journal_%s.db", acctNum),
+			MarkInterval:    time.Minute,
+			Workers:         2,
+			JobTimeout:      30 * time.Second,
+			LiveOrder:       LIVE_ORDER,
+			Stream:          ACCT_STREAM,
+			BalanceInterval: time.Minute,
+		})
+		if err != nil {
+			logger.Error("account not started", "account", acctNum, "error", err)
+			continue
+		}
+		acct.Start(ctx)
+		registry.Add(acct)
+	}
+
+	chains := func(ctx context.Context, underlying string) ([]string, error) {
+		chains, err := tastyClient.GetOptionCompact(ctx, underlying)
+		if err != nil {
+			return nil, err
+		}
+		var syms []string
+		for _, c := range chains {
+			syms = append(syms, c.StreamerSymbols...)
+		}
+		return syms, nil
+	}
+	// legs of open positions stay subscribed when the window moves away from them
+	keep := func() []string {
+		var syms []string
+		for _, acct := range registry.All() {
+			syms = append(syms, acct.OpenLegSymbols()...)
+		}
+		return syms
+	}
+	windows := dxlink.NewWindowManager(streamClient, chains, keep, cal.Holidays, clk)
 
 	// setup and run option streamer
 	startMarketStream := func() {
-		// Get curr market price for each tracked symbol
-		// To be used in filtering subscribed option symbols
+		// DTEs traded on each underlying
+		underlyingDTEs := make(map[string][]int)
+		for _, strat := range strats {
+			for _, dte := range strat.ListDTEs() {
+				if !slices.Contains(underlyingDTEs[strat.Underlying], dte) {
+					underlyingDTEs[strat.Underlying] = append(underlyingDTEs[strat.Underlying], dte)
+				}
+			}
+		}
+		underlyings := slices.Collect(maps.Keys(underlyingDTEs))
+
+		// Get curr market price for each tracked symbol to center the first option window,
+		// without one the window is centered on the first streamed trade
 		mktPrices := make(map[string]float64)
 		if tastyClient.Env == tasty.TastyProd {
 			mktParams := tasty.MarketDataParams{
-				Index: []string{"XSP"},
+				Index: underlyings,
 			}
 			mktData, err := tastyClient.GetMarketData(ctx, &mktParams)
 			if err != nil {
 				logger.Error("error getting Tasty Market Data", "error", err)
-				marketErrChan <- err
-				return
 			} else {
 				for _, item := range mktData {
 					flVal, err := strconv.ParseFloat(item.Last, 64)
@@ -163,83 +220,158 @@ func main() {
 					mktPrices[item.Symbol] = flVal
 				}
 			}
-		} else {
-			mktPrices["XSP"] = 658.75
 		}
 		fmt.Printf("Last Market Prices: %+v\n", mktPrices)
 
-		dteDates := make(map[int]time.Time)
-		for _, strat := range strats {
-			dtes := strat.ListDTEs()
-			for _, dte := range dtes {
-				dteDates[dte] = dt.DTEToDate(dte)
+		if DX_CANDLES {
+			// the VIX conditions read the VIX bars, moves use today's 1m bars over the 5m bars
+			now := clk.Now()
+			for _, sym := range append(slices.Clone(underlyings), "VIX") {
+				if err := streamClient.AddCandleSub(sym, dxlink.Candle1m, dt.Midnight(now)); err != nil {
+					logger.Error("unable to subscribe candles", "symbol", sym, "error", err)
+				}
+				if err := streamClient.AddCandleSub(sym, dxlink.Candle5m, dt.Midnight(dt.PreviousWeekday(now))); err != nil {
+					logger.Error("unable to subscribe candles", "symbol", sym, "error", err)
+				}
+				if err := streamClient.AddCandleSub(sym, dxlink.Candle1d, now.AddDate(0, 0, -10)); err != nil {
+					logger.Error("unable to subscribe candles", "symbol", sym, "error", err)
+				}
 			}
 		}
-		datesOnly := slices.Collect(maps.Values(dteDates))
 
-		chains, err := tastyClient.GetOptionCompact(ctx, "XSP")
+		err := streamClient.Connect()
 		if err != nil {
-			logger.Error("error getting option chains", "error", err)
+			logger.Error("error connecting to streaming client", "error", err)
 			marketErrChan <- err
 			return
 		}
-		for _, c := range chains {
-			fmt.Println(c.ExpirationType)
-			fmt.Println(c.StreamerSymbols[0:10])
-			fmt.Println("=============================================================")
-			err = streamClient.UpdateOptionSubs("XSP", c.StreamerSymbols, mktPrices["XSP"], 9, dxlink.FilterOptionsDates(datesOnly))
+
+		for _, underlying := range underlyings {
+			err := windows.Add(ctx, dxlink.Window{
+				Underlying:  underlying,
+				PctRange:    9,
+				RecenterPct: 1,
+				DTEs:        underlyingDTEs[underlying],
+			}, mktPrices[underlying], clk.Now())
 			if err != nil {
-				logger.Error("unable to update option subs", "error", err)
+				logger.Error("unable to subscribe option window", "underlying", underlying, "error", err)
 				marketErrChan <- err
 				return
 			}
 		}
-		if streamClient.LenOptionSubs() == 0 {
-			logger.Error("No Options after filtering")
-			marketErrChan <- fmt.Errorf("No options after filtering")
-			return
-		}
+		go windows.Run(ctx, 30*time.Second)
 
-		err = streamClient.Connect()
-		if err != nil {
-			logger.Error("error connecting to streaming client", "error", err)
+		// reconnects are handled by the client, only stop once it gives up
+		<-streamClient.Done()
+		if err := streamClient.Err(); err != nil {
 			marketErrChan <- err
-			return
 		}
 	}
 	if MKT_STREAM {
 		go startMarketStream()
 	}
 
-	executor := executor.NewEngine(tastyClient, acctNum, streamClient, stratStates, 1, ctx, LIVE_ORDER)
-
+	var candles strategy.CandlesProvider = streamClient.Candles()
+	if !DX_CANDLES {
+		candles = yahooClient
+	}
 	monitor := monitor.NewEngine(
-		tastyClient,
 		streamClient,
-		yahooClient,
-		executor,
-		stratStates,
+		candles,
+		cal,
+		clk,
 		5*time.Second,
 	)
 	for _, strat := range strats {
-		monitor.AddStrategy(strat)
+		acct, err := registry.Resolve(strat.Account)
+		if err != nil {
+			logger.Error("strategy not added", "strategy", strat.Name, "error", err)
+			continue
+		}
+		monitor.AddStrategy(strat, acct)
 	}
-	go monitor.Run(ctx)
+
+	if IMPORT_HISTORY_DAYS > 0 {
+		var names []string
+		for _, strat := range strats {
+			names = append(names, strat.Name)
+		}
+		now := clk.Now()
+		for _, acct := range registry.All() {
+			go acct.ImportHistory(ctx, now.AddDate(0, 0, -IMPORT_HISTORY_DAYS), now, names)
+		}
+	}
+
+	// each trading day logs in again, subscribes the day's expirations, runs the monitor
+	// for the session and settles the accounts after the close
+	prepDay := func(ctx context.Context, session calendar.Session) error {
+		// new tokens reach the streamers through the token listeners
+		if err := tastyClient.Login(ctx, login); err != nil {
+			return fmt.Errorf("login: %w", err)
+		}
+		if _, err := tastyClient.GetQuoteStreamerToken(ctx); err != nil {
+			return fmt.Errorf("quote streamer token: %w", err)
+		}
+		if err := cal.Refresh(ctx, tastyClient); err != nil {
+			logger.Warn("market calendar not refreshed", "error", err)
+		}
+		// a failing account stays disabled for the session without holding up the others
+		for _, acct := range registry.All() {
+			if err := acct.Prepare(ctx); err != nil {
+				logger.Error("account not prepared", "account", acct.String(), "error", err)
+			}
+		}
+		windows.Resume(ctx, clk.Now())
+		return nil
+	}
+	closeDay := func(ctx context.Context, session calendar.Session) error {
+		var errs []error
+		for _, acct := range registry.All() {
+			if err := acct.Reconcile(ctx); err != nil {
+				errs = append(errs, fmt.Errorf("account %s: %w", acct, err))
+			}
+			stats, err := acct.DailyReport(session.Open)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("account %s: %w", acct, err))
+				continue
+			}
+			logger.Info("Daily Report",
+				"account", acct.String(),
+				"session", session.Open.Format(time.DateOnly),
+				"trades", stats.Trades,
+				"wins", stats.Wins,
+				"losses", stats.Losses,
+				"net pnl", stats.NetPnL,
+				"fees", stats.Fees,
+			)
+		}
+		if err := windows.Suspend(); err != nil {
+			errs = append(errs, fmt.Errorf("unsubscribe option windows: %w", err))
+		}
+		return errors.Join(errs...)
+	}
+	sched := scheduler.New(cal, clk, scheduler.Hooks{
+		PreMarket: prepDay,
+		Open: func(ctx context.Context, session calendar.Session) {
+			monitor.Run(ctx)
+		},
+		PostClose: closeDay,
+	}, 30*time.Minute, 5*time.Minute)
+	go sched.Run(ctx)
 
 	select {
 	case sig := <-sigChan:
 		logger.Info("Gracefully shutting down", "Received signal:", sig)
 		cancel()
-	case acctErr := <-acctErrChan:
-		logger.Info("Account Streamer Shutting down...", "Error:", acctErr)
-		cancel()
 	case mktErr := <-marketErrChan:
 		logger.Info("Market Streamer Shutting down...", "Error:", mktErr)
 		cancel()
 	}
 
 	streamClient.Close()
-	acctStreamer.Close()
+	for _, acct := range registry.All() {
+		acct.Close()
+	}
 }
 
 func mustEnv(key string) string {
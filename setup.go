@@ -3,12 +3,14 @@ package main
 import (
 	"fmt"
 
+	"github.com/jamesonhm/gochain/internal/calendar"
+	"github.com/jamesonhm/gochain/internal/clock"
 	"github.com/jamesonhm/gochain/internal/strategy"
 )
 
-func loadStrategies() ([]strategy.Strategy, error) {
+func loadStrategies(clk clock.Clock, cal *calendar.Calendar) ([]strategy.Strategy, error) {
 	strats := make([]strategy.Strategy, 0)
-	conditionFactory := strategy.NewConditionFactory()
+	conditionFactory := strategy.NewConditionFactory(clk, cal)
 	strat, err := strategy.FromFile("examples/basic.json", conditionFactory)
 	if err != nil {
 		return nil, fmt.Errorf("err in strategy from file: %w", err)